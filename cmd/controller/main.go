@@ -29,7 +29,7 @@ import (
 func main() {
 	ctx, op := operator.NewOperator(coreoperator.NewOperator())
 
-	capiCloudProvider := clusterapi.NewCloudProvider(ctx, op.GetClient(), op.MachineProvider, op.MachineDeploymentProvider)
+	capiCloudProvider := clusterapi.NewCloudProvider(ctx, op.GetClient(), op.MachineProvider, op.ScalableResourceProvider, op.MachineTemplateProvider)
 	cloudProvider := metrics.Decorate(capiCloudProvider)
 	clusterState := state.NewCluster(op.Clock, op.GetClient(), cloudProvider)
 	op.
@@ -49,5 +49,9 @@ func main() {
 			op.GetClient(),
 			op.EventRecorder,
 			cloudProvider,
+			op.MachineProvider,
+			op.ManagementCluster,
+			capiCloudProvider,
+			capiCloudProvider,
 		)...).Start(ctx)
 }
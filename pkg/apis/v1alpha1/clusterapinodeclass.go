@@ -22,13 +22,168 @@ import (
 )
 
 // ClusterAPINodeClassSpec is the top level specification for ClusterAPINodeClasses.
+// +kubebuilder:validation:XValidation:rule="!has(self.clusterRef) || !has(self.machineTemplates) || (self.clusterRef.name == self.machineTemplates.clusterName && (!has(self.clusterRef.__namespace__) || self.clusterRef.__namespace__ == self.machineTemplates.__namespace__))",message="clusterRef must refer to the Cluster of machineTemplates"
 type ClusterAPINodeClassSpec struct {
+	// clusterRef is the workload Cluster of the NodeClass. Only the scalable resources with the
+	// cluster.x-k8s.io/cluster-name label of the Cluster are selected, so that the selector cannot match the
+	// resources of other Clusters in the management cluster. When not specified, the Cluster of machineTemplates or
+	// the Cluster from the --cluster-name controller option is used, and when neither is set the NodeClass is not
+	// ready.
+	// +optional
+	ClusterRef *ClusterReference `json:"clusterRef,omitempty"`
+
 	// scalableResourceSelector is a LabelSelector that is used to identify the Cluster API scalable
 	// resources that are participating in Karpenter provisioning. For a deeper discussion of
 	// how label selectors are used in Kubernetes, please see the following:
 	// https://kubernetes.io/docs/concepts/overview/working-with-objects/labels/
 	// https://kubernetes.io/docs/reference/kubernetes-api/common-definitions/label-selector/
 	ScalableResourceSelector *metav1.LabelSelector `json:"scalableResourceSelector,omitempty"`
+
+	// pricing configures the prices of the instance types created from the selected scalable resources.
+	// Karpenter uses the prices to choose between instance types and to decide when a node can be
+	// consolidated onto a cheaper one. When pricing is not specified, all instance types have a price of zero.
+	// +optional
+	Pricing *PricingSpec `json:"pricing,omitempty"`
+
+	// instanceTypeSelectionStrategy determines which instance type is used when more than one is compatible
+	// with a NodeClaim. Cheapest chooses the instance type with the lowest offering price, LeastWaste chooses the
+	// instance type with the least unused CPU and memory for the requests of the NodeClaim, Weighted chooses the
+	// instance type whose scalable resource has the highest weight annotation, and Random spreads NodeClaims across
+	// the compatible instance types. When not specified, the controller default is used.
+	// +kubebuilder:validation:Enum=Cheapest;LeastWaste;Weighted;Random
+	// +optional
+	InstanceTypeSelectionStrategy *InstanceTypeSelectionStrategy `json:"instanceTypeSelectionStrategy,omitempty"`
+
+	// kubelet contains the kubelet settings of the Nodes created from the selected scalable resources. Karpenter
+	// uses them to calculate the allocatable resources of an instance type. These settings are not applied to the
+	// kubelet, they should match the configuration in the bootstrap templates of the scalable resources.
+	// +optional
+	Kubelet *KubeletConfiguration `json:"kubelet,omitempty"`
+
+	// machineTemplates enables the ownerless Machine provisioning mode. In this mode the instance types are
+	// created from the selected InfrastructureMachineTemplates instead of the scalable resources, and Karpenter
+	// creates a Machine, which is not owned by a scalable resource, for each NodeClaim and deletes it with the
+	// NodeClaim. The scalableResourceSelector is not used when machineTemplates is specified.
+	// +optional
+	MachineTemplates *MachineTemplatesSpec `json:"machineTemplates,omitempty"`
+}
+
+// ClusterReference identifies a Cluster API Cluster in the management cluster.
+type ClusterReference struct {
+	// name is the name of the Cluster.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// namespace is the namespace of the Cluster, when it is not specified the resources with the name of the
+	// Cluster in all namespaces are selected.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// MachineTemplatesSpec selects the templates that are cloned to create the Machines of the ownerless
+// provisioning mode.
+type MachineTemplatesSpec struct {
+	// clusterName is the name of the Cluster that the Machines belong to. The failure domains of the Cluster
+	// are the zones of the instance types.
+	// +kubebuilder:validation:MinLength=1
+	ClusterName string `json:"clusterName"`
+
+	// namespace is the namespace of the Cluster, the templates and the Machines.
+	// +kubebuilder:validation:MinLength=1
+	Namespace string `json:"namespace"`
+
+	// version is the Kubernetes version of the Machines, for example "v1.33.1".
+	// +optional
+	Version *string `json:"version,omitempty"`
+
+	// infrastructureTemplates selects the InfrastructureMachineTemplates, each template with the
+	// node.cluster.x-k8s.io/karpenter-member label becomes an instance type.
+	InfrastructureTemplates TemplateSelector `json:"infrastructureTemplates"`
+
+	// bootstrapTemplates selects the bootstrap templates. An InfrastructureMachineTemplate uses the bootstrap
+	// template named by its bootstrap-template annotation, or the only selected bootstrap template when it does
+	// not have the annotation.
+	BootstrapTemplates TemplateSelector `json:"bootstrapTemplates"`
+}
+
+// TemplateSelector selects the templates of a single kind.
+type TemplateSelector struct {
+	// apiVersion is the API version of the templates, for example "infrastructure.cluster.x-k8s.io/v1beta1".
+	// +kubebuilder:validation:MinLength=1
+	APIVersion string `json:"apiVersion"`
+
+	// kind is the kind of the templates, for example "DockerMachineTemplate".
+	// +kubebuilder:validation:MinLength=1
+	Kind string `json:"kind"`
+
+	// selector is a LabelSelector for the templates, when it is not specified all the templates of the kind
+	// in the namespace are selected.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// KubeletConfiguration contains the kubelet settings that determine the resources which are not allocatable to pods.
+// Each setting can be overridden for a single scalable resource with an annotation, see the design documentation.
+type KubeletConfiguration struct {
+	// maxPods is the maximum number of pods that can run on a Node. It is used when the scalable resource does not
+	// have a maxPods capacity annotation. When not specified the kubelet default of 110 is used.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxPods *int32 `json:"maxPods,omitempty"`
+
+	// kubeReserved contains the resources reserved for Kubernetes system components, for example "cpu: 100m".
+	// +optional
+	KubeReserved map[string]string `json:"kubeReserved,omitempty"`
+
+	// systemReserved contains the resources reserved for operating system components, for example "memory: 100Mi".
+	// +optional
+	SystemReserved map[string]string `json:"systemReserved,omitempty"`
+
+	// evictionHard contains the hard eviction thresholds, for example "memory.available: 100Mi". The values may be
+	// quantities or percentages of the capacity. Only the memory.available and nodefs.available signals reduce the
+	// allocatable resources.
+	// +optional
+	EvictionHard map[string]string `json:"evictionHard,omitempty"`
+}
+
+// InstanceTypeSelectionStrategy is the name of a strategy for choosing between compatible instance types.
+type InstanceTypeSelectionStrategy string
+
+const (
+	InstanceTypeSelectionStrategyCheapest   InstanceTypeSelectionStrategy = "Cheapest"
+	InstanceTypeSelectionStrategyLeastWaste InstanceTypeSelectionStrategy = "LeastWaste"
+	InstanceTypeSelectionStrategyWeighted   InstanceTypeSelectionStrategy = "Weighted"
+	InstanceTypeSelectionStrategyRandom     InstanceTypeSelectionStrategy = "Random"
+)
+
+// InstanceTypeSelectionStrategies contains all of the known instance type selection strategies.
+var InstanceTypeSelectionStrategies = []InstanceTypeSelectionStrategy{
+	InstanceTypeSelectionStrategyCheapest,
+	InstanceTypeSelectionStrategyLeastWaste,
+	InstanceTypeSelectionStrategyWeighted,
+	InstanceTypeSelectionStrategyRandom,
+}
+
+// PricingSpec contains the sources used to calculate the price of an instance type. A price annotation
+// on the scalable resource takes precedence over the instanceTypePrices, which take precedence over the
+// price calculated from the cpuWeight and memoryWeight.
+type PricingSpec struct {
+	// instanceTypePrices maps the name of an instance type, as found in the node.kubernetes.io/instance-type
+	// label, to its price. Prices are decimal numbers, for example "0.096".
+	// +optional
+	InstanceTypePrices map[string]string `json:"instanceTypePrices,omitempty"`
+
+	// cpuWeight is the price of a single CPU, it is multiplied by the CPU capacity of an instance type
+	// and added to the memory price to calculate a synthetic price.
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	// +optional
+	CPUWeight *string `json:"cpuWeight,omitempty"`
+
+	// memoryWeight is the price of a single GiB of memory, it is multiplied by the memory capacity of an
+	// instance type and added to the CPU price to calculate a synthetic price.
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	// +optional
+	MemoryWeight *string `json:"memoryWeight,omitempty"`
 }
 
 // ClusterAPINodeClassStatus is the status for ClusterAPINodeClasses
@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 )
 
 func init() {
@@ -25,7 +26,9 @@ func init() {
 	karpv1.WellKnownLabels = karpv1.WellKnownLabels.Insert(
 		LabelInstanceMemory,
 		LabelInstanceCpu,
+		LabelReservationID,
 	)
+	cloudprovider.ReservationIDLabel = LabelReservationID
 }
 
 var (
@@ -34,6 +37,9 @@ var (
 	LabelInstanceMemory = CapacityGroup + "/memory"
 	LabelInstanceCpu    = CapacityGroup + "/cpu"
 
+	// LabelReservationID identifies the capacity reservation of a reserved offering.
+	LabelReservationID = Group + "/reservation-id"
+
 	// RestrictedLabelDomains are either prohibited by the kubelet or reserved by karpenter
 	RestrictedLabelDomains = []string{
 		Group,
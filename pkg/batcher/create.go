@@ -18,10 +18,12 @@ package batcher
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
+	"github.com/samber/lo"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/ptr"
@@ -31,29 +33,46 @@ import (
 
 	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers"
 	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machine"
-	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machinedeployment"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/scalableresource"
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 )
 
+// ErrNoMachineAvailable is returned for the requests of a create batch when not enough usable Machines appeared
+// in the scalable resource before the poll timed out, for example because the infrastructure provider is out of
+// capacity or the Machines failed.
+var ErrNoMachineAvailable = errors.New("no Machine available for NodeClaim")
+
 // CreateInput is the input to a single create request within a batch.
+// MachineDeploymentName and MachineDeploymentNS identify the scalable
+// resource of the Kind, an empty Kind is a MachineDeployment. APIVersion is
+// only needed for kinds which are not part of Cluster API. NodePoolName and
+// NodeClassName are recorded on the bound Machine with the NodeClaimName.
 type CreateInput struct {
 	NodeClaimName         string
+	NodePoolName          string
+	NodeClassName         string
 	MachineDeploymentName string
 	MachineDeploymentNS   string
+	Kind                  string
+	APIVersion            string
+}
+
+func (c CreateInput) reference() scalableresource.Reference {
+	return scalableresource.NewReference(c.APIVersion, c.Kind, c.MachineDeploymentName, c.MachineDeploymentNS)
 }
 
 func (c CreateInput) BatchKey() string {
-	return c.MachineDeploymentNS + "/" + c.MachineDeploymentName
+	return c.reference().Key()
 }
 
 // CreateOutput is the result of a successfully bound Machine.
 type CreateOutput struct {
-	MachineDeployment *capiv1beta1.MachineDeployment
-	Machine           *capiv1beta1.Machine
+	ScalableResource scalableresource.ScalableResource
+	Machine          *capiv1beta1.Machine
 }
 
 // CreateBatcher coalesces concurrent CloudProvider.Create calls targeting the
-// same MachineDeployment into a single replica increment + Machine poll cycle.
+// same scalable resource into a single replica increment + Machine poll cycle.
 type CreateBatcher struct {
 	batcher *Batcher[CreateInput, CreateOutput]
 }
@@ -62,7 +81,7 @@ func NewCreateBatcher(
 	ctx context.Context,
 	kubeClient client.Client,
 	machineProvider machine.Provider,
-	mdProvider machinedeployment.Provider,
+	scalableResourceProvider scalableresource.Provider,
 	mdLock *MDLockManager,
 ) *CreateBatcher {
 	options := Options[CreateInput, CreateOutput]{
@@ -70,7 +89,7 @@ func NewCreateBatcher(
 		IdleTimeout:   100 * time.Millisecond,
 		MaxTimeout:    1 * time.Second,
 		RequestHasher: BatchKeyHasher[CreateInput],
-		BatchExecutor: execCreateBatch(kubeClient, machineProvider, mdProvider, mdLock),
+		BatchExecutor: execCreateBatch(kubeClient, machineProvider, scalableResourceProvider, mdLock),
 	}
 	return &CreateBatcher{batcher: NewBatcher(ctx, options)}
 }
@@ -80,9 +99,11 @@ func (b *CreateBatcher) Add(ctx context.Context, input *CreateInput) Result[Crea
 }
 
 // execCreateBatch returns a BatchExecutor that provisions Machines for a set
-// of NodeClaims targeting the same MachineDeployment. The algorithm is:
+// of NodeClaims targeting the same scalable resource. The algorithm is the
+// same for all kinds of scalable resources, MachinePools are supported when
+// their infrastructure provider creates MachinePool Machines:
 //
-//  1. Lock the MachineDeployment and count existing unclaimed Machines (those
+//  1. Lock the scalable resource and count existing unclaimed Machines (those
 //     without the NodePoolMemberLabel). We only increment spec.replicas by the
 //     deficit (requested − unclaimed) so that leftover Machines from a previous
 //     batch are reused instead of leaked. This eliminates the need for an
@@ -95,6 +116,8 @@ func (b *CreateBatcher) Add(ctx context.Context, input *CreateInput) Result[Crea
 //
 // Requests that cannot be fulfilled (e.g. poll timeout, binding failure) are
 // returned with an error so the lifecycle controller can re-reconcile them.
+// Requests left without a Machine when the poll times out are returned with
+// ErrNoMachineAvailable. Failed Machines are never claimed.
 // Because we count unclaimed Machines on every batch, retries are cheap — we
 // won't increment replicas for Machines that already exist.
 //
@@ -111,7 +134,7 @@ func (b *CreateBatcher) Add(ctx context.Context, input *CreateInput) Result[Crea
 func execCreateBatch(
 	kubeClient client.Client,
 	machineProvider machine.Provider,
-	mdProvider machinedeployment.Provider,
+	scalableResourceProvider scalableresource.Provider,
 	mdLock *MDLockManager,
 ) BatchExecutor[CreateInput, CreateOutput] {
 	return func(ctx context.Context, inputs []*CreateInput) []Result[CreateOutput] {
@@ -122,36 +145,42 @@ func execCreateBatch(
 			return results
 		}
 
-		mdName := inputs[0].MachineDeploymentName
-		mdNS := inputs[0].MachineDeploymentNS
-		mdKey := mdNS + "/" + mdName
+		ref := inputs[0].reference()
+		mdKey := ref.Key()
 
 		// 1) Count unclaimed Machines and increment replicas by the deficit.
 		mdLock.Lock(mdKey)
-		md, err := mdProvider.Get(ctx, mdName, mdNS)
+		resource, err := scalableResourceProvider.Get(ctx, ref)
 		if err != nil {
 			mdLock.Unlock(mdKey)
 			for i := range results {
-				results[i] = Result[CreateOutput]{Err: fmt.Errorf("unable to get MachineDeployment %q: %w", mdName, err)}
+				results[i] = Result[CreateOutput]{Err: fmt.Errorf("unable to get %s %q: %w", ref.Kind, ref.Name, err)}
 			}
 			return results
 		}
 
-		unclaimed := countUnclaimedMachines(ctx, machineProvider, mdName, mdNS)
+		unclaimed, err := countUnclaimedMachines(ctx, machineProvider, scalableResourceProvider, resource)
+		if err != nil {
+			mdLock.Unlock(mdKey)
+			for i := range results {
+				results[i] = Result[CreateOutput]{Err: fmt.Errorf("unable to list the unclaimed Machines of %s %q: %w", ref.Kind, ref.Name, err)}
+			}
+			return results
+		}
 		deficit := int32(n) - int32(unclaimed)
 		if deficit < 0 {
 			deficit = 0
 		}
 
-		log.FromContext(ctx).V(1).Info("create batch", "machineDeployment", mdKey, "requests", n, "unclaimed", unclaimed, "deficit", deficit)
+		log.FromContext(ctx).V(1).Info("create batch", "kind", ref.Kind, "scalableResource", mdKey, "requests", n, "unclaimed", unclaimed, "deficit", deficit)
 
 		if deficit > 0 {
-			currentReplicas := ptr.Deref(md.Spec.Replicas, 0)
-			md.Spec.Replicas = ptr.To(currentReplicas + deficit)
-			if err := mdProvider.Update(ctx, md); err != nil {
+			currentReplicas := ptr.Deref(resource.Replicas(), 0)
+			resource.SetReplicas(currentReplicas + deficit)
+			if err := scalableResourceProvider.Update(ctx, resource); err != nil {
 				mdLock.Unlock(mdKey)
 				for i := range results {
-					results[i] = Result[CreateOutput]{Err: fmt.Errorf("unable to increment MachineDeployment %q replicas: %w", mdName, err)}
+					results[i] = Result[CreateOutput]{Err: fmt.Errorf("unable to update %s %q replicas: %w", ref.Kind, ref.Name, err)}
 				}
 				return results
 			}
@@ -159,7 +188,7 @@ func execCreateBatch(
 		mdLock.Unlock(mdKey)
 
 		// 2) Poll for N unclaimed Machines (unlocked; can take up to 30s).
-		machines := pollForNUnclaimedMachines(ctx, machineProvider, mdName, mdNS, n, 30*time.Second)
+		machines := pollForNUnclaimedMachines(ctx, machineProvider, scalableResourceProvider, resource, n, 30*time.Second)
 
 		// 3) Bind each Machine to a NodeClaim in parallel.
 		// TODO(maxcao13): Use wg.Go when we bump go.mod to 1.25
@@ -168,14 +197,14 @@ func execCreateBatch(
 			wg.Add(1)
 			go func(idx int) {
 				defer wg.Done()
-				results[idx] = bindMachineToNodeClaim(ctx, kubeClient, machineProvider, md, machines[idx], inputs[idx].NodeClaimName)
+				results[idx] = bindMachineToNodeClaim(ctx, kubeClient, machineProvider, resource, machines[idx], inputs[idx])
 			}(i)
 		}
 		wg.Wait()
 
 		for i, r := range results {
 			if r.Err == nil && r.Output == nil {
-				results[i] = Result[CreateOutput]{Err: fmt.Errorf("no Machine available for NodeClaim")}
+				results[i] = Result[CreateOutput]{Err: fmt.Errorf("%s %q: %w", ref.Kind, mdKey, ErrNoMachineAvailable)}
 			}
 		}
 
@@ -183,31 +212,28 @@ func execCreateBatch(
 	}
 }
 
-// pollForNUnclaimedMachines lists Machines belonging to the MachineDeployment
-// that have not yet been claimed (no NodePoolMemberLabel). It polls every
-// second until count Machines are found or the timeout elapses, returning
-// whatever has been collected so far.
+// unclaimedSelector selects the Machines which do not have the NodePoolMemberLabel.
+var unclaimedSelector = &metav1.LabelSelector{
+	MatchExpressions: []metav1.LabelSelectorRequirement{
+		{
+			Key:      providers.NodePoolMemberLabel,
+			Operator: metav1.LabelSelectorOpDoesNotExist,
+		},
+	},
+}
+
+// pollForNUnclaimedMachines lists Machines belonging to the scalable resource
+// that have not yet been claimed (no NodePoolMemberLabel) and have not
+// failed. It polls every second until count Machines are found or the timeout
+// elapses, returning whatever has been collected so far.
 func pollForNUnclaimedMachines(
 	ctx context.Context,
 	machineProvider machine.Provider,
-	mdName, mdNS string,
+	scalableResourceProvider scalableresource.Provider,
+	resource scalableresource.ScalableResource,
 	count int,
 	timeout time.Duration,
 ) []*capiv1beta1.Machine {
-	selector := &metav1.LabelSelector{
-		MatchExpressions: []metav1.LabelSelectorRequirement{
-			{
-				Key:      providers.NodePoolMemberLabel,
-				Operator: metav1.LabelSelectorOpDoesNotExist,
-			},
-			{
-				Key:      capiv1beta1.MachineDeploymentNameLabel,
-				Operator: metav1.LabelSelectorOpIn,
-				Values:   []string{mdName},
-			},
-		},
-	}
-
 	claimed := map[string]bool{}
 	var found []*capiv1beta1.Machine
 
@@ -216,7 +242,7 @@ func pollForNUnclaimedMachines(
 	defer ticker.Stop()
 
 	for {
-		machineList, err := machineProvider.List(ctx, mdNS, selector)
+		machineList, err := scalableResourceProvider.ListMachines(ctx, resource, unclaimedSelector)
 		if err == nil {
 			for _, m := range machineList {
 				if claimed[m.Name] {
@@ -228,6 +254,9 @@ func pollForNUnclaimedMachines(
 				if _, marked := m.GetAnnotations()[capiv1beta1.DeleteMachineAnnotation]; marked {
 					continue
 				}
+				if machineProvider.IsFailed(m) {
+					continue
+				}
 				claimed[m.Name] = true
 				found = append(found, m)
 				if len(found) >= count {
@@ -250,30 +279,19 @@ func pollForNUnclaimedMachines(
 	}
 }
 
-// countUnclaimedMachines returns the number of Machines in the
-// MachineDeployment that are not yet claimed and not pending deletion.
+// countUnclaimedMachines returns the number of Machines in the scalable
+// resource that are not yet claimed, not pending deletion and not failed.
+// Failed Machines are never claimed, so they are not counted and replicas are
+// incremented for their replacements.
 func countUnclaimedMachines(
 	ctx context.Context,
 	machineProvider machine.Provider,
-	mdName, mdNS string,
-) int {
-	selector := &metav1.LabelSelector{
-		MatchExpressions: []metav1.LabelSelectorRequirement{
-			{
-				Key:      providers.NodePoolMemberLabel,
-				Operator: metav1.LabelSelectorOpDoesNotExist,
-			},
-			{
-				Key:      capiv1beta1.MachineDeploymentNameLabel,
-				Operator: metav1.LabelSelectorOpIn,
-				Values:   []string{mdName},
-			},
-		},
-	}
-
-	machines, err := machineProvider.List(ctx, mdNS, selector)
+	scalableResourceProvider scalableresource.Provider,
+	resource scalableresource.ScalableResource,
+) (int, error) {
+	machines, err := scalableResourceProvider.ListMachines(ctx, resource, unclaimedSelector)
 	if err != nil {
-		return 0
+		return 0, err
 	}
 
 	count := 0
@@ -284,22 +302,28 @@ func countUnclaimedMachines(
 		if _, marked := m.GetAnnotations()[capiv1beta1.DeleteMachineAnnotation]; marked {
 			continue
 		}
+		if machineProvider.IsFailed(m) {
+			continue
+		}
 		count++
 	}
-	return count
+	return count, nil
 }
 
 // bindMachineToNodeClaim claims a Machine for a NodeClaim by labeling the
-// Machine with NodePoolMemberLabel and annotating the NodeClaim with the
+// Machine with NodePoolMemberLabel, annotating the Machine with the NodeClaim,
+// NodePool and NodeClass names, and annotating the NodeClaim with the
 // Machine reference.
 func bindMachineToNodeClaim(
 	ctx context.Context,
 	kubeClient client.Client,
 	machineProvider machine.Provider,
-	md *capiv1beta1.MachineDeployment,
+	resource scalableresource.ScalableResource,
 	m *capiv1beta1.Machine,
-	nodeClaimName string,
+	input *CreateInput,
 ) Result[CreateOutput] {
+	nodeClaimName := input.NodeClaimName
+	bindingAnnotations := providers.BindingAnnotations(input.NodeClaimName, input.NodePoolName, input.NodeClassName)
 	var fresh *capiv1beta1.Machine
 	var err error
 	for attempt := 0; attempt < 3; attempt++ {
@@ -314,6 +338,7 @@ func bindMachineToNodeClaim(
 		}
 		labels[providers.NodePoolMemberLabel] = ""
 		fresh.SetLabels(labels)
+		fresh.SetAnnotations(lo.Assign(fresh.GetAnnotations(), bindingAnnotations))
 		err = machineProvider.Update(ctx, fresh)
 		if err == nil {
 			break
@@ -338,6 +363,7 @@ func bindMachineToNodeClaim(
 			lbls := rollbackFresh.GetLabels()
 			delete(lbls, providers.NodePoolMemberLabel)
 			rollbackFresh.SetLabels(lbls)
+			rollbackFresh.SetAnnotations(lo.OmitByKeys(rollbackFresh.GetAnnotations(), lo.Keys(bindingAnnotations)))
 			if updateErr := machineProvider.Update(ctx, rollbackFresh); updateErr != nil {
 				log.FromContext(ctx).Error(updateErr, "create batch: unable to remove member label from Machine", "machine", rollbackFresh.Name)
 			}
@@ -345,5 +371,5 @@ func bindMachineToNodeClaim(
 		return Result[CreateOutput]{Err: fmt.Errorf("unable to annotate NodeClaim %q: %w", nodeClaimName, err)}
 	}
 
-	return Result[CreateOutput]{Output: &CreateOutput{MachineDeployment: md, Machine: fresh}}
+	return Result[CreateOutput]{Output: &CreateOutput{ScalableResource: resource, Machine: fresh}}
 }
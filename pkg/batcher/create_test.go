@@ -24,23 +24,28 @@ import (
 	. "github.com/onsi/gomega"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 
 	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/batcher"
 	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/scalableresource"
 )
 
 var _ = Describe("Create Batching", func() {
 	var (
 		fakeMP  *fakeMachineProvider
 		fakeMDP *fakeMDProvider
+		fakeMPP *fakeMPProvider
 	)
 
 	BeforeEach(func() {
 		fakeMP = newFakeMachineProvider()
 		fakeMDP = newFakeMDProvider()
+		fakeMPP = newFakeMPProvider()
 	})
 
 	// newCreateBatcher builds a CreateBatcher backed by a fake kube client
@@ -56,7 +61,7 @@ var _ = Describe("Create Batching", func() {
 			})
 		}
 		kubeClient := builder.Build()
-		return batcher.NewCreateBatcher(ctx, kubeClient, fakeMP, fakeMDP, batcher.NewMDLockManager()), kubeClient
+		return batcher.NewCreateBatcher(ctx, kubeClient, fakeMP, scalableresource.NewDefaultProvider(ctx, kubeClient, fakeMP, fakeMDP, fakeMPP), batcher.NewMDLockManager()), kubeClient
 	}
 
 	// expectMachineLabeled asserts that the Machine has the NodePoolMemberLabel.
@@ -271,6 +276,7 @@ var _ = Describe("Create Batching", func() {
 				expectNodeClaimAnnotated(kubeClient, ncNames[i], "default/"+r.Output.Machine.Name)
 				successes++
 			} else {
+				Expect(r.Err).To(MatchError(batcher.ErrNoMachineAvailable))
 				failures++
 			}
 		}
@@ -285,4 +291,199 @@ var _ = Describe("Create Batching", func() {
 		// Only one Update: the deficit increment.
 		Expect(fakeMDP.UpdateCallCount.Load()).To(BeNumerically("==", 1))
 	})
+
+	It("should not claim failed machines", func() {
+		// 2 unclaimed machines exist at replicas=2, one of them has failed.
+		// The healthy machine covers a single request, so no increment is
+		// needed and the healthy machine is claimed.
+		fakeMDP.AddMD(newMachineDeployment("md-0", "default", 2))
+		failed := newMachineForMD("machine-0", "default", "md-0")
+		failed.Status.SetTypedPhase(capiv1beta1.MachinePhaseFailed)
+		fakeMP.AddMachine(failed)
+		fakeMP.AddMachine(newMachineForMD("machine-1", "default", "md-0"))
+
+		cb, kubeClient := newCreateBatcher("nc-0")
+		r := cb.Add(ctx, &batcher.CreateInput{
+			NodeClaimName:         "nc-0",
+			MachineDeploymentName: "md-0",
+			MachineDeploymentNS:   "default",
+		})
+
+		Expect(r.Err).NotTo(HaveOccurred())
+		Expect(r.Output).NotTo(BeNil())
+		Expect(r.Output.Machine.Name).To(Equal("machine-1"))
+		expectNodeClaimAnnotated(kubeClient, "nc-0", "default/machine-1")
+		Expect(fakeMDP.UpdateCallCount.Load()).To(BeNumerically("==", 0))
+	})
+
+	It("should increment replicas to replace failed machines", func() {
+		// The only unclaimed machine has failed, so the request needs a new
+		// machine. It appears after the replicas are incremented.
+		fakeMDP.AddMD(newMachineDeployment("md-0", "default", 1))
+		failed := newMachineForMD("machine-0", "default", "md-0")
+		failed.Status.SetTypedPhase(capiv1beta1.MachinePhaseFailed)
+		fakeMP.AddMachine(failed)
+
+		go func() {
+			defer GinkgoRecover()
+			Eventually(fakeMDP.UpdateCallCount.Load).Should(BeNumerically("==", 1))
+			fakeMP.AddMachine(newMachineForMD("machine-1", "default", "md-0"))
+		}()
+
+		cb, kubeClient := newCreateBatcher("nc-0")
+		r := cb.Add(ctx, &batcher.CreateInput{
+			NodeClaimName:         "nc-0",
+			MachineDeploymentName: "md-0",
+			MachineDeploymentNS:   "default",
+		})
+
+		Expect(r.Err).NotTo(HaveOccurred())
+		Expect(r.Output.Machine.Name).To(Equal("machine-1"))
+		expectNodeClaimAnnotated(kubeClient, "nc-0", "default/machine-1")
+		Expect(*fakeMDP.GetMD("md-0", "default").Spec.Replicas).To(BeNumerically("==", 2))
+	})
+
+	It("should return an error without incrementing replicas when the machines cannot be listed", func() {
+		fakeMDP.AddMD(newMachineDeployment("md-0", "default", 1))
+		fakeMP.ListError = fmt.Errorf("connection refused")
+
+		cb, _ := newCreateBatcher("nc-0")
+		r := cb.Add(ctx, &batcher.CreateInput{
+			NodeClaimName:         "nc-0",
+			MachineDeploymentName: "md-0",
+			MachineDeploymentNS:   "default",
+		})
+
+		Expect(r.Err).To(MatchError(ContainSubstring("unable to list the unclaimed Machines of MachineDeployment \"md-0\"")))
+		Expect(fakeMDP.UpdateCallCount.Load()).To(BeNumerically("==", 0))
+	})
+
+	It("should annotate the claimed machine with the NodeClaim, NodePool and NodeClass", func() {
+		fakeMDP.AddMD(newMachineDeployment("md-0", "default", 1))
+		fakeMP.AddMachine(newMachineForMD("machine-0", "default", "md-0"))
+
+		cb, _ := newCreateBatcher("nc-0")
+		r := cb.Add(ctx, &batcher.CreateInput{
+			NodeClaimName:         "nc-0",
+			NodePoolName:          "default",
+			NodeClassName:         "nodeclass-0",
+			MachineDeploymentName: "md-0",
+			MachineDeploymentNS:   "default",
+		})
+
+		Expect(r.Err).NotTo(HaveOccurred())
+		m := fakeMP.GetMachine("machine-0", "default")
+		Expect(m.Annotations).To(HaveKeyWithValue(providers.NodeClaimAnnotation, "nc-0"))
+		Expect(m.Annotations).To(HaveKeyWithValue(providers.NodePoolAnnotation, "default"))
+		Expect(m.Annotations).To(HaveKeyWithValue(providers.NodeClassAnnotation, "nodeclass-0"))
+	})
+
+	It("should remove the label and annotations from the machine when the NodeClaim cannot be annotated", func() {
+		fakeMDP.AddMD(newMachineDeployment("md-0", "default", 1))
+		fakeMP.AddMachine(newMachineForMD("machine-0", "default", "md-0"))
+
+		// the NodeClaim does not exist, so the patch fails.
+		cb, _ := newCreateBatcher()
+		r := cb.Add(ctx, &batcher.CreateInput{
+			NodeClaimName:         "nc-0",
+			NodePoolName:          "default",
+			MachineDeploymentName: "md-0",
+			MachineDeploymentNS:   "default",
+		})
+
+		Expect(r.Err).To(HaveOccurred())
+		m := fakeMP.GetMachine("machine-0", "default")
+		Expect(m.Labels).NotTo(HaveKey(providers.NodePoolMemberLabel))
+		Expect(m.Annotations).NotTo(HaveKey(providers.NodeClaimAnnotation))
+		Expect(m.Annotations).NotTo(HaveKey(providers.NodePoolAnnotation))
+	})
+
+	It("should scale MachinePools and claim their machines", func() {
+		// MachinePool at 1 replica with 1 unclaimed machine, 2 requests
+		// should increment the MachinePool by the deficit of 1.
+		fakeMPP.AddMP(newMachinePool("mp-0", "default", 1))
+		fakeMP.AddMachine(newMachineForMP("machine-0", "default", "mp-0"))
+		// A MachineDeployment with the same name must not be touched.
+		fakeMDP.AddMD(newMachineDeployment("mp-0", "default", 0))
+
+		go func() {
+			defer GinkgoRecover()
+			Eventually(func() int32 {
+				return *fakeMPP.GetMP("mp-0", "default").Spec.Replicas
+			}).Should(BeNumerically("==", 2))
+			fakeMP.AddMachine(newMachineForMP("machine-1", "default", "mp-0"))
+		}()
+
+		ncNames := []string{"nc-0", "nc-1"}
+		cb, kubeClient := newCreateBatcher(ncNames...)
+
+		var wg sync.WaitGroup
+		results := make([]batcher.Result[batcher.CreateOutput], 2)
+		for i := range 2 {
+			wg.Add(1)
+			go func(idx int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				results[idx] = cb.Add(ctx, &batcher.CreateInput{
+					NodeClaimName:         ncNames[idx],
+					Kind:                  providers.MachinePoolKind,
+					MachineDeploymentName: "mp-0",
+					MachineDeploymentNS:   "default",
+				})
+			}(i)
+		}
+		wg.Wait()
+
+		for i, r := range results {
+			Expect(r.Err).NotTo(HaveOccurred())
+			Expect(r.Output).NotTo(BeNil())
+			Expect(r.Output.ScalableResource.Reference().Kind).To(Equal(providers.MachinePoolKind))
+			expectMachineLabeled(r.Output.Machine.Name, "default")
+			expectNodeClaimAnnotated(kubeClient, ncNames[i], "default/"+r.Output.Machine.Name)
+		}
+
+		Expect(fakeMPP.UpdateCallCount.Load()).To(BeNumerically("==", 1))
+		Expect(fakeMDP.GetCallCount.Load()).To(BeNumerically("==", 0))
+		Expect(*fakeMDP.GetMD("mp-0", "default").Spec.Replicas).To(BeNumerically("==", 0))
+	})
+
+	It("should scale standalone MachineSets", func() {
+		machineSet := &capiv1beta1.MachineSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "ms-0", Namespace: "default"},
+			Spec:       capiv1beta1.MachineSetSpec{Replicas: ptr.To(int32(0))},
+		}
+		kubeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+			machineSet,
+			&karpv1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Name: "nc-0"}},
+		).Build()
+		cb := batcher.NewCreateBatcher(ctx, kubeClient, fakeMP, scalableresource.NewDefaultProvider(ctx, kubeClient, fakeMP, fakeMDP, fakeMPP), batcher.NewMDLockManager())
+
+		go func() {
+			defer GinkgoRecover()
+			Eventually(func() int32 {
+				ms := &capiv1beta1.MachineSet{}
+				Expect(kubeClient.Get(ctx, client.ObjectKeyFromObject(machineSet), ms)).To(Succeed())
+				return ptr.Deref(ms.Spec.Replicas, 0)
+			}).Should(BeNumerically("==", 1))
+			m := &capiv1beta1.Machine{ObjectMeta: metav1.ObjectMeta{
+				Name:      "machine-0",
+				Namespace: "default",
+				Labels:    map[string]string{capiv1beta1.MachineSetNameLabel: "ms-0"},
+			}}
+			fakeMP.AddMachine(m)
+		}()
+
+		r := cb.Add(ctx, &batcher.CreateInput{
+			NodeClaimName:         "nc-0",
+			Kind:                  providers.MachineSetKind,
+			MachineDeploymentName: "ms-0",
+			MachineDeploymentNS:   "default",
+		})
+
+		Expect(r.Err).NotTo(HaveOccurred())
+		Expect(r.Output.Machine.Name).To(Equal("machine-0"))
+		Expect(r.Output.ScalableResource.Reference().Kind).To(Equal(providers.MachineSetKind))
+		expectNodeClaimAnnotated(kubeClient, "nc-0", "default/machine-0")
+		Expect(fakeMDP.GetCallCount.Load()).To(BeNumerically("==", 0))
+	})
 })
@@ -27,26 +27,35 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machine"
-	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machinedeployment"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/scalableresource"
 )
 
 // DeleteInput is the input to a single delete request within a batch.
+// MachineDeploymentName and MachineDeploymentNS identify the scalable
+// resource of the Kind, an empty Kind is a MachineDeployment. APIVersion is
+// only needed for kinds which are not part of Cluster API.
 type DeleteInput struct {
 	MachineName           string
 	MachineNamespace      string
 	MachineDeploymentName string
 	MachineDeploymentNS   string
+	Kind                  string
+	APIVersion            string
+}
+
+func (d DeleteInput) reference() scalableresource.Reference {
+	return scalableresource.NewReference(d.APIVersion, d.Kind, d.MachineDeploymentName, d.MachineDeploymentNS)
 }
 
 func (d DeleteInput) BatchKey() string {
-	return d.MachineDeploymentNS + "/" + d.MachineDeploymentName
+	return d.reference().Key()
 }
 
 // DeleteOutput is the result of a successful Machine deletion request.
 type DeleteOutput struct{}
 
 // DeleteBatcher coalesces concurrent CloudProvider.Delete calls targeting the
-// same MachineDeployment into a single replica decrement.
+// same scalable resource into a single replica decrement.
 type DeleteBatcher struct {
 	batcher *Batcher[DeleteInput, DeleteOutput]
 }
@@ -54,7 +63,7 @@ type DeleteBatcher struct {
 func NewDeleteBatcher(
 	ctx context.Context,
 	machineProvider machine.Provider,
-	mdProvider machinedeployment.Provider,
+	scalableResourceProvider scalableresource.Provider,
 	mdLock *MDLockManager,
 ) *DeleteBatcher {
 	options := Options[DeleteInput, DeleteOutput]{
@@ -62,7 +71,7 @@ func NewDeleteBatcher(
 		IdleTimeout:   100 * time.Millisecond,
 		MaxTimeout:    1 * time.Second,
 		RequestHasher: BatchKeyHasher[DeleteInput],
-		BatchExecutor: execDeleteBatch(machineProvider, mdProvider, mdLock),
+		BatchExecutor: execDeleteBatch(machineProvider, scalableResourceProvider, mdLock),
 	}
 	return &DeleteBatcher{batcher: NewBatcher(ctx, options)}
 }
@@ -72,14 +81,17 @@ func (b *DeleteBatcher) Add(ctx context.Context, input *DeleteInput) Result[Dele
 }
 
 // execDeleteBatch returns a BatchExecutor that deletes Machines from a
-// MachineDeployment. The algorithm is:
+// scalable resource. The algorithm is:
 //
 //  1. Annotate each Machine with the CAPI delete-machine annotation in
-//     parallel. This tells the MachineSet controller to prefer deleting these
-//     specific Machines when replicas are decremented.
-//  2. Lock the MachineDeployment and decrement spec.replicas by the number of
+//     parallel. This tells the MachineSet controller, or the controller of
+//     the scalable resource, to prefer deleting these specific Machines when
+//     replicas are decremented.
+//  2. Lock the scalable resource and decrement spec.replicas by the number of
 //     successfully annotated Machines. If the decrement fails, we roll back
-//     the annotations so the Machines are not orphaned.
+//     the annotations so the Machines are not orphaned. Requests which would
+//     take the resource below the minimum size in its cluster-autoscaler
+//     annotation are rejected, and their annotations rolled back, first.
 //
 // Concurrent create batches do not interfere: the replica read-modify-write
 // is serialized by MDLockManager, and create's poll skips Machines that carry
@@ -87,7 +99,7 @@ func (b *DeleteBatcher) Add(ctx context.Context, input *DeleteInput) Result[Dele
 // claimed by a concurrent create batch.
 func execDeleteBatch(
 	machineProvider machine.Provider,
-	mdProvider machinedeployment.Provider,
+	scalableResourceProvider scalableresource.Provider,
 	mdLock *MDLockManager,
 ) BatchExecutor[DeleteInput, DeleteOutput] {
 	return func(ctx context.Context, inputs []*DeleteInput) []Result[DeleteOutput] {
@@ -98,9 +110,8 @@ func execDeleteBatch(
 			return results
 		}
 
-		mdName := inputs[0].MachineDeploymentName
-		mdNS := inputs[0].MachineDeploymentNS
-		mdKey := mdNS + "/" + mdName
+		ref := inputs[0].reference()
+		mdKey := ref.Key()
 
 		// 1) Annotate each Machine for deletion (parallel, unlocked).
 		// TODO(maxcao13): Use wg.Go when we bump go.mod to 1.25
@@ -128,7 +139,7 @@ func execDeleteBatch(
 		// decrement replicas by the number that were successfully annotated.
 		successCount := int32(lo.CountBy(annotated, func(b bool) bool { return b }))
 
-		log.FromContext(ctx).V(1).Info("delete batch", "machineDeployment", mdKey, "requests", n, "annotated", successCount)
+		log.FromContext(ctx).V(1).Info("delete batch", "kind", ref.Kind, "scalableResource", mdKey, "requests", n, "annotated", successCount)
 
 		if successCount == 0 {
 			return results
@@ -136,33 +147,63 @@ func execDeleteBatch(
 
 		// 2) Lock and decrement replicas by the number of annotated Machines.
 		mdLock.Lock(mdKey)
-		md, err := mdProvider.Get(ctx, mdName, mdNS)
+		resource, err := scalableResourceProvider.Get(ctx, ref)
 		if err != nil {
 			mdLock.Unlock(mdKey)
-			log.FromContext(ctx).Error(err, "delete batch: unable to get MachineDeployment", "machineDeployment", mdName)
+			log.FromContext(ctx).Error(err, "delete batch: unable to get scalable resource", "kind", ref.Kind, "scalableResource", mdKey)
 			rollbackDeleteAnnotations(ctx, machineProvider, inputs, annotated)
 			for i := range results {
 				if annotated[i] {
-					results[i] = Result[DeleteOutput]{Err: fmt.Errorf("unable to get MachineDeployment %q for replica decrement: %w", mdName, err)}
+					results[i] = Result[DeleteOutput]{Err: fmt.Errorf("unable to get %s %q in namespace %q for replica decrement: %w", ref.Kind, ref.Name, ref.Namespace, err)}
+				}
+			}
+			return results
+		}
+
+		currentReplicas := ptr.Deref(resource.Replicas(), 0)
+
+		// Reject the requests which would take the resource below its minimum size. The first requests of the
+		// batch are kept, the annotations of the rejected Machines are removed before replicas are decremented so
+		// that the controller of the resource does not choose them.
+		if minSize, found := scalableresource.MinSize(ctx, resource); found && currentReplicas-successCount < minSize {
+			allowed := max(currentReplicas-minSize, 0)
+			rejected := make([]bool, n)
+			kept := int32(0)
+			for i := range annotated {
+				if !annotated[i] {
+					continue
+				}
+				if kept < allowed {
+					kept++
+					continue
 				}
+				rejected[i] = true
+				annotated[i] = false
+				results[i] = Result[DeleteOutput]{Err: fmt.Errorf("unable to delete Machine %q, %s %q would go below its minimum size of %d replicas", inputs[i].MachineName, ref.Kind, ref.Name, minSize)}
 			}
+			rollbackDeleteAnnotations(ctx, machineProvider, inputs, rejected)
+			log.FromContext(ctx).V(1).Info("delete batch: rejected requests below the minimum size", "kind", ref.Kind, "scalableResource", mdKey, "minSize", minSize, "rejected", successCount-allowed)
+			successCount = allowed
+		}
+
+		if successCount == 0 {
+			mdLock.Unlock(mdKey)
 			return results
 		}
 
-		currentReplicas := ptr.Deref(md.Spec.Replicas, 0)
 		newReplicas := currentReplicas - successCount
 		if newReplicas < 0 {
 			newReplicas = 0
 		}
-		md.Spec.Replicas = ptr.To(newReplicas)
+		resource.SetReplicas(newReplicas)
 
-		if err := mdProvider.Update(ctx, md); err != nil {
+		if err := scalableResourceProvider.Update(ctx, resource); err != nil {
 			mdLock.Unlock(mdKey)
-			log.FromContext(ctx).Error(err, "delete batch: unable to update MachineDeployment replicas", "machineDeployment", mdName)
+			log.FromContext(ctx).Error(err, "delete batch: unable to update replicas", "kind", ref.Kind, "scalableResource", mdKey)
 			rollbackDeleteAnnotations(ctx, machineProvider, inputs, annotated)
 			for i := range results {
 				if annotated[i] {
-					results[i] = Result[DeleteOutput]{Err: fmt.Errorf("unable to update MachineDeployment %q replicas: %w", mdName, err)}
+					results[i] = Result[DeleteOutput]{Err: fmt.Errorf("unable to update %s %q replicas: %w", ref.Kind, ref.Name, err)}
 				}
 			}
 			return results
@@ -17,6 +17,7 @@ limitations under the License.
 package batcher_test
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"sync/atomic"
@@ -26,19 +27,24 @@ import (
 	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
 
 	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/batcher"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/operator/options"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/scalableresource"
 )
 
 var _ = Describe("Delete Batching", func() {
 	var (
 		fakeMP  *fakeMachineProvider
 		fakeMDP *fakeMDProvider
+		fakeMPP *fakeMPProvider
 		db      *batcher.DeleteBatcher
 	)
 
 	BeforeEach(func() {
 		fakeMP = newFakeMachineProvider()
 		fakeMDP = newFakeMDProvider()
-		db = batcher.NewDeleteBatcher(ctx, fakeMP, fakeMDP, batcher.NewMDLockManager())
+		fakeMPP = newFakeMPProvider()
+		db = batcher.NewDeleteBatcher(ctx, fakeMP, scalableresource.NewDefaultProvider(ctx, nil, fakeMP, fakeMDP, fakeMPP), batcher.NewMDLockManager())
 	})
 
 	It("should batch the same MachineDeployment deletes into a single replica decrement", func() {
@@ -261,6 +267,7 @@ var _ = Describe("Delete Batching", func() {
 
 		for _, r := range results {
 			Expect(r.Err).To(HaveOccurred())
+			Expect(r.Err.Error()).To(ContainSubstring(`unable to get MachineDeployment "md-0" in namespace "default"`))
 			Expect(r.Err.Error()).To(ContainSubstring("simulated MD get failure"))
 		}
 		// Get was called but Update should never have been reached.
@@ -309,4 +316,125 @@ var _ = Describe("Delete Batching", func() {
 		// All 3 machines annotated for deletion.
 		Expect(fakeMP.AddDeleteAnnotationCount.Load()).To(BeNumerically("==", 3))
 	})
+
+	It("should decrement MachinePool replicas", func() {
+		fakeMPP.AddMP(newMachinePool("mp-0", "default", 3))
+		for i := range 2 {
+			fakeMP.AddMachine(newMachineForMP(fmt.Sprintf("machine-%d", i), "default", "mp-0"))
+		}
+
+		var wg sync.WaitGroup
+		results := make([]batcher.Result[batcher.DeleteOutput], 2)
+		for i := range 2 {
+			wg.Add(1)
+			go func(idx int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				results[idx] = db.Add(ctx, &batcher.DeleteInput{
+					MachineName:           fmt.Sprintf("machine-%d", idx),
+					MachineNamespace:      "default",
+					Kind:                  providers.MachinePoolKind,
+					MachineDeploymentName: "mp-0",
+					MachineDeploymentNS:   "default",
+				})
+			}(i)
+		}
+		wg.Wait()
+
+		for _, r := range results {
+			Expect(r.Err).NotTo(HaveOccurred())
+			Expect(r.Output).NotTo(BeNil())
+		}
+		mp := fakeMPP.GetMP("mp-0", "default")
+		Expect(mp).NotTo(BeNil())
+		Expect(*mp.Spec.Replicas).To(BeNumerically("==", 1))
+		Expect(fakeMPP.UpdateCallCount.Load()).To(BeNumerically("==", 1))
+		Expect(fakeMDP.GetCallCount.Load()).To(BeNumerically("==", 0))
+		Expect(fakeMP.AddDeleteAnnotationCount.Load()).To(BeNumerically("==", 2))
+	})
+
+	// deleteMachines sends concurrent delete requests for the Machines of the MachineDeployment and returns the results.
+	deleteMachines := func(ctx context.Context, mdName string, count int) []batcher.Result[batcher.DeleteOutput] {
+		GinkgoHelper()
+		var wg sync.WaitGroup
+		results := make([]batcher.Result[batcher.DeleteOutput], count)
+		for i := range count {
+			wg.Add(1)
+			go func(idx int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				results[idx] = db.Add(ctx, &batcher.DeleteInput{
+					MachineName:           fmt.Sprintf("machine-%d", idx),
+					MachineNamespace:      "default",
+					MachineDeploymentName: mdName,
+					MachineDeploymentNS:   "default",
+				})
+			}(i)
+		}
+		wg.Wait()
+		return results
+	}
+
+	It("should reject only the deletes which would go below the min size", func() {
+		md := newMachineDeployment("md-0", "default", 3)
+		md.Annotations = map[string]string{capiv1beta1.AutoscalerMinSizeAnnotation: "1"}
+		fakeMDP.AddMD(md)
+		for i := range 3 {
+			fakeMP.AddMachine(newMachineForMD(fmt.Sprintf("machine-%d", i), "default", "md-0"))
+		}
+
+		results := deleteMachines(ctx, "md-0", 3)
+
+		succeeded, rejected := 0, 0
+		for _, r := range results {
+			if r.Err == nil {
+				succeeded++
+				continue
+			}
+			Expect(r.Err).To(MatchError(ContainSubstring("would go below its minimum size of 1 replicas")))
+			rejected++
+		}
+		Expect(succeeded).To(Equal(2))
+		Expect(rejected).To(Equal(1))
+
+		Expect(*fakeMDP.GetMD("md-0", "default").Spec.Replicas).To(BeNumerically("==", 1))
+		Expect(fakeMP.RemoveDeleteAnnotationCount.Load()).To(BeNumerically("==", 1))
+		annotated := 0
+		for i := range 3 {
+			if _, found := fakeMP.GetMachine(fmt.Sprintf("machine-%d", i), "default").Annotations[capiv1beta1.DeleteMachineAnnotation]; found {
+				annotated++
+			}
+		}
+		Expect(annotated).To(Equal(2))
+	})
+
+	It("should not update replicas when the MachineDeployment is at its min size", func() {
+		md := newMachineDeployment("md-0", "default", 2)
+		md.Annotations = map[string]string{capiv1beta1.AutoscalerMinSizeAnnotation: "2"}
+		fakeMDP.AddMD(md)
+		for i := range 2 {
+			fakeMP.AddMachine(newMachineForMD(fmt.Sprintf("machine-%d", i), "default", "md-0"))
+		}
+
+		for _, r := range deleteMachines(ctx, "md-0", 2) {
+			Expect(r.Err).To(HaveOccurred())
+		}
+		Expect(fakeMDP.UpdateCallCount.Load()).To(BeNumerically("==", 0))
+		Expect(*fakeMDP.GetMD("md-0", "default").Spec.Replicas).To(BeNumerically("==", 2))
+	})
+
+	It("should ignore the min size when the check is disabled", func() {
+		md := newMachineDeployment("md-0", "default", 2)
+		md.Annotations = map[string]string{capiv1beta1.AutoscalerMinSizeAnnotation: "2"}
+		fakeMDP.AddMD(md)
+		for i := range 2 {
+			fakeMP.AddMachine(newMachineForMD(fmt.Sprintf("machine-%d", i), "default", "md-0"))
+		}
+
+		opts := &options.Options{DisableMinSizeCheck: true}
+		for _, r := range deleteMachines(opts.ToContext(ctx), "md-0", 2) {
+			Expect(r.Err).NotTo(HaveOccurred())
+		}
+		Expect(*fakeMDP.GetMD("md-0", "default").Spec.Replicas).To(BeNumerically("==", 0))
+	})
 })
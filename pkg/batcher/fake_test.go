@@ -25,6 +25,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/ptr"
 	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	expv1beta1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
 )
 
 // fakeMachineProvider implements machine.Provider for unit tests.
@@ -118,6 +119,10 @@ func (f *fakeMachineProvider) IsDeleting(m *capiv1beta1.Machine) bool {
 	return m != nil && !m.GetDeletionTimestamp().IsZero()
 }
 
+func (f *fakeMachineProvider) IsFailed(m *capiv1beta1.Machine) bool {
+	return m != nil && (m.Status.GetTypedPhase() == capiv1beta1.MachinePhaseFailed || m.Status.FailureReason != nil || m.Status.FailureMessage != nil)
+}
+
 func (f *fakeMachineProvider) AddDeleteAnnotation(_ context.Context, m *capiv1beta1.Machine) error {
 	f.AddDeleteAnnotationCount.Add(1)
 	if f.AddDeleteAnnotationError != nil {
@@ -166,6 +171,20 @@ func (f *fakeMachineProvider) Update(_ context.Context, m *capiv1beta1.Machine)
 	return nil
 }
 
+func (f *fakeMachineProvider) Create(_ context.Context, m *capiv1beta1.Machine) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.machines[f.key(m.Namespace, m.Name)] = m.DeepCopy()
+	return nil
+}
+
+func (f *fakeMachineProvider) Delete(_ context.Context, m *capiv1beta1.Machine) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.machines, f.key(m.Namespace, m.Name))
+	return nil
+}
+
 func (f *fakeMachineProvider) Reset() {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -258,6 +277,67 @@ func (f *fakeMDProvider) Reset() {
 	f.UpdateError = nil
 }
 
+// fakeMPProvider implements machinepool.Provider for unit tests.
+type fakeMPProvider struct {
+	mu  sync.Mutex
+	mps map[string]*expv1beta1.MachinePool // keyed by "namespace/name"
+
+	GetCallCount    atomic.Int64
+	UpdateCallCount atomic.Int64
+}
+
+func newFakeMPProvider() *fakeMPProvider {
+	return &fakeMPProvider{
+		mps: make(map[string]*expv1beta1.MachinePool),
+	}
+}
+
+func (f *fakeMPProvider) key(ns, name string) string {
+	return ns + "/" + name
+}
+
+func (f *fakeMPProvider) AddMP(mp *expv1beta1.MachinePool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.mps[f.key(mp.Namespace, mp.Name)] = mp.DeepCopy()
+}
+
+func (f *fakeMPProvider) GetMP(name, ns string) *expv1beta1.MachinePool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if mp, ok := f.mps[f.key(ns, name)]; ok {
+		return mp.DeepCopy()
+	}
+	return nil
+}
+
+func (f *fakeMPProvider) Get(_ context.Context, name string, namespace string) (*expv1beta1.MachinePool, error) {
+	f.GetCallCount.Add(1)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	mp, ok := f.mps[f.key(namespace, name)]
+	if !ok {
+		return nil, fmt.Errorf("machinepool %s/%s not found", namespace, name)
+	}
+	return mp.DeepCopy(), nil
+}
+
+func (f *fakeMPProvider) GetForMachine(_ context.Context, _ *capiv1beta1.Machine) (*expv1beta1.MachinePool, error) {
+	return nil, fmt.Errorf("not implemented in fake")
+}
+
+func (f *fakeMPProvider) List(_ context.Context, _ *metav1.LabelSelector) ([]*expv1beta1.MachinePool, error) {
+	return nil, fmt.Errorf("not implemented in fake")
+}
+
+func (f *fakeMPProvider) Update(_ context.Context, mp *expv1beta1.MachinePool) error {
+	f.UpdateCallCount.Add(1)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.mps[f.key(mp.Namespace, mp.Name)] = mp.DeepCopy()
+	return nil
+}
+
 // labelSet adapts a map[string]string to labels.Labels for selector matching.
 type labelSet map[string]string
 
@@ -295,3 +375,29 @@ func newMachineForMD(name, namespace, mdName string) *capiv1beta1.Machine {
 		},
 	}
 }
+
+// newMachinePool creates a MachinePool with the given name, namespace, and replicas.
+func newMachinePool(name, namespace string, replicas int32) *expv1beta1.MachinePool {
+	return &expv1beta1.MachinePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: expv1beta1.MachinePoolSpec{
+			Replicas: ptr.To(replicas),
+		},
+	}
+}
+
+// newMachineForMP creates a Machine belonging to the given MachinePool.
+func newMachineForMP(name, namespace, mpName string) *capiv1beta1.Machine {
+	return &capiv1beta1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				capiv1beta1.MachinePoolNameLabel: mpName,
+			},
+		},
+	}
+}
@@ -18,9 +18,9 @@ package batcher
 
 import "sync"
 
-// MDLockManager provides per-MachineDeployment mutexes so that create and
-// delete batch executors serialise their replica read-modify-write cycles
-// on the same MachineDeployment.
+// MDLockManager provides per-MachineDeployment (or MachinePool) mutexes so
+// that create and delete batch executors serialise their replica
+// read-modify-write cycles on the same scalable resource.
 type MDLockManager struct {
 	mu    sync.Mutex
 	locks map[string]*sync.Mutex
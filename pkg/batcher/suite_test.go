@@ -31,6 +31,7 @@ import (
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 
 	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/batcher"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/scalableresource"
 )
 
 var ctx context.Context
@@ -78,10 +79,12 @@ var _ = Describe("Concurrent Create and Delete", func() {
 				ObjectMeta: metav1.ObjectMeta{Name: name},
 			})
 		}
-		cb := batcher.NewCreateBatcher(ctx, builder.Build(), fakeMP, fakeMDP, mdLock)
+		kubeClient := builder.Build()
+		scalableResourceProvider := scalableresource.NewDefaultProvider(ctx, kubeClient, fakeMP, fakeMDP, newFakeMPProvider())
+		cb := batcher.NewCreateBatcher(ctx, kubeClient, fakeMP, scalableResourceProvider, mdLock)
 
 		// Delete batcher: remove 2 existing claimed machines.
-		db := batcher.NewDeleteBatcher(ctx, fakeMP, fakeMDP, mdLock)
+		db := batcher.NewDeleteBatcher(ctx, fakeMP, scalableResourceProvider, mdLock)
 
 		var wg sync.WaitGroup
 
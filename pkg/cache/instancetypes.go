@@ -0,0 +1,122 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// InstanceTypesTTL is the time the instance types of a NodeClass are cached without being invalidated. It
+	// bounds how long changes which are not watched, such as the status of an InfrastructureMachineTemplate,
+	// take to be seen.
+	InstanceTypesTTL = 5 * time.Minute
+	// InstanceTypesCleanupInterval is the interval at which expired entries are removed.
+	InstanceTypesCleanupInterval = 1 * time.Minute
+)
+
+// InstanceTypes stores the instance types of each ClusterAPINodeClass, so that they are not rebuilt from the
+// scalable resources on every call. Entries are invalidated by watch events for the NodeClass and its scalable
+// resources. Each NodeClass has a sequence number which increases on every invalidation of its instance types, so
+// that callers can tell when they may have changed without being affected by the invalidations of other NodeClasses.
+type InstanceTypes[T any] struct {
+	mu    sync.Mutex
+	cache *cache.Cache
+	// seqNums are the number of invalidations of each NodeClass, and allSeqNum is the number of invalidations of all
+	// NodeClasses. Both only increase, so their sum changes on every invalidation of a NodeClass.
+	seqNums   map[string]uint64
+	allSeqNum uint64
+}
+
+type instanceTypesEntry[T any] struct {
+	uid           types.UID
+	generation    int64
+	instanceTypes []T
+}
+
+func NewInstanceTypes[T any]() *InstanceTypes[T] {
+	return &InstanceTypes[T]{
+		cache:   cache.New(InstanceTypesTTL, InstanceTypesCleanupInterval),
+		seqNums: map[string]uint64{},
+	}
+}
+
+// Get returns the cached instance types of the NodeClass, an entry for an older generation of the NodeClass
+// is not returned.
+func (i *InstanceTypes[T]) Get(nodeClass metav1.Object) ([]T, bool) {
+	value, found := i.cache.Get(nodeClass.GetName())
+	if !found {
+		return nil, false
+	}
+	entry := value.(*instanceTypesEntry[T])
+	if entry.uid != nodeClass.GetUID() || entry.generation != nodeClass.GetGeneration() {
+		return nil, false
+	}
+	return entry.instanceTypes, true
+}
+
+// Set stores the instance types of the NodeClass. The seqNum is the value of SeqNum for the NodeClass from before
+// the instance types were built, they are not stored if the NodeClass was invalidated while they were being built.
+func (i *InstanceTypes[T]) Set(nodeClass metav1.Object, seqNum uint64, instanceTypes []T) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if seqNum != i.seqNum(nodeClass.GetName()) {
+		return
+	}
+	i.cache.SetDefault(nodeClass.GetName(), &instanceTypesEntry[T]{
+		uid:           nodeClass.GetUID(),
+		generation:    nodeClass.GetGeneration(),
+		instanceTypes: instanceTypes,
+	})
+}
+
+// Invalidate removes the instance types of the NodeClass with the name.
+func (i *InstanceTypes[T]) Invalidate(name string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.seqNums[name]++
+	i.cache.Delete(name)
+}
+
+// InvalidateAll removes the instance types of all NodeClasses.
+func (i *InstanceTypes[T]) InvalidateAll() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.allSeqNum++
+	i.cache.Flush()
+}
+
+// SeqNum returns the sequence number of the NodeClass with the name, it changes whenever its cached instance types
+// are invalidated.
+func (i *InstanceTypes[T]) SeqNum(name string) uint64 {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.seqNum(name)
+}
+
+func (i *InstanceTypes[T]) seqNum(name string) uint64 {
+	return i.allSeqNum + i.seqNums[name]
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/patrickmn/go-cache"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("InstanceTypes", func() {
+	var instanceTypes *InstanceTypes[string]
+	var nodeClass *metav1.ObjectMeta
+
+	BeforeEach(func() {
+		instanceTypes = NewInstanceTypes[string]()
+		nodeClass = &metav1.ObjectMeta{Name: "default", UID: "uid-1", Generation: 1}
+	})
+
+	It("returns nothing for a NodeClass which is not cached", func() {
+		_, found := instanceTypes.Get(nodeClass)
+		Expect(found).To(BeFalse())
+	})
+
+	It("returns the instance types which were set for the NodeClass", func() {
+		instanceTypes.Set(nodeClass, instanceTypes.SeqNum(nodeClass.Name), []string{"small", "large"})
+
+		cached, found := instanceTypes.Get(nodeClass)
+		Expect(found).To(BeTrue())
+		Expect(cached).To(Equal([]string{"small", "large"}))
+	})
+
+	It("does not return the instance types of another generation or object with the same name", func() {
+		instanceTypes.Set(nodeClass, instanceTypes.SeqNum(nodeClass.Name), []string{"small"})
+
+		_, found := instanceTypes.Get(&metav1.ObjectMeta{Name: "default", UID: "uid-1", Generation: 2})
+		Expect(found).To(BeFalse())
+		_, found = instanceTypes.Get(&metav1.ObjectMeta{Name: "default", UID: "uid-2", Generation: 1})
+		Expect(found).To(BeFalse())
+	})
+
+	It("does not return the instance types after they are invalidated", func() {
+		other := &metav1.ObjectMeta{Name: "other", UID: "uid-2", Generation: 1}
+		instanceTypes.Set(nodeClass, instanceTypes.SeqNum(nodeClass.Name), []string{"small"})
+		instanceTypes.Set(other, instanceTypes.SeqNum(other.Name), []string{"large"})
+
+		seqNum := instanceTypes.SeqNum(nodeClass.Name)
+		instanceTypes.Invalidate(nodeClass.Name)
+		Expect(instanceTypes.SeqNum(nodeClass.Name)).To(BeNumerically(">", seqNum))
+		_, found := instanceTypes.Get(nodeClass)
+		Expect(found).To(BeFalse())
+		_, found = instanceTypes.Get(other)
+		Expect(found).To(BeTrue())
+
+		instanceTypes.InvalidateAll()
+		_, found = instanceTypes.Get(other)
+		Expect(found).To(BeFalse())
+	})
+
+	It("does not store instance types which were built before an invalidation", func() {
+		seqNum := instanceTypes.SeqNum(nodeClass.Name)
+		instanceTypes.Invalidate(nodeClass.Name)
+		instanceTypes.Set(nodeClass, seqNum, []string{"small"})
+
+		_, found := instanceTypes.Get(nodeClass)
+		Expect(found).To(BeFalse())
+	})
+
+	It("does not store instance types which were built before all NodeClasses were invalidated", func() {
+		seqNum := instanceTypes.SeqNum(nodeClass.Name)
+		instanceTypes.InvalidateAll()
+		instanceTypes.Set(nodeClass, seqNum, []string{"small"})
+
+		_, found := instanceTypes.Get(nodeClass)
+		Expect(found).To(BeFalse())
+	})
+
+	It("stores instance types which were built while another NodeClass was invalidated", func() {
+		seqNum := instanceTypes.SeqNum(nodeClass.Name)
+		instanceTypes.Invalidate("other")
+		instanceTypes.Set(nodeClass, seqNum, []string{"small"})
+
+		cached, found := instanceTypes.Get(nodeClass)
+		Expect(found).To(BeTrue())
+		Expect(cached).To(Equal([]string{"small"}))
+	})
+
+	It("does not return the instance types after they expire", func() {
+		instanceTypes.cache = cache.New(10*time.Millisecond, time.Minute)
+		instanceTypes.Set(nodeClass, instanceTypes.SeqNum(nodeClass.Name), []string{"small"})
+		Eventually(func() bool {
+			_, found := instanceTypes.Get(nodeClass)
+			return found
+		}).Should(BeFalse())
+	})
+})
@@ -0,0 +1,76 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// UnavailableOfferingsTTL is the time an offering stays unavailable after a failed launch.
+	UnavailableOfferingsTTL = 3 * time.Minute
+	// UnavailableOfferingsCleanupInterval is the interval at which expired entries are removed.
+	UnavailableOfferingsCleanupInterval = 1 * time.Minute
+)
+
+// UnavailableOfferings stores the MachineDeployments and zones where Machines could not be launched. Offerings
+// in this cache are reported as unavailable to Karpenter until the entry expires, so that it can choose another
+// offering instead of repeating the failed launch.
+type UnavailableOfferings struct {
+	cache *cache.Cache
+}
+
+func NewUnavailableOfferings() *UnavailableOfferings {
+	return &UnavailableOfferings{
+		cache: cache.New(UnavailableOfferingsTTL, UnavailableOfferingsCleanupInterval),
+	}
+}
+
+// IsUnavailable returns true if a launch from the MachineDeployment in the zone has failed recently.
+func (u *UnavailableOfferings) IsUnavailable(namespace, name, zone string) bool {
+	_, found := u.cache.Get(u.key(namespace, name, zone))
+	return found
+}
+
+// MarkUnavailable records a failed launch from the MachineDeployment in the zone, the reason is only used for logging.
+func (u *UnavailableOfferings) MarkUnavailable(ctx context.Context, reason, namespace, name, zone string) {
+	log.FromContext(ctx).V(1).Info("marking offering unavailable",
+		"reason", reason,
+		"machineDeployment", namespace+"/"+name,
+		"zone", zone,
+		"ttl", UnavailableOfferingsTTL)
+	u.cache.SetDefault(u.key(namespace, name, zone), struct{}{})
+}
+
+// Delete removes the entry for the MachineDeployment in the zone, making its offering available again.
+func (u *UnavailableOfferings) Delete(namespace, name, zone string) {
+	u.cache.Delete(u.key(namespace, name, zone))
+}
+
+// Flush removes all entries from the cache.
+func (u *UnavailableOfferings) Flush() {
+	u.cache.Flush()
+}
+
+func (u *UnavailableOfferings) key(namespace, name, zone string) string {
+	return fmt.Sprintf("%s/%s:%s", namespace, name, zone)
+}
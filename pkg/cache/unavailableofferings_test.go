@@ -0,0 +1,59 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/patrickmn/go-cache"
+)
+
+var _ = Describe("UnavailableOfferings", func() {
+	var unavailableOfferings *UnavailableOfferings
+
+	BeforeEach(func() {
+		unavailableOfferings = NewUnavailableOfferings()
+	})
+
+	It("reports offerings as available by default", func() {
+		Expect(unavailableOfferings.IsUnavailable("default", "md-1", "zone-a")).To(BeFalse())
+	})
+
+	It("reports an offering as unavailable after it is marked", func() {
+		unavailableOfferings.MarkUnavailable(context.Background(), "test", "default", "md-1", "zone-a")
+		Expect(unavailableOfferings.IsUnavailable("default", "md-1", "zone-a")).To(BeTrue())
+		Expect(unavailableOfferings.IsUnavailable("default", "md-1", "zone-b")).To(BeFalse())
+		Expect(unavailableOfferings.IsUnavailable("default", "md-2", "zone-a")).To(BeFalse())
+	})
+
+	It("reports an offering as available after it is deleted", func() {
+		unavailableOfferings.MarkUnavailable(context.Background(), "test", "default", "md-1", "zone-a")
+		unavailableOfferings.Delete("default", "md-1", "zone-a")
+		Expect(unavailableOfferings.IsUnavailable("default", "md-1", "zone-a")).To(BeFalse())
+	})
+
+	It("reports an offering as available after it expires", func() {
+		unavailableOfferings.cache = cache.New(10*time.Millisecond, time.Minute)
+		unavailableOfferings.MarkUnavailable(context.Background(), "test", "default", "md-1", "zone-a")
+		Eventually(func() bool {
+			return unavailableOfferings.IsUnavailable("default", "md-1", "zone-a")
+		}).Should(BeFalse())
+	})
+})
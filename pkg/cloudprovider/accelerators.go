@@ -0,0 +1,141 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+// isExtendedResourceName returns true if the name is a valid name for an extended resource, which is a
+// qualified name with a domain outside of kubernetes.io, for example "nvidia.com/gpu".
+func isExtendedResourceName(name string) bool {
+	domain, _, found := strings.Cut(name, "/")
+	if !found || len(validation.IsQualifiedName(name)) > 0 {
+		return false
+	}
+	return domain != "kubernetes.io" && !strings.HasSuffix(domain, ".kubernetes.io")
+}
+
+// extendedResourceListFromAnnotation converts the ExtendedResourcesAnnotation, which has the format
+// "name=quantity,name=quantity", to a resource list. Entries which are malformed, or have an invalid resource
+// name or quantity, are ignored.
+func extendedResourceListFromAnnotation(annotation string) corev1.ResourceList {
+	resources := corev1.ResourceList{}
+	for _, entry := range strings.Split(annotation, ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || !isExtendedResourceName(name) {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+		resources[corev1.ResourceName(name)] = quantity
+	}
+	return resources
+}
+
+// isAcceleratorResourceName returns true if the extended resource is a GPU, which is the case when its domain or
+// name contains "gpu", for example "nvidia.com/gpu", "amd.com/gpu" or "gpu.intel.com/i915", or when it is a
+// partition of an NVIDIA GPU such as "nvidia.com/mig-1g.5gb".
+func isAcceleratorResourceName(name corev1.ResourceName) bool {
+	if !isExtendedResourceName(string(name)) {
+		return false
+	}
+	domain, resourceName, _ := strings.Cut(string(name), "/")
+	return strings.Contains(domain, "gpu") || strings.Contains(resourceName, "gpu") || isMIGResourceName(name)
+}
+
+// isMIGResourceName returns true if the extended resource is a partition of an NVIDIA GPU, such as
+// "nvidia.com/mig-1g.5gb". Several partitions share a GPU, so they are not counted as GPUs.
+func isMIGResourceName(name corev1.ResourceName) bool {
+	_, resourceName, _ := strings.Cut(string(name), "/")
+	return strings.HasPrefix(resourceName, "mig-")
+}
+
+// acceleratorManufacturer returns the manufacturer for an accelerator resource, which is the organization of its
+// domain, for example "nvidia" for "nvidia.com/gpu" and "intel" for "gpu.intel.com/i915".
+func acceleratorManufacturer(name corev1.ResourceName) string {
+	domain, _, _ := strings.Cut(string(name), "/")
+	parts := strings.Split(domain, ".")
+	if len(parts) < 2 {
+		return domain
+	}
+	return parts[len(parts)-2]
+}
+
+// acceleratorCount returns the number of whole GPUs in the capacity, MIG partitions are not included.
+func acceleratorCount(capacity corev1.ResourceList) int64 {
+	count := int64(0)
+	for name, quantity := range capacity {
+		if isAcceleratorResourceName(name) && !isMIGResourceName(name) {
+			count += quantity.Value()
+		}
+	}
+	return count
+}
+
+// migCount returns the number of MIG partitions in the capacity.
+func migCount(capacity corev1.ResourceList) int64 {
+	count := int64(0)
+	for name, quantity := range capacity {
+		if isAcceleratorResourceName(name) && isMIGResourceName(name) {
+			count += quantity.Value()
+		}
+	}
+	return count
+}
+
+// acceleratorLabelsFromMachineDeployment returns the accelerator labels for the capacity of a MachineDeployment.
+// The GPU count is the number of whole GPUs, which is zero when there are only MIG partitions, and the MIG count is
+// only set when there are MIG partitions. The manufacturer is the one of the accelerator resource which is first in
+// alphabetical order. The model is read from the GPUModelAnnotation. No labels are returned
+// when the capacity has no accelerators.
+func acceleratorLabelsFromMachineDeployment(machineDeployment *capiv1beta1.MachineDeployment, capacity corev1.ResourceList) map[string]string {
+	labels := map[string]string{}
+
+	names := lo.Filter(lo.Keys(capacity), func(name corev1.ResourceName, _ int) bool {
+		quantity := capacity[name]
+		return isAcceleratorResourceName(name) && !quantity.IsZero()
+	})
+	if len(names) == 0 {
+		return labels
+	}
+	slices.Sort(names)
+
+	labels[InstanceGPUCountLabelKey] = strconv.FormatInt(acceleratorCount(capacity), 10)
+	if count := migCount(capacity); count > 0 {
+		labels[InstanceGPUMIGCountLabelKey] = strconv.FormatInt(count, 10)
+	}
+
+	if manufacturer := acceleratorManufacturer(names[0]); len(validation.IsValidLabelValue(manufacturer)) == 0 {
+		labels[InstanceGPUManufacturerLabelKey] = manufacturer
+	}
+	if model := machineDeployment.GetAnnotations()[GPUModelAnnotation]; model != "" && len(validation.IsValidLabelValue(model)) == 0 {
+		labels[InstanceGPUModelLabelKey] = model
+	}
+
+	return labels
+}
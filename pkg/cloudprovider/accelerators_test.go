@@ -0,0 +1,188 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+var _ = Describe("isExtendedResourceName function", func() {
+	It("returns true for a qualified name with a domain", func() {
+		Expect(isExtendedResourceName("nvidia.com/gpu")).To(BeTrue())
+		Expect(isExtendedResourceName("gpu.intel.com/i915")).To(BeTrue())
+	})
+
+	It("returns false for names without a domain or in the kubernetes.io domain", func() {
+		Expect(isExtendedResourceName("gpu")).To(BeFalse())
+		Expect(isExtendedResourceName("kubernetes.io/gpu")).To(BeFalse())
+		Expect(isExtendedResourceName("node.kubernetes.io/gpu")).To(BeFalse())
+	})
+
+	It("returns false for names which are not qualified names", func() {
+		Expect(isExtendedResourceName("nvidia.com/gpu type")).To(BeFalse())
+		Expect(isExtendedResourceName("nvidia.com/")).To(BeFalse())
+	})
+})
+
+var _ = Describe("extendedResourceListFromAnnotation function", func() {
+	It("ignores malformed entries and trims the whitespace around entries and quantities", func() {
+		resources := extendedResourceListFromAnnotation(" nvidia.com/gpu= 2 ,example.com/fpga,=1,,example.com/nic=1")
+		Expect(resources).To(HaveLen(2))
+		Expect(resources).To(HaveKeyWithValue(corev1.ResourceName("nvidia.com/gpu"), resource.MustParse("2")))
+		Expect(resources).To(HaveKeyWithValue(corev1.ResourceName("example.com/nic"), resource.MustParse("1")))
+	})
+})
+
+var _ = Describe("capacityResourceListFromAnnotations function with extended resources", func() {
+	It("returns all the valid extended resources from the annotation", func() {
+		capacity := capacityResourceListFromAnnotations(context.Background(), map[string]string{
+			ExtendedResourcesAnnotation: "nvidia.com/gpu=2,example.com/fpga=1,invalid=1,example.com/bad=x",
+		})
+		Expect(capacity).To(HaveLen(2))
+		Expect(capacity).To(HaveKeyWithValue(corev1.ResourceName("nvidia.com/gpu"), resource.MustParse("2")))
+		Expect(capacity).To(HaveKeyWithValue(corev1.ResourceName("example.com/fpga"), resource.MustParse("1")))
+	})
+
+	It("prefers the gpu annotations over the extended resources annotation", func() {
+		capacity := capacityResourceListFromAnnotations(context.Background(), map[string]string{
+			ExtendedResourcesAnnotation: "nvidia.com/gpu=2",
+			gpuCountKey:                 "4",
+			gpuTypeKey:                  "nvidia.com/gpu",
+		})
+		Expect(capacity).To(HaveKeyWithValue(corev1.ResourceName("nvidia.com/gpu"), resource.MustParse("4")))
+	})
+
+	It("ignores a gpu count which is not a valid quantity", func() {
+		capacity := capacityResourceListFromAnnotations(context.Background(), map[string]string{
+			ExtendedResourcesAnnotation: "nvidia.com/gpu=2",
+			gpuCountKey:                 "two",
+			gpuTypeKey:                  "nvidia.com/gpu",
+		})
+		Expect(capacity).To(HaveKeyWithValue(corev1.ResourceName("nvidia.com/gpu"), resource.MustParse("2")))
+
+		capacity = capacityResourceListFromAnnotations(context.Background(), map[string]string{
+			gpuCountKey: "two",
+			gpuTypeKey:  "nvidia.com/gpu",
+		})
+		Expect(capacity).To(BeEmpty())
+	})
+
+	It("ignores a gpu type which is not an extended resource name", func() {
+		capacity := capacityResourceListFromAnnotations(context.Background(), map[string]string{
+			gpuCountKey: "1",
+			gpuTypeKey:  "A100",
+		})
+		Expect(capacity).To(BeEmpty())
+	})
+})
+
+var _ = Describe("acceleratorLabelsFromMachineDeployment function", func() {
+	var machineDeployment *capiv1beta1.MachineDeployment
+
+	BeforeEach(func() {
+		machineDeployment = newMachineDeployment("md-1", "test-cluster", true)
+	})
+
+	It("returns no labels when there are no accelerators", func() {
+		capacity := corev1.ResourceList{
+			corev1.ResourceCPU:                      resource.MustParse("4"),
+			corev1.ResourceName("example.com/fpga"): resource.MustParse("1"),
+		}
+		Expect(acceleratorLabelsFromMachineDeployment(machineDeployment, capacity)).To(BeEmpty())
+	})
+
+	It("returns the count, manufacturer and model of the accelerators", func() {
+		machineDeployment.SetAnnotations(map[string]string{GPUModelAnnotation: "a100"})
+		capacity := corev1.ResourceList{
+			corev1.ResourceCPU:                    resource.MustParse("4"),
+			corev1.ResourceName("nvidia.com/gpu"): resource.MustParse("2"),
+		}
+		Expect(acceleratorLabelsFromMachineDeployment(machineDeployment, capacity)).To(Equal(map[string]string{
+			InstanceGPUCountLabelKey:        "2",
+			InstanceGPUManufacturerLabelKey: "nvidia",
+			InstanceGPUModelLabelKey:        "a100",
+		}))
+		Expect(acceleratorLabelsFromMachineDeployment(machineDeployment, capacity)).ToNot(HaveKey(InstanceGPUMIGCountLabelKey))
+	})
+
+	It("adds up several accelerator resources and counts the MIG partitions separately", func() {
+		capacity := corev1.ResourceList{
+			corev1.ResourceName("nvidia.com/mig-1g.5gb"):  resource.MustParse("7"),
+			corev1.ResourceName("nvidia.com/mig-2g.10gb"): resource.MustParse("1"),
+			corev1.ResourceName("gpu.intel.com/i915"):     resource.MustParse("1"),
+			corev1.ResourceName("amd.com/gpu"):            resource.MustParse("2"),
+			corev1.ResourceName("nvidia.com/gpu"):         resource.MustParse("0"),
+		}
+		labels := acceleratorLabelsFromMachineDeployment(machineDeployment, capacity)
+		Expect(labels).To(HaveKeyWithValue(InstanceGPUCountLabelKey, "3"))
+		Expect(labels).To(HaveKeyWithValue(InstanceGPUMIGCountLabelKey, "8"))
+		Expect(labels).To(HaveKeyWithValue(InstanceGPUManufacturerLabelKey, "amd"))
+		Expect(labels).ToNot(HaveKey(InstanceGPUModelLabelKey))
+	})
+
+	It("sets a GPU count of zero when there are only MIG partitions", func() {
+		capacity := corev1.ResourceList{corev1.ResourceName("nvidia.com/mig-1g.5gb"): resource.MustParse("7")}
+		labels := acceleratorLabelsFromMachineDeployment(machineDeployment, capacity)
+		Expect(labels).To(HaveKeyWithValue(InstanceGPUCountLabelKey, "0"))
+		Expect(labels).To(HaveKeyWithValue(InstanceGPUMIGCountLabelKey, "7"))
+		Expect(labels).To(HaveKeyWithValue(InstanceGPUManufacturerLabelKey, "nvidia"))
+	})
+
+	It("ignores a model which is not a valid label value", func() {
+		machineDeployment.SetAnnotations(map[string]string{GPUModelAnnotation: "Tesla V100 SXM2"})
+		capacity := corev1.ResourceList{corev1.ResourceName("nvidia.com/gpu"): resource.MustParse("1")}
+		Expect(acceleratorLabelsFromMachineDeployment(machineDeployment, capacity)).ToNot(HaveKey(InstanceGPUModelLabelKey))
+	})
+})
+
+var _ = Describe("machineDeploymentToInstanceType function with accelerators", func() {
+	It("adds the accelerator labels to the requirements", func() {
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		machineDeployment.SetAnnotations(map[string]string{
+			cpuKey:                      "8",
+			memoryKey:                   "64Gi",
+			ExtendedResourcesAnnotation: "nvidia.com/gpu=4",
+			GPUModelAnnotation:          "a100",
+		})
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
+		Expect(instanceType.Capacity).To(HaveKeyWithValue(corev1.ResourceName("nvidia.com/gpu"), resource.MustParse("4")))
+		Expect(instanceType.Requirements.Get(InstanceGPUCountLabelKey).Values()).To(ConsistOf("4"))
+		Expect(instanceType.Requirements.Get(InstanceGPUManufacturerLabelKey).Values()).To(ConsistOf("nvidia"))
+		Expect(instanceType.Requirements.Get(InstanceGPUModelLabelKey).Values()).To(ConsistOf("a100"))
+
+		nodeClaim := createNodeClaimFromMachineDeployment(context.Background(), machineDeployment, nil, nil, nil)
+		Expect(nodeClaim.Labels).To(HaveKeyWithValue(InstanceGPUCountLabelKey, "4"))
+		Expect(nodeClaim.Labels).To(HaveKeyWithValue(InstanceGPUModelLabelKey, "a100"))
+	})
+
+	It("does not change accelerator labels set by the MachineDeployment", func() {
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		machineDeployment.SetAnnotations(map[string]string{
+			gpuCountKey: "1",
+			gpuTypeKey:  "nvidia.com/gpu",
+			labelsKey:   InstanceGPUManufacturerLabelKey + "=acme",
+		})
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
+		Expect(instanceType.Requirements.Get(InstanceGPUManufacturerLabelKey).Values()).To(ConsistOf("acme"))
+	})
+})
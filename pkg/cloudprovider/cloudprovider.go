@@ -17,10 +17,12 @@ limitations under the License.
 package cloudprovider
 
 import (
-	"cmp"
 	"context"
 	_ "embed"
+	"errors"
 	"fmt"
+	"maps"
+	"regexp"
 	"slices"
 	"strconv"
 	"strings"
@@ -33,12 +35,16 @@ import (
 	"k8s.io/utils/ptr"
 	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/apis/v1alpha1"
 	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/batcher"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/cache"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/operator/options"
 	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers"
 	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machine"
-	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machinedeployment"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machinetemplate"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/scalableresource"
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
@@ -57,30 +63,59 @@ const (
 	maxPodsKey      = "capacity.cluster-autoscaler.kubernetes.io/maxPods"
 )
 
-func NewCloudProvider(ctx context.Context, kubeClient client.Client, machineProvider machine.Provider, machineDeploymentProvider machinedeployment.Provider) *CloudProvider {
+func NewCloudProvider(ctx context.Context, kubeClient client.Client, machineProvider machine.Provider, scalableResourceProvider scalableresource.Provider, machineTemplateProvider machinetemplate.Provider) *CloudProvider {
 	mdLock := batcher.NewMDLockManager()
 	return &CloudProvider{
-		kubeClient:                kubeClient,
-		machineProvider:           machineProvider,
-		machineDeploymentProvider: machineDeploymentProvider,
-		createBatcher:             batcher.NewCreateBatcher(ctx, kubeClient, machineProvider, machineDeploymentProvider, mdLock),
-		deleteBatcher:             batcher.NewDeleteBatcher(ctx, machineProvider, machineDeploymentProvider, mdLock),
+		kubeClient:               kubeClient,
+		machineProvider:          machineProvider,
+		scalableResourceProvider: scalableResourceProvider,
+		machineTemplateProvider:  machineTemplateProvider,
+		repairPolicies:           repairPoliciesFromOptions(ctx),
+		defaultSelectionStrategy: selectionStrategyFromOptions(ctx),
+		asyncLaunch:              asyncLaunchFromOptions(ctx),
+		instanceTypeNamePattern:  instanceTypeNamePatternFromOptions(ctx),
+		clusterName:              clusterNameFromOptions(ctx),
+		unavailableOfferings:     cache.NewUnavailableOfferings(),
+		instanceTypes:            cache.NewInstanceTypes[*ClusterAPIInstanceType](),
+		createBatcher:            batcher.NewCreateBatcher(ctx, kubeClient, machineProvider, scalableResourceProvider, mdLock),
+		deleteBatcher:            batcher.NewDeleteBatcher(ctx, machineProvider, scalableResourceProvider, mdLock),
 	}
 }
 
+// asyncLaunchFromOptions returns true when Create should return as soon as a Machine is bound to the NodeClaim.
+func asyncLaunchFromOptions(ctx context.Context) bool {
+	opts := options.FromContext(ctx)
+	return opts != nil && opts.AsyncLaunch
+}
+
 type ClusterAPIInstanceType struct {
 	cloudprovider.InstanceType
 
-	MachineDeploymentName      string
-	MachineDeploymentNamespace string
+	// Taints are the taints that Nodes created from this instance type will be registered with.
+	Taints []corev1.Taint
+
+	// Weight is used by the Weighted instance type selection strategy, higher weights are preferred.
+	Weight int
+
+	// MachineDeploymentOfferings records the MachineDeployment for each of the offerings, an instance type
+	// will have more than one when equivalent MachineDeployments exist in different zones.
+	MachineDeploymentOfferings []MachineDeploymentOffering
 }
 
 type CloudProvider struct {
-	kubeClient                client.Client
-	machineProvider           machine.Provider
-	machineDeploymentProvider machinedeployment.Provider
-	createBatcher             *batcher.CreateBatcher
-	deleteBatcher             *batcher.DeleteBatcher
+	kubeClient               client.Client
+	machineProvider          machine.Provider
+	scalableResourceProvider scalableresource.Provider
+	machineTemplateProvider  machinetemplate.Provider
+	repairPolicies           []cloudprovider.RepairPolicy
+	defaultSelectionStrategy v1alpha1.InstanceTypeSelectionStrategy
+	asyncLaunch              bool
+	instanceTypeNamePattern  *regexp.Regexp
+	clusterName              string
+	unavailableOfferings     *cache.UnavailableOfferings
+	instanceTypes            *cache.InstanceTypes[*ClusterAPIInstanceType]
+	createBatcher            *batcher.CreateBatcher
+	deleteBatcher            *batcher.DeleteBatcher
 }
 
 func (c *CloudProvider) Create(ctx context.Context, nodeClaim *karpv1.NodeClaim) (*karpv1.NodeClaim, error) {
@@ -91,31 +126,61 @@ func (c *CloudProvider) Create(ctx context.Context, nodeClaim *karpv1.NodeClaim)
 	// If the NodeClaim already has a Machine annotation, just
 	// fetch the existing Machine and return it.
 	if machineAnno, ok := nodeClaim.Annotations[providers.MachineAnnotation]; ok {
-		return c.getExistingMachine(ctx, machineAnno)
+		return c.getExistingMachine(ctx, nodeClaim, machineAnno)
 	}
 
-	instanceType, err := c.resolveInstanceType(ctx, nodeClaim)
+	nodeClass, err := c.resolveNodeClassFromNodeClaim(ctx, nodeClaim)
+	if err != nil {
+		return nil, fmt.Errorf("cannot satisfy create, unable to resolve NodeClass from NodeClaim %q: %w", nodeClaim.Name, err)
+	}
+
+	instanceType, err := c.resolveInstanceType(ctx, nodeClaim, nodeClass)
 	if err != nil {
 		return nil, err
 	}
 
+	// choose the scalable resource that provides the offering for the zone Karpenter selected.
+	offering := machineDeploymentOfferingForNodeClaim(instanceType, nodeClaim)
+	if offering == nil {
+		return nil, cloudprovider.NewInsufficientCapacityError(fmt.Errorf("cannot satisfy create, no available offering for instance type %q", instanceType.Name))
+	}
+
+	if nodeClass.Spec.MachineTemplates != nil {
+		// in the ownerless provisioning mode a Machine is created for the NodeClaim instead of scaling a resource.
+		return c.createMachineFromTemplate(ctx, nodeClaim, nodeClass, offering)
+	}
+
 	result := c.createBatcher.Add(ctx, &batcher.CreateInput{
 		NodeClaimName:         nodeClaim.Name,
-		MachineDeploymentName: instanceType.MachineDeploymentName,
-		MachineDeploymentNS:   instanceType.MachineDeploymentNamespace,
+		NodePoolName:          nodeClaim.Labels[karpv1.NodePoolLabelKey],
+		NodeClassName:         nodeClass.Name,
+		Kind:                  offering.Kind,
+		APIVersion:            offering.APIVersion,
+		MachineDeploymentName: offering.MachineDeploymentName,
+		MachineDeploymentNS:   offering.MachineDeploymentNamespace,
 	})
 	if result.Err != nil {
+		if errors.Is(result.Err, batcher.ErrNoMachineAvailable) {
+			// the MachineDeployment could not provide a Machine in time, let Karpenter try another offering.
+			c.unavailableOfferings.MarkUnavailable(ctx, "MachinePollTimeout", offering.MachineDeploymentNamespace, offering.MachineDeploymentName, offering.Offering.Zone())
+			return nil, cloudprovider.NewInsufficientCapacityError(fmt.Errorf("launching nodeclaim: %w", result.Err))
+		}
 		return nil, fmt.Errorf("launching nodeclaim: %w", result.Err)
 	}
+	// the replicas of the scalable resource changed, which can change the availability of its offerings.
+	c.instanceTypes.Invalidate(nodeClass.Name)
 
 	machine := result.Output.Machine
-	if machine.Spec.ProviderID == nil {
+	if machine.Spec.ProviderID == nil && !c.asyncLaunch {
 		return nil, fmt.Errorf("cannot satisfy create, waiting for Machine %q to have ProviderID", machine.Name)
 	}
 
 	//  fill out nodeclaim with details
-	createdNodeClaim := createNodeClaimFromMachineDeployment(result.Output.MachineDeployment)
-	createdNodeClaim.Status.ProviderID = *machine.Spec.ProviderID
+	machineDeployment := result.Output.ScalableResource.MachineDeployment()
+	templateStatus := c.infrastructureTemplateStatus(ctx, machineDeployment)
+	createdNodeClaim := createNodeClaimFromMachineDeployment(ctx, machineDeployment, templateStatus, nodeClass, c.instanceTypeNamePattern)
+	// in the asynchronous launch mode the provider ID is filled in by the launch controller when the Machine has one.
+	createdNodeClaim.Status.ProviderID = ptr.Deref(machine.Spec.ProviderID, "")
 
 	return createdNodeClaim, nil
 }
@@ -130,32 +195,59 @@ func (c *CloudProvider) Delete(ctx context.Context, nodeClaim *karpv1.NodeClaim)
 		return cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("unable to find Machine with provider ID %q to Delete NodeClaim %q", nodeClaim.Status.ProviderID, nodeClaim.Name))
 	}
 
+	return c.deleteMachine(ctx, machine, fmt.Sprintf("NodeClaim %q", nodeClaim.Name))
+}
+
+// DeleteMachine removes a Machine which is not bound to a NodeClaim, by scaling down its scalable resource through
+// the delete batcher, or by deleting it when it is an ownerless Machine.
+func (c *CloudProvider) DeleteMachine(ctx context.Context, machine *capiv1beta1.Machine) error {
+	return c.deleteMachine(ctx, machine, fmt.Sprintf("Machine %q", machine.Name))
+}
+
+// deleteMachine removes the Machine, the description of what is deleted is used in the errors.
+func (c *CloudProvider) deleteMachine(ctx context.Context, machine *capiv1beta1.Machine, description string) error {
 	// check if already deleting
 	if c.machineProvider.IsDeleting(machine) {
 		// Machine is already deleting, we do not need to annotate it or change the scalable resource replicas.
 		return nil
 	}
 
+	if isOwnerlessMachine(machine) {
+		// the Machine is not owned by a scalable resource, it is deleted directly.
+		return c.machineProvider.Delete(ctx, machine)
+	}
+
 	// check if reducing replicas goes below zero
-	machineDeployment, err := c.machineDeploymentFromMachine(ctx, machine)
+	scalableResource, err := c.scalableResourceFromMachine(ctx, machine)
 	if err != nil {
-		return fmt.Errorf("unable to delete NodeClaim %q, cannot find an owner MachineDeployment for Machine %q: %w", nodeClaim.Name, machine.Name, err)
+		return fmt.Errorf("unable to delete %s, cannot find an owner MachineDeployment for Machine %q: %w", description, machine.Name, err)
+	}
+	ref := scalableResource.Reference()
+
+	if scalableResource.Replicas() == nil {
+		return fmt.Errorf("unable to delete %s, %s %q has nil replicas", description, ref.Kind, ref.Name)
 	}
 
-	if machineDeployment.Spec.Replicas == nil {
-		return fmt.Errorf("unable to delete NodeClaim %q, MachineDeployment %q has nil replicas", nodeClaim.Name, machineDeployment.Name)
+	if *scalableResource.Replicas() == 0 {
+		return fmt.Errorf("unable to delete %s, %s %q is already at zero replicas", description, ref.Kind, ref.Name)
 	}
 
-	if *machineDeployment.Spec.Replicas == 0 {
-		return fmt.Errorf("unable to delete NodeClaim %q, MachineDeployment %q is already at zero replicas", nodeClaim.Name, machineDeployment.Name)
+	if minSize, found := scalableresource.MinSize(ctx, scalableResource); found && *scalableResource.Replicas() <= minSize {
+		return fmt.Errorf("unable to delete %s, %s %q is at its minimum size of %d replicas", description, ref.Kind, ref.Name, minSize)
 	}
 
 	result := c.deleteBatcher.Add(ctx, &batcher.DeleteInput{
 		MachineName:           machine.Name,
 		MachineNamespace:      machine.Namespace,
-		MachineDeploymentName: machineDeployment.Name,
-		MachineDeploymentNS:   machineDeployment.Namespace,
+		Kind:                  ref.Kind,
+		APIVersion:            ref.APIVersion,
+		MachineDeploymentName: ref.Name,
+		MachineDeploymentNS:   ref.Namespace,
 	})
+	if result.Err == nil {
+		// the NodeClasses of the scalable resource are not known here, so the instance types of all of them are rebuilt.
+		c.instanceTypes.InvalidateAll()
+	}
 	return result.Err
 }
 
@@ -172,6 +264,9 @@ func (c *CloudProvider) Get(ctx context.Context, providerID string) (*karpv1.Nod
 	if machine == nil {
 		return nil, cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("cannot find Machine with provider ID %q", providerID))
 	}
+	if !c.isManagedMachine(machine) {
+		return nil, cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("Machine %q with provider ID %q is not in Cluster %q", machine.Name, providerID, c.clusterName))
+	}
 
 	nodeClaim, err := c.machineToNodeClaim(ctx, machine)
 	if err != nil {
@@ -194,11 +289,17 @@ func (c *CloudProvider) GetInstanceTypes(ctx context.Context, nodePool *karpv1.N
 		return nil, fmt.Errorf("unable to resolve NodeClass from NodePool %s: %w", nodePool.Name, err)
 	}
 
-	capiInstanceTypes, err := c.findInstanceTypesForNodeClass(ctx, nodeClass)
+	capiInstanceTypes, err := c.instanceTypesForNodeClass(ctx, nodeClass)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get instance types for NodePool %q: %w", nodePool.Name, err)
 	}
 
+	// the scheduler does not know the taints of the instance types, so the instance types which register Nodes with
+	// taints that the NodePool does not declare are left out, pods scheduled to them might not tolerate the taints.
+	capiInstanceTypes = lo.Filter(capiInstanceTypes, func(i *ClusterAPIInstanceType, _ int) bool {
+		return expectsTaints(nodePool.Spec.Template.Spec.Taints, nodePool.Spec.Template.Spec.StartupTaints, i.Taints)
+	})
+
 	instanceTypes := lo.Map(capiInstanceTypes, func(i *ClusterAPIInstanceType, _ int) *cloudprovider.InstanceType {
 		return &cloudprovider.InstanceType{
 			Name:         i.Name,
@@ -215,12 +316,64 @@ func (c *CloudProvider) GetSupportedNodeClasses() []status.Object {
 	return []status.Object{&v1alpha1.ClusterAPINodeClass{}}
 }
 
-// Return nothing since there's no cloud provider drift.
+// IsDrifted compares the Machine backing the NodeClaim with the template of its owning MachineDeployment
+// and the ClusterAPINodeClass, returning the reason for drift or an empty reason if the Machine is up to date.
 func (c *CloudProvider) IsDrifted(ctx context.Context, nodeClaim *karpv1.NodeClaim) (cloudprovider.DriftReason, error) {
-	return "", nil
+	if nodeClaim == nil {
+		return "", fmt.Errorf("NodeClaim is nil, cannot determine drift")
+	}
+
+	machine, err := c.findMachineForNodeClaim(ctx, nodeClaim)
+	if err != nil {
+		return "", fmt.Errorf("unable to find Machine for NodeClaim %q to determine drift: %w", nodeClaim.Name, err)
+	}
+	if machine == nil {
+		// the Machine is gone, there is nothing left to compare.
+		return "", nil
+	}
+
+	nodeClass, err := c.resolveNodeClassFromNodeClaim(ctx, nodeClaim)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve NodeClass to determine drift for NodeClaim %q: %w", nodeClaim.Name, err)
+	}
+
+	if isOwnerlessMachine(machine) {
+		return c.isOwnerlessMachineDrifted(ctx, nodeClaim, nodeClass, machine)
+	}
+	if nodeClass.Spec.MachineTemplates != nil {
+		// the NodeClass uses the ownerless provisioning mode, it no longer selects scalable resources.
+		return NodeClassSelectorDrift, nil
+	}
+
+	scalableResource, err := c.scalableResourceFromMachine(ctx, machine)
+	if err != nil {
+		return "", fmt.Errorf("unable to determine drift for NodeClaim %q: %w", nodeClaim.Name, err)
+	}
+	machineDeployment := scalableResource.MachineDeployment()
+
+	return c.isDrifted(ctx, nodeClaim, nodeClass, machine, machineDeployment)
 }
 
 func (c *CloudProvider) List(ctx context.Context) ([]*karpv1.NodeClaim, error) {
+	machines, err := c.ListClaimedMachines(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodeClaims []*karpv1.NodeClaim
+	for _, machine := range machines {
+		nodeClaim, err := c.machineToNodeClaim(ctx, machine)
+		if err != nil {
+			return []*karpv1.NodeClaim{}, fmt.Errorf("unable to convert Machine %s to NodeClaim: %w", machine.Name, err)
+		}
+		nodeClaims = append(nodeClaims, nodeClaim)
+	}
+
+	return nodeClaims, nil
+}
+
+// ListClaimedMachines returns the Machines of the workload Cluster which have been claimed for a NodeClaim.
+func (c *CloudProvider) ListClaimedMachines(ctx context.Context) ([]*capiv1beta1.Machine, error) {
 	// select all machines that have the nodepool membership label, this should be all the machines that are registered as nodes
 	selector := metav1.LabelSelector{
 		MatchExpressions: []metav1.LabelSelectorRequirement{
@@ -230,37 +383,31 @@ func (c *CloudProvider) List(ctx context.Context) ([]*karpv1.NodeClaim, error) {
 			},
 		},
 	}
+	if c.clusterName != "" {
+		// only the Machines of the workload Cluster are its NodeClaims.
+		selector.MatchLabels = map[string]string{capiv1beta1.ClusterNameLabel: c.clusterName}
+	}
 	machines, err := c.machineProvider.List(ctx, "", &selector)
 	if err != nil {
 		return nil, fmt.Errorf("listing machines, %w", err)
 	}
 
-	var nodeClaims []*karpv1.NodeClaim
-	for _, machine := range machines {
-		nodeClaim, err := c.machineToNodeClaim(ctx, machine)
-		if err != nil {
-			return []*karpv1.NodeClaim{}, fmt.Errorf("unable to convert Machine %s to NodeClaim: %w", machine.Name, err)
-		}
-		nodeClaims = append(nodeClaims, nodeClaim)
-	}
-
-	return nodeClaims, nil
+	return machines, nil
 }
 
 func (c *CloudProvider) Name() string {
 	return "clusterapi"
 }
 
+// RepairPolicies returns the node conditions that will cause Karpenter to replace an unhealthy node.
+// These are the DefaultRepairPolicies, as modified by the controller options.
 func (c *CloudProvider) RepairPolicies() []cloudprovider.RepairPolicy {
-	// TODO(elmiko) research what this means for cluster-api, perhaps there are conditions that
-	// we could use from cluster-api to determine when repair should be initiated.
-
-	return []cloudprovider.RepairPolicy{}
+	return c.repairPolicies
 }
 
 // getExistingMachine handles the resume path when a NodeClaim already has a
 // Machine annotation from a previous Create attempt.
-func (c *CloudProvider) getExistingMachine(ctx context.Context, machineAnno string) (*karpv1.NodeClaim, error) {
+func (c *CloudProvider) getExistingMachine(ctx context.Context, nodeClaim *karpv1.NodeClaim, machineAnno string) (*karpv1.NodeClaim, error) {
 	machineNamespace, machineName, err := providers.ParseMachineAnnotation(machineAnno)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing machine annotation: %w", err)
@@ -276,23 +423,37 @@ func (c *CloudProvider) getExistingMachine(ctx context.Context, machineAnno stri
 		return nil, fmt.Errorf("failed to get NodeClaim's MachineDeployment %s: %w", machineName, err)
 	}
 
-	if m.Spec.ProviderID == nil {
-		return nil, fmt.Errorf("cannot satisfy create, waiting for Machine %q to have ProviderID", m.Name)
+	if c.machineProvider.IsFailed(m) {
+		// the Machine will not become a Node, the offering is unavailable so that the next NodeClaims try another
+		// one, and the failure of the Machine is set on the Launched condition of this NodeClaim.
+		c.unavailableOfferings.MarkUnavailable(ctx, "MachineFailed", md.Namespace, md.Name, zoneFromMachineDeployment(md, nodeLabelsFromMachineDeployment(md)))
+		return nil, MachineFailedError(m)
 	}
 
-	nc := createNodeClaimFromMachineDeployment(md)
-	nc.Status.ProviderID = *m.Spec.ProviderID
-	return nc, nil
-}
+	if m.Spec.ProviderID == nil {
+		// an error reported by the infrastructure or bootstrap provider is put on the Launched condition of the
+		// NodeClaim, instead of only waiting for the registration timeout.
+		if err := machineConditionError(m); err != nil {
+			return nil, err
+		}
+		if !c.asyncLaunch {
+			return nil, fmt.Errorf("cannot satisfy create, waiting for Machine %q to have ProviderID", m.Name)
+		}
+	}
 
-// resolveInstanceType finds the best matching instance type for a NodeClaim.
-func (c *CloudProvider) resolveInstanceType(ctx context.Context, nodeClaim *karpv1.NodeClaim) (*ClusterAPIInstanceType, error) {
 	nodeClass, err := c.resolveNodeClassFromNodeClaim(ctx, nodeClaim)
 	if err != nil {
 		return nil, fmt.Errorf("cannot satisfy create, unable to resolve NodeClass from NodeClaim %q: %w", nodeClaim.Name, err)
 	}
 
-	instanceTypes, err := c.findInstanceTypesForNodeClass(ctx, nodeClass)
+	nc := createNodeClaimFromMachineDeployment(ctx, md, c.infrastructureTemplateStatus(ctx, md), nodeClass, c.instanceTypeNamePattern)
+	nc.Status.ProviderID = ptr.Deref(m.Spec.ProviderID, "")
+	return nc, nil
+}
+
+// resolveInstanceType finds the best matching instance type of the NodeClass for a NodeClaim.
+func (c *CloudProvider) resolveInstanceType(ctx context.Context, nodeClaim *karpv1.NodeClaim, nodeClass *v1alpha1.ClusterAPINodeClass) (*ClusterAPIInstanceType, error) {
+	instanceTypes, err := c.instanceTypesForNodeClass(ctx, nodeClass)
 	if err != nil {
 		return nil, fmt.Errorf("cannot satisfy create, unable to get instance types for NodeClass %q of NodeClaim %q: %w", nodeClass.Name, nodeClaim.Name, err)
 	}
@@ -303,13 +464,9 @@ func (c *CloudProvider) resolveInstanceType(ctx context.Context, nodeClaim *karp
 		return nil, cloudprovider.NewInsufficientCapacityError(fmt.Errorf("cannot satisfy create, no compatible instance types found"))
 	}
 
-	// TODO (elmiko) if multiple instance types are found to be compatible we need to select one.
-	// for now, we sort by resource name and take the first in the list. In the future, this should
-	// be an option or something more useful like minimum size or cost.
-	slices.SortFunc(compatibleInstanceTypes, func(a, b *ClusterAPIInstanceType) int {
-		return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
-	})
-	return compatibleInstanceTypes[0], nil
+	// when multiple instance types are compatible, the strategy from the NodeClass decides which one to use.
+	strategy := selectionStrategyForNodeClass(nodeClass, c.defaultSelectionStrategy)
+	return selectInstanceType(strategy, compatibleInstanceTypes, nodeClaim), nil
 }
 
 // findMachineForNodeClaim resolves a CAPI Machine from a NodeClaim's providerID
@@ -320,6 +477,10 @@ func (c *CloudProvider) findMachineForNodeClaim(ctx context.Context, nodeClaim *
 		if err != nil {
 			return nil, fmt.Errorf("error finding Machine with provider ID %q to Delete NodeClaim %q: %w", nodeClaim.Status.ProviderID, nodeClaim.Name, err)
 		}
+		if m != nil && !c.isManagedMachine(m) {
+			// a Machine of another Cluster is never changed.
+			return nil, nil
+		}
 		return m, nil
 	}
 
@@ -332,26 +493,80 @@ func (c *CloudProvider) findMachineForNodeClaim(ctx context.Context, nodeClaim *
 		if err != nil {
 			return nil, fmt.Errorf("error finding Machine %q in namespace %s to Delete NodeClaim %q: %w", machineName, machineNamespace, nodeClaim.Name, err)
 		}
+		if m != nil && !c.isManagedMachine(m) {
+			// a Machine of another Cluster is never changed.
+			return nil, nil
+		}
 		return m, nil
 	}
 
 	return nil, fmt.Errorf("NodeClaim %q does not have a provider ID or Machine annotations, cannot delete", nodeClaim.Name)
 }
 
+// scalableResourceFromMachine returns the scalable resource that owns the Machine.
+func (c *CloudProvider) scalableResourceFromMachine(ctx context.Context, machine *capiv1beta1.Machine) (scalableresource.ScalableResource, error) {
+	scalableResource, err := c.scalableResourceProvider.GetForMachine(ctx, machine)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get scalable resource for Machine %s: %w", machine.GetName(), err)
+	}
+	if scalableResource == nil {
+		return nil, fmt.Errorf("unable to find scalable resource for Machine %q, has no MachineDeployment label %q", machine.GetName(), capiv1beta1.MachineDeploymentNameLabel)
+	}
+
+	return scalableResource, nil
+}
+
+// machineDeploymentFromMachine returns the MachineDeployment view of the scalable resource that owns the Machine,
+// or of the InfrastructureMachineTemplate that an ownerless Machine was created from.
 func (c *CloudProvider) machineDeploymentFromMachine(ctx context.Context, machine *capiv1beta1.Machine) (*capiv1beta1.MachineDeployment, error) {
-	mdName, found := machine.GetLabels()[capiv1beta1.MachineDeploymentNameLabel]
-	if !found {
-		return nil, fmt.Errorf("unable to find MachineDeployment for Machine %q, has no MachineDeployment label %q", machine.GetName(), capiv1beta1.MachineDeploymentNameLabel)
+	if isOwnerlessMachine(machine) {
+		return c.machineDeploymentFromOwnerlessMachine(ctx, machine)
 	}
 
-	machineDeployment, err := c.machineDeploymentProvider.Get(ctx, mdName, machine.GetNamespace())
+	scalableResource, err := c.scalableResourceFromMachine(ctx, machine)
 	if err != nil {
-		return nil, fmt.Errorf("unable to get MachineDeployment %s for Machine %s: %w", mdName, machine.GetName(), err)
+		return nil, err
 	}
 
-	return machineDeployment, nil
+	return scalableResource.MachineDeployment(), nil
 }
 
+// InvalidateInstanceTypes removes the cached instance types of the NodeClass with the name, they are rebuilt on
+// the next request.
+func (c *CloudProvider) InvalidateInstanceTypes(nodeClassName string) {
+	c.instanceTypes.Invalidate(nodeClassName)
+}
+
+// instanceTypesForNodeClass returns the instance types of the NodeClass from the catalog, building them when they
+// are not cached. The instance types are copies with the availability of their offerings set from the recent
+// launch failures, so callers may modify them.
+func (c *CloudProvider) instanceTypesForNodeClass(ctx context.Context, nodeClass *v1alpha1.ClusterAPINodeClass) ([]*ClusterAPIInstanceType, error) {
+	if nodeClass == nil {
+		return nil, fmt.Errorf("unable to find instance types for nil NodeClass")
+	}
+
+	instanceTypes, found := c.instanceTypes.Get(nodeClass)
+	if !found {
+		// the sequence number is read first, so that instance types built while the NodeClass is invalidated are not
+		// stored.
+		seqNum := c.instanceTypes.SeqNum(nodeClass.Name)
+		var err error
+		instanceTypes, err = c.findInstanceTypesForNodeClass(ctx, nodeClass)
+		if err != nil {
+			return nil, err
+		}
+		c.instanceTypes.Set(nodeClass, seqNum, instanceTypes)
+	}
+
+	return lo.Map(instanceTypes, func(i *ClusterAPIInstanceType, _ int) *ClusterAPIInstanceType {
+		instanceType := copyInstanceType(i)
+		c.markUnavailableOfferings(instanceType)
+		return instanceType
+	}), nil
+}
+
+// findInstanceTypesForNodeClass builds the instance types of the NodeClass from its scalable resources or machine
+// templates, the availability of their offerings is not set.
 func (c *CloudProvider) findInstanceTypesForNodeClass(ctx context.Context, nodeClass *v1alpha1.ClusterAPINodeClass) ([]*ClusterAPIInstanceType, error) {
 	instanceTypes := []*ClusterAPIInstanceType{}
 
@@ -359,25 +574,60 @@ func (c *CloudProvider) findInstanceTypesForNodeClass(ctx context.Context, nodeC
 		return instanceTypes, fmt.Errorf("unable to find instance types for nil NodeClass")
 	}
 
-	machineDeployments, err := c.machineDeploymentProvider.List(ctx, nodeClass.Spec.ScalableResourceSelector)
+	if nodeClass.Spec.MachineTemplates != nil {
+		return c.findInstanceTypesForMachineTemplates(ctx, nodeClass)
+	}
+
+	// only the scalable resources of the workload Cluster are selected, so that a selector which matches the
+	// resources of another Cluster cannot scale them.
+	clusterRef := c.clusterRefForNodeClass(nodeClass)
+	scalableResources, err := c.scalableResourceProvider.List(ctx, selectorForCluster(nodeClass.Spec.ScalableResourceSelector, clusterRef))
 	if err != nil {
-		return instanceTypes, fmt.Errorf("unable to list MachineDeployments for NodeClass %s: %w", nodeClass.Name, err)
+		return instanceTypes, fmt.Errorf("unable to list scalable resources for NodeClass %s: %w", nodeClass.Name, err)
 	}
 
-	for _, md := range machineDeployments {
-		it := machineDeploymentToInstanceType(md)
-		instanceTypes = append(instanceTypes, it)
+	for _, r := range scalableResources {
+		if clusterRef != nil && clusterRef.Namespace != "" && r.Reference().Namespace != clusterRef.Namespace {
+			continue
+		}
+		instanceTypes = append(instanceTypes, c.scalableResourceToInstanceType(ctx, r, nodeClass))
 	}
 
+	// equivalent MachineDeployments in different zones become a single instance type with an offering per zone.
+	instanceTypes = mergeInstanceTypes(instanceTypes)
+
 	return instanceTypes, nil
 }
 
-func (c *CloudProvider) machineToNodeClaim(ctx context.Context, machine *capiv1beta1.Machine) (*karpv1.NodeClaim, error) {
-	nodeClaim := karpv1.NodeClaim{}
-	if machine.Spec.ProviderID != nil {
-		nodeClaim.Status.ProviderID = *machine.Spec.ProviderID
+// scalableResourceToInstanceType returns the instance type for a scalable resource, with the kubelet overhead,
+// prices and availability of its offerings set.
+func (c *CloudProvider) scalableResourceToInstanceType(ctx context.Context, scalableResource scalableresource.ScalableResource, nodeClass *v1alpha1.ClusterAPINodeClass) *ClusterAPIInstanceType {
+	machineDeployment := scalableResource.MachineDeployment()
+	ref := scalableResource.Reference()
+	return c.instanceTypeFromMachineDeployment(ctx, machineDeployment, c.infrastructureTemplateStatus(ctx, machineDeployment), nodeClass, ref.APIVersion, ref.Kind)
+}
+
+// instanceTypeFromMachineDeployment returns the instance type for a MachineDeployment, or the MachineDeployment view
+// of another resource of the kind, with the kubelet overhead, prices and availability of its offerings set.
+func (c *CloudProvider) instanceTypeFromMachineDeployment(ctx context.Context, machineDeployment *capiv1beta1.MachineDeployment, templateStatus *machinetemplate.Status, nodeClass *v1alpha1.ClusterAPINodeClass, apiVersion string, kind string) *ClusterAPIInstanceType {
+	it := machineDeploymentToInstanceType(ctx, machineDeployment, templateStatus)
+	applyInstanceTypeLabels(it, c.instanceTypeNamePattern)
+	setOfferingsKind(it, apiVersion, kind)
+	applyKubeletConfiguration(it, machineDeployment, nodeClass)
+	price, err := priceForInstanceType(machineDeployment, it, nodeClass)
+	if err != nil {
+		// an invalid price should not prevent the instance type from being used, it will keep a price of zero.
+		log.FromContext(ctx).Error(err, "unable to determine price for instance type", "kind", kind, "name", machineDeployment.Name)
 	}
+	setOfferingPrices(it, price)
+	return it
+}
 
+// machineToNodeClaim reconstructs the NodeClaim of a Machine. The capacity, allocatable, labels and taints come from
+// the MachineDeployment that owns the Machine, in the same way as for Create. The name, NodePool and NodeClass of the
+// NodeClaim come from the annotations recorded on the Machine when it was bound, and the node name, zone and creation
+// time from the Machine itself.
+func (c *CloudProvider) machineToNodeClaim(ctx context.Context, machine *capiv1beta1.Machine) (*karpv1.NodeClaim, error) {
 	// we want to get the MachineDeployment that owns this Machine to read the capacity information.
 	// to being this process, we get the MachineDeployment name from the Machine labels.
 	machineDeployment, err := c.machineDeploymentFromMachine(ctx, machine)
@@ -386,10 +636,11 @@ func (c *CloudProvider) machineToNodeClaim(ctx context.Context, machine *capiv1b
 	}
 
 	// machine capacity
-	// we are using the scale from zero annotations on the MachineDeployment to make this accessible.
-	// TODO (elmiko) improve this once upstream has advanced the state of the art for getting capacity,
-	// also add a mechanism to lookup the infra machine template similar to how CAS does it.
-	capacity := capacityResourceListFromAnnotations(machineDeployment.GetAnnotations())
+	// we are using the scale from zero annotations on the MachineDeployment, and the status of the
+	// infrastructure machine template it references, to make this accessible.
+	// TODO (elmiko) improve this once upstream has advanced the state of the art for getting capacity.
+	templateStatus := c.infrastructureTemplateStatus(ctx, machineDeployment)
+	capacity := capacityResourceListFromMachineDeployment(ctx, machineDeployment, templateStatus)
 	_, found := capacity[corev1.ResourceCPU]
 	if !found {
 		// if there is no cpu resource we aren't going to get far, return an error
@@ -402,14 +653,95 @@ func (c *CloudProvider) machineToNodeClaim(ctx context.Context, machine *capiv1b
 		return nil, fmt.Errorf("unable to convert Machine %q to a NodeClaim, no memory capacity found on MachineDeployment %q", machine.GetName(), machineDeployment.Name)
 	}
 
-	// Set NodeClaim labels from the MachineDeployment
-	nodeClaim.Labels = nodeLabelsFromMachineDeployment(machineDeployment)
+	// the NodeClass supplies the kubelet settings for the allocatable resources, the defaults are used without it.
+	nodeClass := c.nodeClassFromMachine(ctx, machine)
+	nodeClaim := createNodeClaimFromMachineDeployment(ctx, machineDeployment, templateStatus, nodeClass, c.instanceTypeNamePattern)
 
-	// TODO (elmiko) add taints
+	annotations := machine.GetAnnotations()
+	nodeClaim.Name = annotations[providers.NodeClaimAnnotation]
+	nodeClaim.CreationTimestamp = machine.CreationTimestamp
+	if nodePool := annotations[providers.NodePoolAnnotation]; nodePool != "" {
+		nodeClaim.Labels[karpv1.NodePoolLabelKey] = nodePool
+	}
+	if nodeClassName := annotations[providers.NodeClassAnnotation]; nodeClassName != "" {
+		nodeClaim.Spec.NodeClassRef = &karpv1.NodeClassReference{
+			Group: v1alpha1.Group,
+			Kind:  "ClusterAPINodeClass",
+			Name:  nodeClassName,
+		}
+	}
 
-	nodeClaim.Status.Capacity = capacity
+	// the failure domain of the Machine is where it was placed, which can be more specific than the MachineDeployment.
+	if zone := ptr.Deref(machine.Spec.FailureDomain, ""); zone != "" {
+		nodeClaim.Labels[corev1.LabelTopologyZone] = zone
+	}
+	nodeClaim.Spec.Requirements = requirementsFromLabels(nodeClaim.Labels)
+
+	nodeClaim.Status.ProviderID = ptr.Deref(machine.Spec.ProviderID, "")
+	if machine.Status.NodeRef != nil {
+		nodeClaim.Status.NodeName = machine.Status.NodeRef.Name
+	}
 
-	return &nodeClaim, nil
+	return nodeClaim, nil
+}
+
+// nodeClassFromMachine returns the NodeClass recorded on the Machine when it was bound to a NodeClaim, or nil when
+// it is not recorded or cannot be read.
+func (c *CloudProvider) nodeClassFromMachine(ctx context.Context, machine *capiv1beta1.Machine) *v1alpha1.ClusterAPINodeClass {
+	name := machine.GetAnnotations()[providers.NodeClassAnnotation]
+	if name == "" {
+		return nil
+	}
+
+	nodeClass := &v1alpha1.ClusterAPINodeClass{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: name}, nodeClass); err != nil {
+		log.FromContext(ctx).V(1).Info("unable to get NodeClass for Machine", "machine", machine.Name, "nodeClass", name, "error", err.Error())
+		return nil
+	}
+
+	return nodeClass
+}
+
+// requirementsFromLabels returns a requirement for each of the well known labels, so that the NodeClaim of a Machine
+// has the same instance type, zone and capacity type requirements as the NodeClaim that Karpenter created.
+func requirementsFromLabels(labels map[string]string) []karpv1.NodeSelectorRequirementWithMinValues {
+	requirements := []karpv1.NodeSelectorRequirementWithMinValues{}
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		if !karpv1.WellKnownLabels.Has(key) {
+			continue
+		}
+		requirements = append(requirements, karpv1.NodeSelectorRequirementWithMinValues{
+			NodeSelectorRequirement: corev1.NodeSelectorRequirement{
+				Key:      key,
+				Operator: corev1.NodeSelectorOpIn,
+				Values:   []string{labels[key]},
+			},
+		})
+	}
+
+	return requirements
+}
+
+// infrastructureTemplateStatus returns the scale from zero status of the infrastructure machine template
+// referenced by the MachineDeployment. Because the status fields are optional in the Cluster API contract,
+// any failure to read the template is logged and nil is returned so that the annotations can still be used.
+func (c *CloudProvider) infrastructureTemplateStatus(ctx context.Context, machineDeployment *capiv1beta1.MachineDeployment) *machinetemplate.Status {
+	if c.machineTemplateProvider == nil {
+		return nil
+	}
+
+	ref := machineDeployment.Spec.Template.Spec.InfrastructureRef
+	if ref.Name == "" {
+		return nil
+	}
+
+	status, err := c.machineTemplateProvider.GetStatus(ctx, &ref, machineDeployment.Namespace)
+	if err != nil {
+		log.FromContext(ctx).V(1).Info("unable to read infrastructure machine template status", "machineDeployment", machineDeployment.Name, "error", err.Error())
+		return nil
+	}
+
+	return status
 }
 
 func (c *CloudProvider) resolveNodeClassFromNodeClaim(ctx context.Context, nodeClaim *karpv1.NodeClaim) (*v1alpha1.ClusterAPINodeClass, error) {
@@ -463,56 +795,94 @@ func (c *CloudProvider) resolveNodeClassFromNodePool(ctx context.Context, nodePo
 	return nodeClass, nil
 }
 
-func capacityResourceListFromAnnotations(annotations map[string]string) corev1.ResourceList {
+func capacityResourceListFromAnnotations(ctx context.Context, annotations map[string]string) corev1.ResourceList {
 	capacity := corev1.ResourceList{}
 
 	if annotations == nil {
 		return capacity
 	}
 
-	cpu, found := annotations[cpuKey]
-	if found {
-		capacity[corev1.ResourceCPU] = resource.MustParse(cpu)
+	// an annotation with an invalid quantity is ignored, so that it does not prevent the other resources from
+	// being used.
+	setQuantity := func(name corev1.ResourceName, key string) {
+		value, found := annotations[key]
+		if !found {
+			return
+		}
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			log.FromContext(ctx).Error(err, "ignoring invalid capacity annotation", "annotation", key, "value", value)
+			return
+		}
+		capacity[name] = quantity
 	}
 
-	memory, found := annotations[memoryKey]
-	if found {
-		capacity[corev1.ResourceMemory] = resource.MustParse(memory)
+	setQuantity(corev1.ResourceCPU, cpuKey)
+	setQuantity(corev1.ResourceMemory, memoryKey)
+
+	// additional extended resources come first, so that the gpu annotations take precedence for the same resource.
+	for name, quantity := range extendedResourceListFromAnnotation(annotations[ExtendedResourcesAnnotation]) {
+		capacity[name] = quantity
 	}
 
-	gpuCount, found := annotations[gpuCountKey]
-	if found {
-		// if there is a count there must also be a type
-		gpuType, found := annotations[gpuTypeKey]
-		if found {
-			capacity[corev1.ResourceName(gpuType)] = resource.MustParse(gpuCount)
-		}
+	// if there is a count there must also be a type, which is the name of the extended resource.
+	if gpuType, found := annotations[gpuTypeKey]; found && isExtendedResourceName(gpuType) {
+		setQuantity(corev1.ResourceName(gpuType), gpuCountKey)
 	}
 
-	ephStorage, found := annotations[diskCapacityKey]
-	if found {
-		capacity[corev1.ResourceEphemeralStorage] = resource.MustParse(ephStorage)
+	setQuantity(corev1.ResourceEphemeralStorage, diskCapacityKey)
+	setQuantity(corev1.ResourcePods, maxPodsKey)
+
+	return capacity
+}
+
+// capacityResourceListFromMachineDeployment returns the capacity of the Machines created from the MachineDeployment.
+// The capacity from the infrastructure machine template status is used as the base, and the scale from zero
+// annotations on the MachineDeployment take precedence over it.
+func capacityResourceListFromMachineDeployment(ctx context.Context, machineDeployment *capiv1beta1.MachineDeployment, templateStatus *machinetemplate.Status) corev1.ResourceList {
+	capacity := corev1.ResourceList{}
+
+	if templateStatus != nil {
+		for name, quantity := range templateStatus.Capacity {
+			capacity[name] = quantity.DeepCopy()
+		}
 	}
 
-	maxPods, found := annotations[maxPodsKey]
-	if found {
-		capacity[corev1.ResourcePods] = resource.MustParse(maxPods)
+	for name, quantity := range capacityResourceListFromAnnotations(ctx, machineDeployment.GetAnnotations()) {
+		capacity[name] = quantity
 	}
 
 	return capacity
 }
 
-func createNodeClaimFromMachineDeployment(machineDeployment *capiv1beta1.MachineDeployment) *karpv1.NodeClaim {
+func createNodeClaimFromMachineDeployment(ctx context.Context, machineDeployment *capiv1beta1.MachineDeployment, templateStatus *machinetemplate.Status, nodeClass *v1alpha1.ClusterAPINodeClass, instanceTypeNamePattern *regexp.Regexp) *karpv1.NodeClaim {
 	nodeClaim := &karpv1.NodeClaim{}
 
-	instanceType := machineDeploymentToInstanceType(machineDeployment)
+	instanceType := machineDeploymentToInstanceType(ctx, machineDeployment, templateStatus)
+	applyKubeletConfiguration(instanceType, machineDeployment, nodeClass)
 	nodeClaim.Status.Capacity = instanceType.Capacity
 	nodeClaim.Status.Allocatable = instanceType.Allocatable()
 
-	// Set NodeClaim labels from the MachineDeployment
-	nodeClaim.Labels = nodeLabelsFromMachineDeployment(machineDeployment)
+	// Set NodeClaim labels from the MachineDeployment. A synthesized instance type name is not set on the
+	// instance type label, Karpenter copies the label to the Node and it would replace the value from the
+	// infrastructure provider.
+	nodeClaim.Labels = lo.Assign(nodeInfoLabelsFromMachineDeployment(machineDeployment, templateStatus), nodeLabelsFromMachineDeployment(machineDeployment))
+	nodeClaim.Labels = lo.Assign(
+		instanceTypeLabels(instanceType.Name, instanceType.Capacity, instanceTypeNamePattern),
+		acceleratorLabelsFromMachineDeployment(machineDeployment, instanceType.Capacity),
+		nodeClaim.Labels,
+	)
+	if zone := zoneFromMachineDeployment(machineDeployment, nodeClaim.Labels); zone != "" {
+		nodeClaim.Labels[corev1.LabelTopologyZone] = zone
+	}
+	capacityType := capacityTypeFromMachineDeployment(machineDeployment, nodeClaim.Labels)
+	nodeClaim.Labels[karpv1.CapacityTypeLabelKey] = capacityType
+	if capacityType == karpv1.CapacityTypeReserved {
+		nodeClaim.Labels[cloudprovider.ReservationIDLabel] = reservationIDFromMachineDeployment(machineDeployment)
+	}
 
-	// TODO (elmiko) add taints
+	// Set NodeClaim taints from the MachineDeployment
+	nodeClaim.Spec.Taints = instanceType.Taints
 
 	return nodeClaim
 }
@@ -520,15 +890,34 @@ func createNodeClaimFromMachineDeployment(machineDeployment *capiv1beta1.Machine
 func filterCompatibleInstanceTypes(instanceTypes []*ClusterAPIInstanceType, nodeClaim *karpv1.NodeClaim) []*ClusterAPIInstanceType {
 	reqs := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
 	filteredInstances := lo.Filter(instanceTypes, func(i *ClusterAPIInstanceType, _ int) bool {
-		// TODO (elmiko) if/when we have offering availability, this is a good place to filter out unavailable instance types
 		return reqs.Compatible(i.Requirements, scheduling.AllowUndefinedWellKnownLabels) == nil &&
 			resources.Fits(nodeClaim.Spec.Resources.Requests, i.Allocatable()) &&
-			i.Offerings.Available().HasCompatible(reqs)
+			i.Offerings.Available().HasCompatible(reqs) &&
+			nodeClaimExpectsTaints(nodeClaim, i.Taints)
 	})
 
 	return filteredInstances
 }
 
+// nodeClaimExpectsTaints returns true if every taint in the supplied list is declared in the
+// NodeClaim taints or startup taints. The scheduler only considers the taints from the NodePool
+// when placing pods, so an instance type that registers Nodes with additional taints would
+// result in pods that cannot tolerate the resulting Node.
+func nodeClaimExpectsTaints(nodeClaim *karpv1.NodeClaim, taints []corev1.Taint) bool {
+	return expectsTaints(nodeClaim.Spec.Taints, nodeClaim.Spec.StartupTaints, taints)
+}
+
+// expectsTaints returns true if every taint in the supplied list is in the declared taints or startup taints.
+func expectsTaints(declaredTaints, declaredStartupTaints, taints []corev1.Taint) bool {
+	expected := append(slices.Clone(declaredTaints), declaredStartupTaints...)
+	for _, taint := range taints {
+		if !lo.ContainsBy(expected, func(t corev1.Taint) bool { return t.MatchTaint(&taint) && t.Value == taint.Value }) {
+			return false
+		}
+	}
+	return true
+}
+
 func labelsFromScaleFromZeroAnnotation(annotation string) map[string]string {
 	labels := map[string]string{}
 
@@ -543,6 +932,37 @@ func labelsFromScaleFromZeroAnnotation(annotation string) map[string]string {
 	return labels
 }
 
+// taintsFromScaleFromZeroAnnotation parses the scale from zero taints annotation. The annotation
+// is a comma separated list of taints in the form "key=value:Effect" or "key:Effect", entries that
+// cannot be parsed, or that have an unknown effect, are ignored.
+func taintsFromScaleFromZeroAnnotation(annotation string) []corev1.Taint {
+	taints := []corev1.Taint{}
+
+	taintStrings := strings.Split(annotation, ",")
+	for _, taintString := range taintStrings {
+		keyValue, effect, found := strings.Cut(strings.TrimSpace(taintString), ":")
+		if !found {
+			continue
+		}
+
+		key, value, _ := strings.Cut(keyValue, "=")
+		if key == "" {
+			continue
+		}
+
+		switch corev1.TaintEffect(effect) {
+		case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+			taints = append(taints, corev1.Taint{
+				Key:    key,
+				Value:  value,
+				Effect: corev1.TaintEffect(effect),
+			})
+		}
+	}
+
+	return taints
+}
+
 // isBelowMaxSize checks if the MachineDeployment's current replicas are below the max size
 // defined by the Cluster Autoscaler annotation. If the annotation is not present or cannot
 // be parsed, it returns true (no limit enforced).
@@ -562,34 +982,42 @@ func isBelowMaxSize(machineDeployment *capiv1beta1.MachineDeployment) bool {
 	return replicas < maxSize
 }
 
-func machineDeploymentToInstanceType(machineDeployment *capiv1beta1.MachineDeployment) *ClusterAPIInstanceType {
+func machineDeploymentToInstanceType(ctx context.Context, machineDeployment *capiv1beta1.MachineDeployment, templateStatus *machinetemplate.Status) *ClusterAPIInstanceType {
 	instanceType := &ClusterAPIInstanceType{}
 
-	labels := nodeLabelsFromMachineDeployment(machineDeployment)
+	// the architecture and operating system from the template apply unless the MachineDeployment sets the labels.
+	labels := lo.Assign(nodeInfoLabelsFromMachineDeployment(machineDeployment, templateStatus), nodeLabelsFromMachineDeployment(machineDeployment))
 	requirements := []*scheduling.Requirement{}
 	for k, v := range labels {
 		requirements = append(requirements, scheduling.NewRequirement(k, corev1.NodeSelectorOpIn, v))
 	}
+	// the zone may come from the failure domain, in which case it is not part of the labels.
+	zone := zoneFromMachineDeployment(machineDeployment, labels)
+	if _, found := labels[corev1.LabelTopologyZone]; !found && zone != "" {
+		requirements = append(requirements, scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, zone))
+	}
 	instanceType.Requirements = scheduling.NewRequirements(requirements...)
 
-	capacity := capacityResourceListFromAnnotations(machineDeployment.GetAnnotations())
+	capacity := capacityResourceListFromMachineDeployment(ctx, machineDeployment, templateStatus)
 	instanceType.Capacity = capacity
 
-	// TODO (elmiko) add offerings info, TBD of where this would come from
-	// start with zone, read from the label and add to offering
-	// initial price is 0
-	// there is a single offering, and it is available
-	zone := zoneLabelFromLabels(labels)
-	requirements = []*scheduling.Requirement{
-		scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, zone),
-		scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, karpv1.CapacityTypeOnDemand),
+	// the accelerator labels are derived from the capacity, unless the MachineDeployment sets them itself.
+	for key, value := range acceleratorLabelsFromMachineDeployment(machineDeployment, capacity) {
+		if !instanceType.Requirements.Has(key) {
+			instanceType.Requirements.Add(scheduling.NewRequirement(key, corev1.NodeSelectorOpIn, value))
+		}
 	}
+
+	instanceType.Taints = nodeTaintsFromMachineDeployment(machineDeployment)
+
+	instanceType.Weight = weightFromMachineDeployment(machineDeployment)
+
+	// there is a single offering for the zone and capacity type of the MachineDeployment, offerings from
+	// equivalent MachineDeployments are merged when the instance types are listed.
+	// initial price is 0, it is set from the NodeClass pricing when the instance types are listed
+	capacityType := capacityTypeFromMachineDeployment(machineDeployment, labels)
 	offerings := cloudprovider.Offerings{
-		&cloudprovider.Offering{
-			Requirements: scheduling.NewRequirements(requirements...),
-			Price:        0.0,
-			Available:    isBelowMaxSize(machineDeployment),
-		},
+		offeringForMachineDeployment(machineDeployment, zone, capacityType),
 	}
 
 	instanceType.Offerings = offerings
@@ -598,18 +1026,24 @@ func machineDeploymentToInstanceType(machineDeployment *capiv1beta1.MachineDeplo
 	// as the well-known `node.kubernetes.io/instance-type` that will be on resulting nodes. Usually, a
 	// cloud controller, or similar, mechanism is used to apply this label.
 	// For now, we check the labels we know about from the MachineDeployment, if the instance type label
-	// is not there, leave it blank.
-	// to the v1.LabelInstanceTypeStable. if karpenter expects this to match the node, then we need to get this value through capi.
-	// TODO (jkyros) Add a test case to test this behavior
-	instanceType.Name = labels[corev1.LabelInstanceTypeStable]
+	// is not there, a name is synthesized from the shape of the MachineDeployment so that instance types
+	// from different MachineDeployments do not collapse into a single nameless instance type.
+	instanceType.Name = instanceTypeNameFromMachineDeployment(labels, capacity)
+	if _, found := labels[corev1.LabelInstanceTypeStable]; !found {
+		instanceType.Requirements.Add(scheduling.NewRequirement(corev1.LabelInstanceTypeStable, corev1.NodeSelectorOpIn, instanceType.Name))
+	}
 
-	// TODO (elmiko) add the proper overhead information, not sure where we will harvest this information.
-	// perhaps it needs to be a configurable option somewhere.
+	// the overhead is calculated from the kubelet settings of the NodeClass, see applyKubeletConfiguration.
 	instanceType.Overhead = &cloudprovider.InstanceTypeOverhead{}
 
 	// record the information from the MachineDeployment so we can find it again later.
-	instanceType.MachineDeploymentName = machineDeployment.Name
-	instanceType.MachineDeploymentNamespace = machineDeployment.Namespace
+	instanceType.MachineDeploymentOfferings = []MachineDeploymentOffering{
+		{
+			Offering:                   offerings[0],
+			MachineDeploymentName:      machineDeployment.Name,
+			MachineDeploymentNamespace: machineDeployment.Namespace,
+		},
+	}
 
 	return instanceType
 }
@@ -653,6 +1087,17 @@ func nodeLabelsFromMachineDeployment(machineDeployment *capiv1beta1.MachineDeplo
 	return labels
 }
 
+// nodeTaintsFromMachineDeployment returns the taints that will be on Nodes created from the MachineDeployment.
+func nodeTaintsFromMachineDeployment(machineDeployment *capiv1beta1.MachineDeployment) []corev1.Taint {
+	// the v1beta1 Machine template does not carry taints, so we rely on the scale-from-zero annotation.
+	// see https://github.com/kubernetes-sigs/cluster-api/blob/main/docs/proposals/20210310-opt-in-autoscaling-from-zero.md#machineset-and-machinedeployment-annotations
+	if annotation, found := machineDeployment.GetAnnotations()[taintsKey]; found {
+		return taintsFromScaleFromZeroAnnotation(annotation)
+	}
+
+	return []corev1.Taint{}
+}
+
 // zoneLabelFromLabels returns the value of the kubernetes well-known zone label or an empty string
 func zoneLabelFromLabels(labels map[string]string) string {
 	zone := ""
@@ -18,6 +18,7 @@ package cloudprovider
 
 import (
 	"context"
+	goerrors "errors"
 	"fmt"
 	"math/rand"
 	"time"
@@ -27,14 +28,19 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/ptr"
 	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/operator/options"
 	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers"
 	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machine"
 	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machinedeployment"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machinetemplate"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/scalableresource"
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 )
 
 var randsrc *rand.Rand
@@ -59,7 +65,8 @@ var _ = Describe("CloudProvider.Create method", func() {
 	BeforeEach(func() {
 		machineProvider := machine.NewDefaultProvider(context.Background(), cl)
 		machineDeploymentProvider := machinedeployment.NewDefaultProvider(context.Background(), cl)
-		provider = NewCloudProvider(context.Background(), cl, machineProvider, machineDeploymentProvider)
+		machineTemplateProvider := machinetemplate.NewDefaultProvider(context.Background(), cl)
+		provider = NewCloudProvider(context.Background(), cl, machineProvider, scalableresource.NewDefaultProvider(context.Background(), cl, machineProvider, machineDeploymentProvider, nil), machineTemplateProvider)
 	})
 
 	AfterEach(func() {
@@ -85,6 +92,91 @@ var _ = Describe("CloudProvider.Create method", func() {
 		Expect(createdNodeClaim).To(BeNil())
 
 	})
+
+	It("waits for the Machine to have a provider ID", func() {
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		machineDeployment.SetAnnotations(map[string]string{cpuKey: "4", memoryKey: "16Gi"})
+		Expect(cl.Create(context.Background(), machineDeployment)).To(Succeed())
+		machine := newMachine("m-1", "test-cluster", true)
+		machine.GetLabels()[capiv1beta1.MachineDeploymentNameLabel] = machineDeployment.Name
+		machine.Spec.ProviderID = nil
+		Expect(cl.Create(context.Background(), machine)).To(Succeed())
+
+		nodeClass := &v1alpha1.ClusterAPINodeClass{}
+		nodeClass.Name = "default"
+		Expect(cl.Create(context.Background(), nodeClass)).To(Succeed())
+
+		nodeClaim := &karpv1.NodeClaim{}
+		nodeClaim.Name = "pending-nodeclaim"
+		nodeClaim.Annotations = map[string]string{providers.MachineAnnotation: machine.Namespace + "/" + machine.Name}
+		nodeClaim.Spec.NodeClassRef = &karpv1.NodeClassReference{Name: nodeClass.Name}
+		createdNodeClaim, err := provider.Create(context.Background(), nodeClaim)
+		Expect(err).To(MatchError(ContainSubstring("waiting for Machine \"m-1\" to have ProviderID")))
+		Expect(createdNodeClaim).To(BeNil())
+
+		// in the asynchronous launch mode the NodeClaim is returned without a provider ID.
+		opts := &options.Options{AsyncLaunch: true}
+		asyncProvider := NewCloudProvider(opts.ToContext(context.Background()), cl, provider.machineProvider, provider.scalableResourceProvider, provider.machineTemplateProvider)
+		createdNodeClaim, err = asyncProvider.Create(context.Background(), nodeClaim)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(createdNodeClaim.Status.ProviderID).To(BeEmpty())
+		Expect(createdNodeClaim.Status.Capacity.Cpu().String()).To(Equal("4"))
+	})
+
+	It("returns a create error and marks the offering unavailable when the Machine has failed", func() {
+		machineDeployment := newMachineDeployment("md-failed", "test-cluster", true)
+		Expect(cl.Create(context.Background(), machineDeployment)).To(Succeed())
+		machine := newMachine("m-failed", "test-cluster", true)
+		machine.GetLabels()[capiv1beta1.MachineDeploymentNameLabel] = machineDeployment.Name
+		Expect(cl.Create(context.Background(), machine)).To(Succeed())
+		machine.Status.FailureMessage = ptr.To("quota exceeded")
+		Expect(cl.Status().Update(context.Background(), machine)).To(Succeed())
+
+		nodeClaim := &karpv1.NodeClaim{}
+		nodeClaim.Name = "failed-nodeclaim"
+		nodeClaim.Annotations = map[string]string{providers.MachineAnnotation: machine.Namespace + "/" + machine.Name}
+		stored := nodeClaim.DeepCopy()
+		createdNodeClaim, err := provider.Create(context.Background(), nodeClaim)
+		Expect(cloudprovider.IsInsufficientCapacityError(err)).To(BeFalse())
+		Expect(err).To(MatchError(ContainSubstring("quota exceeded")))
+		var createError *cloudprovider.CreateError
+		Expect(goerrors.As(err, &createError)).To(BeTrue())
+		Expect(createError.ConditionReason).To(Equal(MachineFailedReason))
+		Expect(createError.ConditionMessage).To(ContainSubstring("quota exceeded"))
+		Expect(createdNodeClaim).To(BeNil())
+		Expect(nodeClaim).To(Equal(stored))
+		Expect(provider.unavailableOfferings.IsUnavailable(machineDeployment.Namespace, machineDeployment.Name, "")).To(BeTrue())
+	})
+
+	It("returns a create error when the infrastructure of the Machine reports an error", func() {
+		machineDeployment := newMachineDeployment("md-not-ready", "test-cluster", true)
+		Expect(cl.Create(context.Background(), machineDeployment)).To(Succeed())
+		machine := newMachine("m-not-ready", "test-cluster", true)
+		machine.GetLabels()[capiv1beta1.MachineDeploymentNameLabel] = machineDeployment.Name
+		machine.Spec.ProviderID = nil
+		Expect(cl.Create(context.Background(), machine)).To(Succeed())
+		machine.Status.Conditions = capiv1beta1.Conditions{{
+			Type:               capiv1beta1.InfrastructureReadyCondition,
+			Status:             corev1.ConditionFalse,
+			Severity:           capiv1beta1.ConditionSeverityError,
+			Reason:             "InstanceProvisionFailed",
+			Message:            "subnet is full",
+			LastTransitionTime: metav1.Now(),
+		}}
+		Expect(cl.Status().Update(context.Background(), machine)).To(Succeed())
+
+		nodeClaim := &karpv1.NodeClaim{}
+		nodeClaim.Name = "not-ready-nodeclaim"
+		nodeClaim.Annotations = map[string]string{providers.MachineAnnotation: machine.Namespace + "/" + machine.Name}
+		createdNodeClaim, err := provider.Create(context.Background(), nodeClaim)
+		Expect(createdNodeClaim).To(BeNil())
+		Expect(cloudprovider.IsInsufficientCapacityError(err)).To(BeFalse())
+		var createError *cloudprovider.CreateError
+		Expect(goerrors.As(err, &createError)).To(BeTrue())
+		Expect(createError.ConditionReason).To(Equal(InfrastructureNotReadyReason))
+		Expect(createError.ConditionMessage).To(ContainSubstring("subnet is full"))
+		Expect(provider.unavailableOfferings.IsUnavailable(machineDeployment.Namespace, machineDeployment.Name, "")).To(BeFalse())
+	})
 })
 
 var _ = Describe("CloudProvider.Delete method", func() {
@@ -93,7 +185,8 @@ var _ = Describe("CloudProvider.Delete method", func() {
 	BeforeEach(func() {
 		machineProvider := machine.NewDefaultProvider(context.Background(), cl)
 		machineDeploymentProvider := machinedeployment.NewDefaultProvider(context.Background(), cl)
-		provider = NewCloudProvider(context.Background(), cl, machineProvider, machineDeploymentProvider)
+		machineTemplateProvider := machinetemplate.NewDefaultProvider(context.Background(), cl)
+		provider = NewCloudProvider(context.Background(), cl, machineProvider, scalableresource.NewDefaultProvider(context.Background(), cl, machineProvider, machineDeploymentProvider, nil), machineTemplateProvider)
 	})
 
 	AfterEach(func() {
@@ -135,6 +228,18 @@ var _ = Describe("CloudProvider.Delete method", func() {
 		Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("unable to delete NodeClaim %q, cannot find an owner MachineDeployment for Machine %q", nodeClaim.Name, machine.Name))))
 	})
 
+	It("returns an error naming the Machine when it is deleted without a NodeClaim", func() {
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		Expect(cl.Create(context.Background(), machineDeployment)).To(Succeed())
+
+		machine := newMachine("m-1", "test-cluster", true)
+		machine.GetLabels()[capiv1beta1.MachineDeploymentNameLabel] = machineDeployment.Name
+		Expect(cl.Create(context.Background(), machine)).To(Succeed())
+
+		err := provider.DeleteMachine(context.Background(), machine)
+		Expect(err).To(MatchError(fmt.Errorf("unable to delete Machine %q, MachineDeployment %q has nil replicas", machine.Name, machineDeployment.Name)))
+	})
+
 	It("returns an error when the owner MachineDeployment has nil replicas", func() {
 		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
 		Expect(cl.Create(context.Background(), machineDeployment)).To(Succeed())
@@ -172,6 +277,29 @@ var _ = Describe("CloudProvider.Delete method", func() {
 		Expect(err).To(MatchError(fmt.Errorf("unable to delete NodeClaim %q, MachineDeployment %q is already at zero replicas", nodeClaim.Name, machineDeployment.Name)))
 	})
 
+	It("returns an error when the owner MachineDeployment is at its minimum size", func() {
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		machineDeployment.Spec.Replicas = ptr.To(int32(1))
+		machineDeployment.SetAnnotations(map[string]string{capiv1beta1.AutoscalerMinSizeAnnotation: "1"})
+		Expect(cl.Create(context.Background(), machineDeployment)).To(Succeed())
+
+		machine := newMachine("m-1", "test-cluster", true)
+		machine.GetLabels()[capiv1beta1.MachineDeploymentNameLabel] = machineDeployment.Name
+		providerID := *machine.Spec.ProviderID
+		Expect(cl.Create(context.Background(), machine)).To(Succeed())
+
+		nodeClaim := karpv1.NodeClaim{
+			Status: karpv1.NodeClaimStatus{
+				ProviderID: providerID,
+			},
+		}
+		err := provider.Delete(context.Background(), &nodeClaim)
+		Expect(err).To(MatchError(fmt.Errorf("unable to delete NodeClaim %q, MachineDeployment %q is at its minimum size of 1 replicas", nodeClaim.Name, machineDeployment.Name)))
+
+		opts := &options.Options{DisableMinSizeCheck: true}
+		Expect(provider.Delete(opts.ToContext(context.Background()), &nodeClaim)).To(Succeed())
+	})
+
 	It("annotates the correct Machine and reduces replicas", func() {
 		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
 		machineDeployment.Spec.Replicas = ptr.To(int32(2))
@@ -201,8 +329,8 @@ var _ = Describe("CloudProvider.Delete method", func() {
 		}).Should(HaveKey(capiv1beta1.DeleteMachineAnnotation))
 
 		Eventually(func() *capiv1beta1.MachineDeployment {
-			md, err := provider.machineDeploymentProvider.Get(context.Background(), machineDeployment.Name, machineDeployment.Namespace)
-			Expect(err).ToNot(HaveOccurred())
+			md := &capiv1beta1.MachineDeployment{}
+			Expect(cl.Get(context.Background(), client.ObjectKeyFromObject(machineDeployment), md)).To(Succeed())
 			return md
 		}).Should(HaveField("Spec", HaveField("Replicas", ptr.To(int32(1)))))
 	})
@@ -214,7 +342,8 @@ var _ = Describe("CloudProvider.Get method", func() {
 	BeforeEach(func() {
 		machineProvider := machine.NewDefaultProvider(context.Background(), cl)
 		machineDeploymentProvider := machinedeployment.NewDefaultProvider(context.Background(), cl)
-		provider = NewCloudProvider(context.Background(), cl, machineProvider, machineDeploymentProvider)
+		machineTemplateProvider := machinetemplate.NewDefaultProvider(context.Background(), cl)
+		provider = NewCloudProvider(context.Background(), cl, machineProvider, scalableresource.NewDefaultProvider(context.Background(), cl, machineProvider, machineDeploymentProvider, nil), machineTemplateProvider)
 	})
 
 	AfterEach(func() {
@@ -257,6 +386,27 @@ var _ = Describe("CloudProvider.Get method", func() {
 		Expect(nodeClaim).ToNot(BeNil())
 		Expect(nodeClaim.Status).Should(HaveField("ProviderID", providerID))
 	})
+
+	It("does not set a synthesized instance type name on the NodeClaim", func() {
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		machineDeployment.SetAnnotations(map[string]string{cpuKey: "4", memoryKey: "16Gi"})
+		Expect(cl.Create(context.Background(), machineDeployment)).To(Succeed())
+
+		machine := newMachine("m-1", "test-cluster", true)
+		machine.GetLabels()[capiv1beta1.MachineDeploymentNameLabel] = machineDeployment.Name
+		Expect(cl.Create(context.Background(), machine)).To(Succeed())
+
+		nodeClaim, err := provider.Get(context.Background(), *machine.Spec.ProviderID)
+		Expect(err).ToNot(HaveOccurred())
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
+		Expect(instanceType.Name).To(Equal("c4-m16g"))
+		Expect(instanceType.Requirements.Get(corev1.LabelInstanceTypeStable).Values()).To(ConsistOf("c4-m16g"))
+		// the synthesized name is not set on the NodeClaim, so the Node keeps the label from the infrastructure provider.
+		Expect(nodeClaim.Labels).ToNot(HaveKey(corev1.LabelInstanceTypeStable))
+		Expect(nodeClaim.Labels).To(HaveKeyWithValue(InstanceCPULabelKey, "4"))
+		Expect(nodeClaim.Labels).To(HaveKeyWithValue(InstanceMemoryLabelKey, "16384"))
+		Expect(nodeClaim.Labels).ToNot(HaveKey(InstanceFamilyLabelKey))
+	})
 })
 
 var _ = Describe("CloudProvider.GetInstanceTypes method", func() {
@@ -265,7 +415,8 @@ var _ = Describe("CloudProvider.GetInstanceTypes method", func() {
 	BeforeEach(func() {
 		machineProvider := machine.NewDefaultProvider(context.Background(), cl)
 		machineDeploymentProvider := machinedeployment.NewDefaultProvider(context.Background(), cl)
-		provider = NewCloudProvider(context.Background(), cl, machineProvider, machineDeploymentProvider)
+		machineTemplateProvider := machinetemplate.NewDefaultProvider(context.Background(), cl)
+		provider = NewCloudProvider(context.Background(), cl, machineProvider, scalableresource.NewDefaultProvider(context.Background(), cl, machineProvider, machineDeploymentProvider, nil), machineTemplateProvider)
 	})
 
 	AfterEach(func() {
@@ -315,6 +466,113 @@ var _ = Describe("CloudProvider.GetInstanceTypes method", func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(instanceTypes).To(HaveLen(1))
 	})
+
+	It("returns the cached instance types until they are invalidated", func() {
+		nodeClass := &v1alpha1.ClusterAPINodeClass{}
+		nodeClass.Name = "default"
+		Expect(cl.Create(context.Background(), nodeClass)).To(Succeed())
+
+		nodePool := karpv1.NodePool{}
+		nodePool.Spec.Template.Spec.NodeClassRef = &karpv1.NodeClassReference{
+			Name: nodeClass.Name,
+		}
+
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		machineDeployment.SetAnnotations(map[string]string{
+			cpuKey:    "4",
+			memoryKey: "16777220Ki",
+		})
+		Expect(cl.Create(context.Background(), machineDeployment)).To(Succeed())
+
+		instanceTypes, err := provider.GetInstanceTypes(context.Background(), &nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).To(HaveLen(1))
+
+		machineDeployment = newMachineDeployment("md-2", "test-cluster", true)
+		machineDeployment.SetAnnotations(map[string]string{
+			cpuKey:    "8",
+			memoryKey: "33554432Ki",
+		})
+		Expect(cl.Create(context.Background(), machineDeployment)).To(Succeed())
+
+		instanceTypes, err = provider.GetInstanceTypes(context.Background(), &nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).To(HaveLen(1))
+
+		provider.InvalidateInstanceTypes(nodeClass.Name)
+
+		instanceTypes, err = provider.GetInstanceTypes(context.Background(), &nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).To(HaveLen(2))
+	})
+
+	It("only returns the instance types with tainted Nodes to NodePools which declare the taints", func() {
+		nodeClass := &v1alpha1.ClusterAPINodeClass{}
+		nodeClass.Name = "default"
+		Expect(cl.Create(context.Background(), nodeClass)).To(Succeed())
+
+		nodePool := karpv1.NodePool{}
+		nodePool.Spec.Template.Spec.NodeClassRef = &karpv1.NodeClassReference{
+			Name: nodeClass.Name,
+		}
+
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		machineDeployment.SetAnnotations(map[string]string{
+			cpuKey:    "4",
+			memoryKey: "16777220Ki",
+		})
+		Expect(cl.Create(context.Background(), machineDeployment)).To(Succeed())
+		machineDeployment = newMachineDeployment("md-2", "test-cluster", true)
+		machineDeployment.SetAnnotations(map[string]string{
+			cpuKey:    "8",
+			memoryKey: "33554432Ki",
+			taintsKey: "dedicated=gpu:NoSchedule",
+		})
+		Expect(cl.Create(context.Background(), machineDeployment)).To(Succeed())
+
+		instanceTypes, err := provider.GetInstanceTypes(context.Background(), &nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).To(HaveLen(1))
+		Expect(instanceTypes[0].Capacity.Cpu().String()).To(Equal("4"))
+
+		nodePool.Spec.Template.Spec.Taints = []corev1.Taint{{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}}
+		instanceTypes, err = provider.GetInstanceTypes(context.Background(), &nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).To(HaveLen(2))
+	})
+
+	It("sets the availability of cached offerings from recent launch failures", func() {
+		nodeClass := &v1alpha1.ClusterAPINodeClass{}
+		nodeClass.Name = "default"
+		Expect(cl.Create(context.Background(), nodeClass)).To(Succeed())
+
+		nodePool := karpv1.NodePool{}
+		nodePool.Spec.Template.Spec.NodeClassRef = &karpv1.NodeClassReference{
+			Name: nodeClass.Name,
+		}
+
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		machineDeployment.SetAnnotations(map[string]string{
+			cpuKey:    "4",
+			memoryKey: "16777220Ki",
+		})
+		Expect(cl.Create(context.Background(), machineDeployment)).To(Succeed())
+
+		instanceTypes, err := provider.GetInstanceTypes(context.Background(), &nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).To(HaveLen(1))
+		Expect(instanceTypes[0].Offerings[0].Available).To(BeTrue())
+
+		provider.unavailableOfferings.MarkUnavailable(context.Background(), "test", machineDeployment.Namespace, machineDeployment.Name, "")
+		instanceTypes, err = provider.GetInstanceTypes(context.Background(), &nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes[0].Offerings[0].Available).To(BeFalse())
+
+		provider.unavailableOfferings.Delete(machineDeployment.Namespace, machineDeployment.Name, "")
+		instanceTypes, err = provider.GetInstanceTypes(context.Background(), &nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes[0].Offerings[0].Available).To(BeTrue())
+	})
 })
 
 var _ = Describe("CloudProvider.List method", func() {
@@ -323,7 +581,8 @@ var _ = Describe("CloudProvider.List method", func() {
 	BeforeEach(func() {
 		machineProvider := machine.NewDefaultProvider(context.Background(), cl)
 		machineDeploymentProvider := machinedeployment.NewDefaultProvider(context.Background(), cl)
-		provider = NewCloudProvider(context.Background(), cl, machineProvider, machineDeploymentProvider)
+		machineTemplateProvider := machinetemplate.NewDefaultProvider(context.Background(), cl)
+		provider = NewCloudProvider(context.Background(), cl, machineProvider, scalableresource.NewDefaultProvider(context.Background(), cl, machineProvider, machineDeploymentProvider, nil), machineTemplateProvider)
 	})
 
 	AfterEach(func() {
@@ -384,7 +643,7 @@ var _ = Describe("CloudProvider.findInstanceTypesForNodeClass method", func() {
 
 	BeforeEach(func() {
 		machineDeploymentProvider := machinedeployment.NewDefaultProvider(context.Background(), cl)
-		provider = NewCloudProvider(context.Background(), cl, nil, machineDeploymentProvider)
+		provider = NewCloudProvider(context.Background(), cl, nil, scalableresource.NewDefaultProvider(context.Background(), cl, nil, machineDeploymentProvider, nil), nil)
 	})
 
 	AfterEach(func() {
@@ -405,7 +664,8 @@ var _ = Describe("CloudProvider.machineToNodeClaim method", func() {
 	BeforeEach(func() {
 		machineProvider := machine.NewDefaultProvider(context.Background(), cl)
 		machineDeploymentProvider := machinedeployment.NewDefaultProvider(context.Background(), cl)
-		provider = NewCloudProvider(context.Background(), cl, machineProvider, machineDeploymentProvider)
+		machineTemplateProvider := machinetemplate.NewDefaultProvider(context.Background(), cl)
+		provider = NewCloudProvider(context.Background(), cl, machineProvider, scalableresource.NewDefaultProvider(context.Background(), cl, machineProvider, machineDeploymentProvider, nil), machineTemplateProvider)
 	})
 
 	AfterEach(func() {
@@ -435,6 +695,67 @@ var _ = Describe("CloudProvider.machineToNodeClaim method", func() {
 		Expect(nodeClaim.Status.Capacity).Should(HaveKeyWithValue(corev1.ResourceMemory, memory))
 	})
 
+	It("reconstructs the NodeClaim from the Machine and its binding annotations", func() {
+		nodeClass := &v1alpha1.ClusterAPINodeClass{}
+		nodeClass.Name = "nodeclass-1"
+		nodeClass.Spec.Kubelet = &v1alpha1.KubeletConfiguration{KubeReserved: map[string]string{"cpu": "500m"}}
+		Expect(cl.Create(context.Background(), nodeClass)).To(Succeed())
+		DeferCleanup(func() { Expect(cl.Delete(context.Background(), nodeClass)).To(Succeed()) })
+
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		machineDeployment.SetAnnotations(map[string]string{
+			cpuKey:    "4",
+			memoryKey: "16777220Ki",
+			labelsKey: "node.kubernetes.io/instance-type=m1.large,topology.kubernetes.io/zone=zone-a",
+		})
+		Expect(cl.Create(context.Background(), machineDeployment)).To(Succeed())
+
+		machine := newMachine("m-1", "test-cluster", true)
+		machine.GetLabels()[capiv1beta1.MachineDeploymentNameLabel] = machineDeployment.Name
+		machine.SetAnnotations(providers.BindingAnnotations("nodeclaim-1", "default", nodeClass.Name))
+		machine.Spec.FailureDomain = ptr.To("zone-b")
+		Expect(cl.Create(context.Background(), machine)).To(Succeed())
+		machine.Status.NodeRef = &corev1.ObjectReference{Kind: "Node", Name: "node-1"}
+		Expect(cl.Status().Update(context.Background(), machine)).To(Succeed())
+
+		nodeClaim, err := provider.machineToNodeClaim(context.Background(), machine)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nodeClaim.Name).To(Equal("nodeclaim-1"))
+		Expect(nodeClaim.CreationTimestamp).To(Equal(machine.CreationTimestamp))
+		Expect(nodeClaim.Labels).To(HaveKeyWithValue(karpv1.NodePoolLabelKey, "default"))
+		Expect(nodeClaim.Labels).To(HaveKeyWithValue(corev1.LabelTopologyZone, "zone-b"))
+		Expect(nodeClaim.Spec.NodeClassRef).To(Equal(&karpv1.NodeClassReference{Group: v1alpha1.Group, Kind: "ClusterAPINodeClass", Name: nodeClass.Name}))
+		Expect(nodeClaim.Spec.Requirements).To(ContainElements(
+			karpv1.NodeSelectorRequirementWithMinValues{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelInstanceTypeStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"m1.large"}}},
+			karpv1.NodeSelectorRequirementWithMinValues{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"zone-b"}}},
+			karpv1.NodeSelectorRequirementWithMinValues{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.NodePoolLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{"default"}}},
+		))
+		Expect(nodeClaim.Status.ProviderID).To(Equal(*machine.Spec.ProviderID))
+		Expect(nodeClaim.Status.NodeName).To(Equal("node-1"))
+		Expect(nodeClaim.Status.Allocatable.Cpu().String()).To(Equal("3500m"))
+	})
+
+	It("returns a NodeClaim without a name or NodeClass for a Machine without binding annotations", func() {
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		machineDeployment.SetAnnotations(map[string]string{
+			cpuKey:    "4",
+			memoryKey: "16777220Ki",
+		})
+		Expect(cl.Create(context.Background(), machineDeployment)).To(Succeed())
+
+		machine := newMachine("m-1", "test-cluster", true)
+		machine.GetLabels()[capiv1beta1.MachineDeploymentNameLabel] = machineDeployment.Name
+		Expect(cl.Create(context.Background(), machine)).To(Succeed())
+
+		nodeClaim, err := provider.machineToNodeClaim(context.Background(), machine)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nodeClaim.Name).To(BeEmpty())
+		Expect(nodeClaim.Spec.NodeClassRef).To(BeNil())
+		Expect(nodeClaim.Labels).ToNot(HaveKey(karpv1.NodePoolLabelKey))
+		Expect(nodeClaim.Status.NodeName).To(BeEmpty())
+		Expect(nodeClaim.Status.Allocatable.Cpu().String()).To(Equal("4"))
+	})
+
 	It("returns an error when the cpu annotation is not on the MachineDeployment", func() {
 		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
 		annotations := map[string]string{
@@ -469,6 +790,25 @@ var _ = Describe("CloudProvider.machineToNodeClaim method", func() {
 		Expect(err).To(MatchError(fmt.Errorf("unable to convert Machine %q to a NodeClaim, no memory capacity found on MachineDeployment %q", machine.Name, machineDeployment.Name)))
 	})
 
+	It("returns the taints from the scale from zero annotation in the NodeClaim", func() {
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		annotations := map[string]string{
+			cpuKey:    "4",
+			memoryKey: "16777220Ki",
+			taintsKey: "dedicated=gpu:NoSchedule",
+		}
+		machineDeployment.SetAnnotations(annotations)
+		Expect(cl.Create(context.Background(), machineDeployment)).To(Succeed())
+
+		machine := newMachine("m-1", "test-cluster", true)
+		machine.GetLabels()[capiv1beta1.MachineDeploymentNameLabel] = machineDeployment.Name
+		Expect(cl.Create(context.Background(), machine)).To(Succeed())
+
+		nodeClaim, err := provider.machineToNodeClaim(context.Background(), machine)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nodeClaim.Spec.Taints).To(ConsistOf(corev1.Taint{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}))
+	})
+
 	It("returns an error when the MachineDeployment label is not present", func() {
 		machine := newMachine("m-1", "test-cluster", true)
 		Expect(cl.Create(context.Background(), machine)).To(Succeed())
@@ -487,13 +827,28 @@ var _ = Describe("CloudProvider.machineToNodeClaim method", func() {
 	})
 })
 
+var _ = Describe("requirementsFromLabels function", func() {
+	It("returns a requirement for each well known label", func() {
+		requirements := requirementsFromLabels(map[string]string{
+			corev1.LabelInstanceTypeStable: "m1.large",
+			karpv1.CapacityTypeLabelKey:    karpv1.CapacityTypeOnDemand,
+			"example.com/label":            "value",
+		})
+		Expect(requirements).To(Equal([]karpv1.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: karpv1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeOnDemand}}},
+			{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelInstanceTypeStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"m1.large"}}},
+		}))
+	})
+})
+
 var _ = Describe("CloudProvider.resolveNodeClassFromNodeClaim method", func() {
 	var provider *CloudProvider
 
 	BeforeEach(func() {
 		machineProvider := machine.NewDefaultProvider(context.Background(), cl)
 		machineDeploymentProvider := machinedeployment.NewDefaultProvider(context.Background(), cl)
-		provider = NewCloudProvider(context.Background(), cl, machineProvider, machineDeploymentProvider)
+		machineTemplateProvider := machinetemplate.NewDefaultProvider(context.Background(), cl)
+		provider = NewCloudProvider(context.Background(), cl, machineProvider, scalableresource.NewDefaultProvider(context.Background(), cl, machineProvider, machineDeploymentProvider, nil), machineTemplateProvider)
 	})
 
 	AfterEach(func() {
@@ -549,7 +904,8 @@ var _ = Describe("CloudProvider.resolveNodeClassFromNodePool method", func() {
 	BeforeEach(func() {
 		machineProvider := machine.NewDefaultProvider(context.Background(), cl)
 		machineDeploymentProvider := machinedeployment.NewDefaultProvider(context.Background(), cl)
-		provider = NewCloudProvider(context.Background(), cl, machineProvider, machineDeploymentProvider)
+		machineTemplateProvider := machinetemplate.NewDefaultProvider(context.Background(), cl)
+		provider = NewCloudProvider(context.Background(), cl, machineProvider, scalableresource.NewDefaultProvider(context.Background(), cl, machineProvider, machineDeploymentProvider, nil), machineTemplateProvider)
 	})
 
 	AfterEach(func() {
@@ -608,20 +964,21 @@ var _ = Describe("machineDeploymentToInstanceType function", func() {
 			gpuTypeKey:  "nvidia.com/gpu",
 		}
 
-		instanceType := machineDeploymentToInstanceType(machineDeployment)
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
 		Expect(instanceType.Capacity).Should(HaveKeyWithValue(corev1.ResourceCPU, resource.MustParse("1")))
 		Expect(instanceType.Capacity).Should(HaveKeyWithValue(corev1.ResourceMemory, resource.MustParse("16Gi")))
 		Expect(instanceType.Capacity).Should(HaveKeyWithValue(corev1.ResourceName("nvidia.com/gpu"), resource.MustParse("1")))
-		Expect(instanceType.MachineDeploymentName).To(Equal(machineDeployment.Name))
+		Expect(instanceType.MachineDeploymentOfferings[0].MachineDeploymentName).To(Equal(machineDeployment.Name))
 	})
 
-	It("adds nothing to requirements when no managed labels or scale from zero annotations are present", func() {
+	It("adds only the instance type name to requirements when no managed labels or scale from zero annotations are present", func() {
 		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
 		machineDeployment.Spec.Template.Labels = map[string]string{}
-		instanceType := machineDeploymentToInstanceType(machineDeployment)
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
 
-		Expect(instanceType.Requirements).To(HaveLen(0))
-		Expect(instanceType.MachineDeploymentName).To(Equal(machineDeployment.Name))
+		Expect(instanceType.Requirements).To(HaveLen(1))
+		Expect(instanceType.Requirements.Get(corev1.LabelInstanceTypeStable).Values()).To(ConsistOf(instanceType.Name))
+		Expect(instanceType.MachineDeploymentOfferings[0].MachineDeploymentName).To(Equal(machineDeployment.Name))
 	})
 
 	It("adds labels to the requirements from the Cluster API propagation rules", func() {
@@ -637,14 +994,15 @@ var _ = Describe("machineDeploymentToInstanceType function", func() {
 			"prefixed.node-role.kubernetes.io/no-propagate": "special-role",
 		}
 
-		instanceType := machineDeploymentToInstanceType(machineDeployment)
-		Expect(instanceType.Requirements).To(HaveLen(5))
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
+		Expect(instanceType.Requirements).To(HaveLen(6))
+		Expect(instanceType.Requirements).Should(HaveKey(corev1.LabelInstanceTypeStable))
 		Expect(instanceType.Requirements).Should(HaveKey(providers.NodePoolMemberLabel))
 		Expect(instanceType.Requirements).Should(HaveKey("node-restriction.kubernetes.io/some-thing"))
 		Expect(instanceType.Requirements).Should(HaveKey("prefixed.node-restriction.kubernetes.io/some-other-thing"))
 		Expect(instanceType.Requirements).Should(HaveKey("node.cluster.x-k8s.io/another-thing"))
 		Expect(instanceType.Requirements).Should(HaveKey("prefixed.node.cluster.x-k8s.io/another-thing"))
-		Expect(instanceType.MachineDeploymentName).To(Equal(machineDeployment.Name))
+		Expect(instanceType.MachineDeploymentOfferings[0].MachineDeploymentName).To(Equal(machineDeployment.Name))
 	})
 
 	It("adds labels to the requirements from the scale from zero annotations", func() {
@@ -654,11 +1012,12 @@ var _ = Describe("machineDeploymentToInstanceType function", func() {
 			"some-other-label": "stuff!",
 		}
 
-		instanceType := machineDeploymentToInstanceType(machineDeployment)
-		Expect(instanceType.Requirements).To(HaveLen(2))
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
+		Expect(instanceType.Requirements).To(HaveLen(3))
+		Expect(instanceType.Requirements).Should(HaveKey(corev1.LabelInstanceTypeStable))
 		Expect(instanceType.Requirements).Should(HaveKey(corev1.LabelTopologyZone))
 		Expect(instanceType.Requirements).Should(HaveKey(InstanceSizeLabelKey))
-		Expect(instanceType.MachineDeploymentName).To(Equal(machineDeployment.Name))
+		Expect(instanceType.MachineDeploymentOfferings[0].MachineDeploymentName).To(Equal(machineDeployment.Name))
 	})
 
 	It("adds labels to the requirements from the propagation rules and the scale from zero annotations", func() {
@@ -678,8 +1037,9 @@ var _ = Describe("machineDeploymentToInstanceType function", func() {
 			"prefixed.node-role.kubernetes.io/no-propagate": "special-role",
 		}
 
-		instanceType := machineDeploymentToInstanceType(machineDeployment)
-		Expect(instanceType.Requirements).To(HaveLen(7))
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
+		Expect(instanceType.Requirements).To(HaveLen(8))
+		Expect(instanceType.Requirements).Should(HaveKey(corev1.LabelInstanceTypeStable))
 		Expect(instanceType.Requirements).Should(HaveKey(corev1.LabelTopologyZone))
 		Expect(instanceType.Requirements).Should(HaveKey(InstanceSizeLabelKey))
 		Expect(instanceType.Requirements).Should(HaveKey(providers.NodePoolMemberLabel))
@@ -687,12 +1047,12 @@ var _ = Describe("machineDeploymentToInstanceType function", func() {
 		Expect(instanceType.Requirements).Should(HaveKey("prefixed.node-restriction.kubernetes.io/some-other-thing"))
 		Expect(instanceType.Requirements).Should(HaveKey("node.cluster.x-k8s.io/another-thing"))
 		Expect(instanceType.Requirements).Should(HaveKey("prefixed.node.cluster.x-k8s.io/another-thing"))
-		Expect(instanceType.MachineDeploymentName).To(Equal(machineDeployment.Name))
+		Expect(instanceType.MachineDeploymentOfferings[0].MachineDeploymentName).To(Equal(machineDeployment.Name))
 	})
 
 	It("adds a single available on-demand offering with price 0 and empty zone", func() {
 		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
-		instanceType := machineDeploymentToInstanceType(machineDeployment)
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
 		Expect(instanceType.Offerings).To(HaveLen(1))
 		offering := instanceType.Offerings[0]
 		Expect(offering).To(HaveField("Price", 0.0))
@@ -701,7 +1061,7 @@ var _ = Describe("machineDeploymentToInstanceType function", func() {
 		Expect(offering.Requirements[karpv1.CapacityTypeLabelKey].Values()).Should(ContainElement(karpv1.CapacityTypeOnDemand))
 		Expect(offering.Requirements).Should(HaveKey(corev1.LabelTopologyZone))
 		Expect(offering.Requirements[corev1.LabelTopologyZone].Values()).Should(ContainElement(""))
-		Expect(instanceType.MachineDeploymentName).To(Equal(machineDeployment.Name))
+		Expect(instanceType.MachineDeploymentOfferings[0].MachineDeploymentName).To(Equal(machineDeployment.Name))
 	})
 
 	It("adds the correct zone to offering when the well known zone label is present", func() {
@@ -711,12 +1071,165 @@ var _ = Describe("machineDeploymentToInstanceType function", func() {
 			// we need to add the zone label to the scale from zero annotations due to the capi metadata propagation rules
 			labelsKey: fmt.Sprintf("%s=%s", corev1.LabelTopologyZone, zone),
 		}
-		instanceType := machineDeploymentToInstanceType(machineDeployment)
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
 		Expect(instanceType.Offerings).To(HaveLen(1))
 		offering := instanceType.Offerings[0]
 		Expect(offering.Requirements).Should(HaveKey(corev1.LabelTopologyZone))
 		Expect(offering.Requirements[corev1.LabelTopologyZone].Values()).Should(ContainElement(zone))
-		Expect(instanceType.MachineDeploymentName).To(Equal(machineDeployment.Name))
+		Expect(instanceType.MachineDeploymentOfferings[0].MachineDeploymentName).To(Equal(machineDeployment.Name))
+	})
+})
+
+var _ = Describe("capacityResourceListFromMachineDeployment function", func() {
+	It("returns the capacity from the scale from zero annotations when there is no template status", func() {
+		md := newMachineDeployment("md-1", "test-cluster", true)
+		md.Annotations = map[string]string{
+			cpuKey:    "4",
+			memoryKey: "16Gi",
+		}
+		capacity := capacityResourceListFromMachineDeployment(context.Background(), md, nil)
+		Expect(capacity).To(HaveLen(2))
+		Expect(capacity).Should(HaveKeyWithValue(corev1.ResourceCPU, resource.MustParse("4")))
+		Expect(capacity).Should(HaveKeyWithValue(corev1.ResourceMemory, resource.MustParse("16Gi")))
+	})
+
+	It("ignores the annotations which are not valid quantities", func() {
+		md := newMachineDeployment("md-1", "test-cluster", true)
+		md.Annotations = map[string]string{
+			cpuKey:          "four",
+			memoryKey:       "16Gi",
+			diskCapacityKey: "100 GB",
+			maxPodsKey:      "many",
+		}
+		templateStatus := &machinetemplate.Status{
+			Capacity: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("8"),
+			},
+		}
+		capacity := capacityResourceListFromMachineDeployment(context.Background(), md, templateStatus)
+		Expect(capacity).To(HaveLen(2))
+		Expect(capacity).Should(HaveKeyWithValue(corev1.ResourceCPU, resource.MustParse("8")))
+		Expect(capacity).Should(HaveKeyWithValue(corev1.ResourceMemory, resource.MustParse("16Gi")))
+	})
+
+	It("returns the capacity from the template status when there are no annotations", func() {
+		md := newMachineDeployment("md-1", "test-cluster", true)
+		templateStatus := &machinetemplate.Status{
+			Capacity: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("8"),
+				corev1.ResourceMemory: resource.MustParse("32Gi"),
+			},
+		}
+		capacity := capacityResourceListFromMachineDeployment(context.Background(), md, templateStatus)
+		Expect(capacity).To(HaveLen(2))
+		Expect(capacity).Should(HaveKeyWithValue(corev1.ResourceCPU, resource.MustParse("8")))
+		Expect(capacity).Should(HaveKeyWithValue(corev1.ResourceMemory, resource.MustParse("32Gi")))
+	})
+
+	It("prefers the scale from zero annotations over the template status", func() {
+		md := newMachineDeployment("md-1", "test-cluster", true)
+		md.Annotations = map[string]string{
+			cpuKey: "4",
+		}
+		templateStatus := &machinetemplate.Status{
+			Capacity: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("8"),
+				corev1.ResourceMemory: resource.MustParse("32Gi"),
+			},
+		}
+		capacity := capacityResourceListFromMachineDeployment(context.Background(), md, templateStatus)
+		Expect(capacity).To(HaveLen(2))
+		Expect(capacity).Should(HaveKeyWithValue(corev1.ResourceCPU, resource.MustParse("4")))
+		Expect(capacity).Should(HaveKeyWithValue(corev1.ResourceMemory, resource.MustParse("32Gi")))
+	})
+})
+
+var _ = Describe("machineDeploymentToInstanceType taints behavior", func() {
+	It("adds no taints when the scale from zero annotation is not present", func() {
+		md := newMachineDeployment("md-1", "test-cluster", true)
+		instanceType := machineDeploymentToInstanceType(context.Background(), md, nil)
+		Expect(instanceType.Taints).To(BeEmpty())
+	})
+
+	It("adds taints from the scale from zero annotation", func() {
+		md := newMachineDeployment("md-1", "test-cluster", true)
+		md.Annotations = map[string]string{
+			taintsKey: "dedicated=gpu:NoSchedule,tenant:NoExecute",
+		}
+		instanceType := machineDeploymentToInstanceType(context.Background(), md, nil)
+		Expect(instanceType.Taints).To(ConsistOf(
+			corev1.Taint{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule},
+			corev1.Taint{Key: "tenant", Effect: corev1.TaintEffectNoExecute},
+		))
+	})
+})
+
+var _ = Describe("taintsFromScaleFromZeroAnnotation function", func() {
+	It("parses taints with and without values", func() {
+		taints := taintsFromScaleFromZeroAnnotation("key1=value1:NoSchedule,key2:PreferNoSchedule, key3=value3:NoExecute")
+		Expect(taints).To(Equal([]corev1.Taint{
+			{Key: "key1", Value: "value1", Effect: corev1.TaintEffectNoSchedule},
+			{Key: "key2", Effect: corev1.TaintEffectPreferNoSchedule},
+			{Key: "key3", Value: "value3", Effect: corev1.TaintEffectNoExecute},
+		}))
+	})
+
+	It("ignores taints that are malformed or have an unknown effect", func() {
+		taints := taintsFromScaleFromZeroAnnotation("no-effect=value,=value:NoSchedule,key=value:Sometimes,good=value:NoSchedule")
+		Expect(taints).To(Equal([]corev1.Taint{
+			{Key: "good", Value: "value", Effect: corev1.TaintEffectNoSchedule},
+		}))
+	})
+
+	It("returns an empty list for an empty annotation", func() {
+		Expect(taintsFromScaleFromZeroAnnotation("")).To(BeEmpty())
+	})
+})
+
+var _ = Describe("filterCompatibleInstanceTypes function", func() {
+	var instanceTypes []*ClusterAPIInstanceType
+
+	BeforeEach(func() {
+		tainted := newMachineDeployment("md-tainted", "test-cluster", true)
+		tainted.Annotations = map[string]string{
+			cpuKey:    "4",
+			memoryKey: "16Gi",
+			taintsKey: "dedicated=gpu:NoSchedule",
+		}
+		untainted := newMachineDeployment("md-untainted", "test-cluster", true)
+		untainted.Annotations = map[string]string{
+			cpuKey:    "4",
+			memoryKey: "16Gi",
+		}
+		instanceTypes = []*ClusterAPIInstanceType{
+			machineDeploymentToInstanceType(context.Background(), tainted, nil),
+			machineDeploymentToInstanceType(context.Background(), untainted, nil),
+		}
+	})
+
+	It("filters out instance types with taints the NodeClaim does not declare", func() {
+		nodeClaim := &karpv1.NodeClaim{}
+		compatible := filterCompatibleInstanceTypes(instanceTypes, nodeClaim)
+		Expect(compatible).To(HaveLen(1))
+		Expect(compatible[0].MachineDeploymentOfferings[0].MachineDeploymentName).To(Equal("md-untainted"))
+	})
+
+	It("keeps instance types with taints the NodeClaim declares", func() {
+		nodeClaim := &karpv1.NodeClaim{}
+		nodeClaim.Spec.Taints = []corev1.Taint{
+			{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule},
+		}
+		compatible := filterCompatibleInstanceTypes(instanceTypes, nodeClaim)
+		Expect(compatible).To(HaveLen(2))
+	})
+
+	It("keeps instance types with taints the NodeClaim declares as startup taints", func() {
+		nodeClaim := &karpv1.NodeClaim{}
+		nodeClaim.Spec.StartupTaints = []corev1.Taint{
+			{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule},
+		}
+		compatible := filterCompatibleInstanceTypes(instanceTypes, nodeClaim)
+		Expect(compatible).To(HaveLen(2))
 	})
 })
 
@@ -793,7 +1306,7 @@ var _ = Describe("machineDeploymentToInstanceType max size behavior", func() {
 		md.Annotations = map[string]string{
 			capiv1beta1.AutoscalerMaxSizeAnnotation: "10",
 		}
-		instanceType := machineDeploymentToInstanceType(md)
+		instanceType := machineDeploymentToInstanceType(context.Background(), md, nil)
 		Expect(instanceType.Offerings).To(HaveLen(1))
 		Expect(instanceType.Offerings[0]).To(HaveField("Available", false))
 	})
@@ -804,7 +1317,7 @@ var _ = Describe("machineDeploymentToInstanceType max size behavior", func() {
 		md.Annotations = map[string]string{
 			capiv1beta1.AutoscalerMaxSizeAnnotation: "10",
 		}
-		instanceType := machineDeploymentToInstanceType(md)
+		instanceType := machineDeploymentToInstanceType(context.Background(), md, nil)
 		Expect(instanceType.Offerings).To(HaveLen(1))
 		Expect(instanceType.Offerings[0]).To(HaveField("Available", true))
 	})
@@ -0,0 +1,97 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/operator/options"
+)
+
+// clusterNameFromOptions returns the name of the workload Cluster that Karpenter manages, or an empty string when
+// it is not configured.
+func clusterNameFromOptions(ctx context.Context) string {
+	opts := options.FromContext(ctx)
+	if opts == nil {
+		return ""
+	}
+	return opts.ClusterName
+}
+
+// clusterRefForNodeClass returns the workload Cluster of the NodeClass. This is its clusterRef, or the Cluster of
+// its machineTemplates, or the Cluster from the controller options. It returns nil when the NodeClass is not scoped
+// to a Cluster.
+func (c *CloudProvider) clusterRefForNodeClass(nodeClass *v1alpha1.ClusterAPINodeClass) *v1alpha1.ClusterReference {
+	if nodeClass.Spec.ClusterRef != nil {
+		return nodeClass.Spec.ClusterRef
+	}
+	if nodeClass.Spec.MachineTemplates != nil {
+		return &v1alpha1.ClusterReference{
+			Name:      nodeClass.Spec.MachineTemplates.ClusterName,
+			Namespace: nodeClass.Spec.MachineTemplates.Namespace,
+		}
+	}
+	if c.clusterName != "" {
+		return &v1alpha1.ClusterReference{Name: c.clusterName}
+	}
+	return nil
+}
+
+// selectorForCluster returns a copy of the selector which also requires the cluster name label of the Cluster. A nil
+// selector selects all the participating resources, so the returned selector only requires the label. The selector
+// is returned unchanged when the Cluster is nil.
+func selectorForCluster(selector *metav1.LabelSelector, clusterRef *v1alpha1.ClusterReference) *metav1.LabelSelector {
+	if clusterRef == nil {
+		return selector
+	}
+
+	scoped := &metav1.LabelSelector{}
+	if selector != nil {
+		scoped = selector.DeepCopy()
+	}
+	if scoped.MatchLabels == nil {
+		scoped.MatchLabels = map[string]string{}
+	}
+	scoped.MatchLabels[capiv1beta1.ClusterNameLabel] = clusterRef.Name
+
+	return scoped
+}
+
+// inCluster returns true if the object has the cluster name label of the Cluster and is in its namespace, when the
+// Cluster has one. All objects are in a nil Cluster.
+func inCluster(obj metav1.Object, clusterRef *v1alpha1.ClusterReference) bool {
+	if clusterRef == nil {
+		return true
+	}
+	if clusterRef.Namespace != "" && obj.GetNamespace() != clusterRef.Namespace {
+		return false
+	}
+	return obj.GetLabels()[capiv1beta1.ClusterNameLabel] == clusterRef.Name
+}
+
+// isManagedMachine returns true if the Machine belongs to the workload Cluster from the controller options, or when
+// that Cluster is not configured. Machines of other Clusters are not reported as NodeClaims.
+func (c *CloudProvider) isManagedMachine(machine *capiv1beta1.Machine) bool {
+	if c.clusterName == "" {
+		return true
+	}
+	return inCluster(machine, &v1alpha1.ClusterReference{Name: c.clusterName})
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/operator/options"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machine"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machinedeployment"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/scalableresource"
+)
+
+var _ = Describe("CloudProvider.clusterRefForNodeClass method", func() {
+	It("returns the clusterRef of the NodeClass", func() {
+		provider := &CloudProvider{clusterName: "option-cluster"}
+		nodeClass := &v1alpha1.ClusterAPINodeClass{}
+		nodeClass.Spec.ClusterRef = &v1alpha1.ClusterReference{Name: "test-cluster", Namespace: testNamespace}
+
+		Expect(provider.clusterRefForNodeClass(nodeClass)).To(Equal(&v1alpha1.ClusterReference{Name: "test-cluster", Namespace: testNamespace}))
+	})
+
+	It("returns the Cluster of the machine templates when the NodeClass does not have a clusterRef", func() {
+		provider := &CloudProvider{clusterName: "option-cluster"}
+		nodeClass := &v1alpha1.ClusterAPINodeClass{}
+		nodeClass.Spec.MachineTemplates = &v1alpha1.MachineTemplatesSpec{ClusterName: "test-cluster", Namespace: testNamespace}
+
+		Expect(provider.clusterRefForNodeClass(nodeClass)).To(Equal(&v1alpha1.ClusterReference{Name: "test-cluster", Namespace: testNamespace}))
+	})
+
+	It("returns the Cluster from the options when the NodeClass does not have one", func() {
+		provider := &CloudProvider{clusterName: "option-cluster"}
+
+		Expect(provider.clusterRefForNodeClass(&v1alpha1.ClusterAPINodeClass{})).To(Equal(&v1alpha1.ClusterReference{Name: "option-cluster"}))
+	})
+
+	It("returns nil when no Cluster is configured", func() {
+		provider := &CloudProvider{}
+
+		Expect(provider.clusterRefForNodeClass(&v1alpha1.ClusterAPINodeClass{})).To(BeNil())
+	})
+})
+
+var _ = Describe("selectorForCluster function", func() {
+	It("returns the selector unchanged without a Cluster", func() {
+		selector := &metav1.LabelSelector{MatchLabels: map[string]string{"a": "b"}}
+
+		Expect(selectorForCluster(selector, nil)).To(BeIdenticalTo(selector))
+		Expect(selectorForCluster(nil, nil)).To(BeNil())
+	})
+
+	It("adds the cluster name label to a copy of the selector", func() {
+		selector := &metav1.LabelSelector{MatchLabels: map[string]string{"a": "b"}}
+
+		scoped := selectorForCluster(selector, &v1alpha1.ClusterReference{Name: "test-cluster"})
+		Expect(scoped.MatchLabels).To(Equal(map[string]string{"a": "b", capiv1beta1.ClusterNameLabel: "test-cluster"}))
+		Expect(selector.MatchLabels).To(Equal(map[string]string{"a": "b"}))
+	})
+
+	It("returns a selector for the cluster name label when the selector is nil", func() {
+		scoped := selectorForCluster(nil, &v1alpha1.ClusterReference{Name: "test-cluster"})
+		Expect(scoped.MatchLabels).To(Equal(map[string]string{capiv1beta1.ClusterNameLabel: "test-cluster"}))
+	})
+})
+
+var _ = Describe("inCluster function", func() {
+	var machineDeployment *capiv1beta1.MachineDeployment
+
+	BeforeEach(func() {
+		machineDeployment = newMachineDeployment("md-1", "test-cluster", true)
+		machineDeployment.GetLabels()[capiv1beta1.ClusterNameLabel] = "test-cluster"
+	})
+
+	It("returns true for every object without a Cluster", func() {
+		Expect(inCluster(machineDeployment, nil)).To(BeTrue())
+		Expect(inCluster(newMachineDeployment("md-2", "other-cluster", true), nil)).To(BeTrue())
+	})
+
+	It("returns true when the cluster name label and namespace match", func() {
+		Expect(inCluster(machineDeployment, &v1alpha1.ClusterReference{Name: "test-cluster"})).To(BeTrue())
+		Expect(inCluster(machineDeployment, &v1alpha1.ClusterReference{Name: "test-cluster", Namespace: testNamespace})).To(BeTrue())
+	})
+
+	It("returns false when the cluster name label or namespace does not match", func() {
+		Expect(inCluster(machineDeployment, &v1alpha1.ClusterReference{Name: "other-cluster"})).To(BeFalse())
+		Expect(inCluster(machineDeployment, &v1alpha1.ClusterReference{Name: "test-cluster", Namespace: "other-namespace"})).To(BeFalse())
+		Expect(inCluster(newMachineDeployment("md-2", "test-cluster", true), &v1alpha1.ClusterReference{Name: "test-cluster"})).To(BeFalse())
+	})
+})
+
+var _ = Describe("CloudProvider scoped to a Cluster", func() {
+	var provider *CloudProvider
+
+	BeforeEach(func() {
+		ctx := (&options.Options{ClusterName: "test-cluster"}).ToContext(context.Background())
+		machineProvider := machine.NewDefaultProvider(ctx, cl)
+		machineDeploymentProvider := machinedeployment.NewDefaultProvider(ctx, cl)
+		provider = NewCloudProvider(ctx, cl, machineProvider, scalableresource.NewDefaultProvider(ctx, cl, machineProvider, machineDeploymentProvider, nil), nil)
+	})
+
+	AfterEach(func() {
+		eventuallyDeleteAllOf(cl, &capiv1beta1.Machine{}, &capiv1beta1.MachineList{})
+		eventuallyDeleteAllOf(cl, &capiv1beta1.MachineDeployment{}, &capiv1beta1.MachineDeploymentList{})
+	})
+
+	newClusterMachineDeployment := func(name, clusterName string) *capiv1beta1.MachineDeployment {
+		machineDeployment := newMachineDeployment(name, clusterName, true)
+		machineDeployment.GetLabels()[capiv1beta1.ClusterNameLabel] = clusterName
+		machineDeployment.SetAnnotations(map[string]string{
+			cpuKey:    "4",
+			memoryKey: "16777220Ki",
+		})
+		return machineDeployment
+	}
+
+	It("only returns the instance types of the Cluster of the NodeClass", func() {
+		Expect(cl.Create(context.Background(), newClusterMachineDeployment("md-1", "test-cluster"))).To(Succeed())
+		Expect(cl.Create(context.Background(), newClusterMachineDeployment("md-2", "other-cluster"))).To(Succeed())
+
+		instanceTypes, err := provider.findInstanceTypesForNodeClass(context.Background(), &v1alpha1.ClusterAPINodeClass{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).To(HaveLen(1))
+		Expect(instanceTypes[0].MachineDeploymentOfferings[0].MachineDeploymentName).To(Equal("md-1"))
+
+		nodeClass := &v1alpha1.ClusterAPINodeClass{}
+		nodeClass.Spec.ClusterRef = &v1alpha1.ClusterReference{Name: "other-cluster"}
+		instanceTypes, err = provider.findInstanceTypesForNodeClass(context.Background(), nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).To(HaveLen(1))
+		Expect(instanceTypes[0].MachineDeploymentOfferings[0].MachineDeploymentName).To(Equal("md-2"))
+
+		nodeClass.Spec.ClusterRef = &v1alpha1.ClusterReference{Name: "other-cluster", Namespace: "other-namespace"}
+		instanceTypes, err = provider.findInstanceTypesForNodeClass(context.Background(), nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).To(BeEmpty())
+	})
+
+	It("does not return the instance types of a MachineDeployment of the Cluster which is not a member", func() {
+		Expect(cl.Create(context.Background(), newClusterMachineDeployment("md-1", "test-cluster"))).To(Succeed())
+		nonMember := newClusterMachineDeployment("md-2", "test-cluster")
+		delete(nonMember.GetLabels(), providers.NodePoolMemberLabel)
+		Expect(cl.Create(context.Background(), nonMember)).To(Succeed())
+
+		instanceTypes, err := provider.findInstanceTypesForNodeClass(context.Background(), &v1alpha1.ClusterAPINodeClass{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).To(HaveLen(1))
+		Expect(instanceTypes[0].MachineDeploymentOfferings[0].MachineDeploymentName).To(Equal("md-1"))
+	})
+
+	It("only returns the Machines of the Cluster as NodeClaims", func() {
+		machineDeployment := newClusterMachineDeployment("md-1", "test-cluster")
+		Expect(cl.Create(context.Background(), machineDeployment)).To(Succeed())
+
+		m := newMachine("m-1", "test-cluster", true)
+		m.GetLabels()[capiv1beta1.MachineDeploymentNameLabel] = machineDeployment.Name
+		m.GetLabels()[capiv1beta1.ClusterNameLabel] = "test-cluster"
+		Expect(cl.Create(context.Background(), m)).To(Succeed())
+
+		other := newMachine("m-2", "other-cluster", true)
+		other.GetLabels()[capiv1beta1.MachineDeploymentNameLabel] = machineDeployment.Name
+		other.GetLabels()[capiv1beta1.ClusterNameLabel] = "other-cluster"
+		Expect(cl.Create(context.Background(), other)).To(Succeed())
+
+		nodeClaims, err := provider.List(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nodeClaims).To(HaveLen(1))
+		Expect(nodeClaims[0].Status.ProviderID).To(Equal(*m.Spec.ProviderID))
+
+		_, err = provider.Get(context.Background(), *other.Spec.ProviderID)
+		Expect(cloudprovider.IsNodeClaimNotFoundError(err)).To(BeTrue())
+	})
+
+	It("reports a Machine of another Cluster as drifted", func() {
+		m := newMachine("m-1", "other-cluster", true)
+		m.GetLabels()[capiv1beta1.ClusterNameLabel] = "other-cluster"
+
+		drifted, err := provider.isDrifted(context.Background(), nil, &v1alpha1.ClusterAPINodeClass{}, m, newClusterMachineDeployment("md-1", "other-cluster"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(drifted).To(Equal(NodeClassSelectorDrift))
+	})
+
+	It("does not delete a Machine of another Cluster found from the Machine annotation", func() {
+		m := newMachine("m-1", "other-cluster", true)
+		m.GetLabels()[capiv1beta1.ClusterNameLabel] = "other-cluster"
+		Expect(cl.Create(context.Background(), m)).To(Succeed())
+
+		nodeClaim := &karpv1.NodeClaim{}
+		nodeClaim.Name = "nodeclaim-1"
+		nodeClaim.Annotations = map[string]string{providers.MachineAnnotation: m.Namespace + "/" + m.Name}
+
+		err := provider.Delete(context.Background(), nodeClaim)
+		Expect(cloudprovider.IsNodeClaimNotFoundError(err)).To(BeTrue())
+		Expect(cl.Get(context.Background(), client.ObjectKeyFromObject(m), m)).To(Succeed())
+		Expect(m.GetAnnotations()).ToNot(HaveKey(capiv1beta1.DeleteMachineAnnotation))
+	})
+})
@@ -26,4 +26,49 @@ const (
 	InstanceFamilyLabelKey = v1alpha1.Group + "/instance-family"
 	InstanceMemoryLabelKey = v1alpha1.Group + "/instance-memory"
 	InstanceCPULabelKey    = v1alpha1.Group + "/instance-cpu"
+
+	// Labels for the accelerators of an instance type, they are only set when the instance type has accelerators
+	InstanceGPUCountLabelKey        = v1alpha1.Group + "/instance-gpu-count"
+	InstanceGPUManufacturerLabelKey = v1alpha1.Group + "/instance-gpu-manufacturer"
+	InstanceGPUModelLabelKey        = v1alpha1.Group + "/instance-gpu-model"
+	InstanceGPUMIGCountLabelKey     = v1alpha1.Group + "/instance-gpu-mig-count"
+)
+
+const (
+	// PriceAnnotation can be placed on a MachineDeployment to set the price of its instance type,
+	// the value is a decimal number, for example "0.096".
+	PriceAnnotation = v1alpha1.Group + "/price"
+	// WeightAnnotation can be placed on a MachineDeployment to set the weight of its instance type for the
+	// Weighted instance type selection strategy, the value is an integer and higher weights are preferred.
+	WeightAnnotation = v1alpha1.Group + "/weight"
+	// CapacityTypeAnnotation can be placed on a MachineDeployment to set the capacity type of its offerings,
+	// the value is one of "on-demand", "spot" or "reserved".
+	CapacityTypeAnnotation = v1alpha1.Group + "/capacity-type"
+	// ReservationIDAnnotation can be placed on a MachineDeployment with the reserved capacity type to identify
+	// its capacity reservation, the name of the MachineDeployment is used when it is missing.
+	ReservationIDAnnotation = v1alpha1.Group + "/reservation-id"
+	// KubeReservedAnnotation can be placed on a MachineDeployment to override the kubeReserved settings of the
+	// NodeClass for its instance type, the value has the format "cpu=100m,memory=1Gi".
+	KubeReservedAnnotation = v1alpha1.Group + "/kube-reserved"
+	// SystemReservedAnnotation can be placed on a MachineDeployment to override the systemReserved settings of
+	// the NodeClass for its instance type, the value has the format "cpu=100m,memory=1Gi".
+	SystemReservedAnnotation = v1alpha1.Group + "/system-reserved"
+	// EvictionHardAnnotation can be placed on a MachineDeployment to override the evictionHard settings of the
+	// NodeClass for its instance type, the value has the format "memory.available=100Mi,nodefs.available=10%".
+	EvictionHardAnnotation = v1alpha1.Group + "/eviction-hard"
+	// BootstrapTemplateAnnotation can be placed on an InfrastructureMachineTemplate in the ownerless provisioning
+	// mode to choose the bootstrap template of its Machines, the value is the name of a selected bootstrap template.
+	BootstrapTemplateAnnotation = v1alpha1.Group + "/bootstrap-template"
+	// ExtendedResourcesAnnotation can be placed on a MachineDeployment to add extended resources, such as
+	// accelerators, to the capacity of its instance type, the value has the format "nvidia.com/gpu=2,example.com/fpga=1".
+	ExtendedResourcesAnnotation = v1alpha1.Group + "/extended-resources"
+	// GPUModelAnnotation can be placed on a MachineDeployment with accelerators to set the value of the
+	// InstanceGPUModelLabelKey label, for example "a100".
+	GPUModelAnnotation = v1alpha1.Group + "/gpu-model"
+	// ArchitectureAnnotation can be placed on a MachineDeployment to set the kubernetes.io/arch label of its
+	// instance type when the infrastructure machine template does not publish status.nodeInfo, for example "arm64".
+	ArchitectureAnnotation = v1alpha1.Group + "/architecture"
+	// OperatingSystemAnnotation can be placed on a MachineDeployment to set the kubernetes.io/os label of its
+	// instance type when the infrastructure machine template does not publish status.nodeInfo, for example "windows".
+	OperatingSystemAnnotation = v1alpha1.Group + "/operating-system"
 )
@@ -0,0 +1,160 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/utils/ptr"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/apis/v1alpha1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+)
+
+const (
+	// NodeClassSelectorDrift means the ClusterAPINodeClass no longer selects the MachineDeployment that owns the Machine.
+	NodeClassSelectorDrift cloudprovider.DriftReason = "NodeClassSelectorDrift"
+	// VersionDrift means the Kubernetes version of the Machine differs from its MachineDeployment template.
+	VersionDrift cloudprovider.DriftReason = "VersionDrift"
+	// InfrastructureTemplateDrift means the infrastructure machine was cloned from a different template than
+	// the one referenced by the MachineDeployment template.
+	InfrastructureTemplateDrift cloudprovider.DriftReason = "InfrastructureTemplateDrift"
+	// BootstrapTemplateDrift means the bootstrap config was cloned from a different template than the one
+	// referenced by the MachineDeployment template.
+	BootstrapTemplateDrift cloudprovider.DriftReason = "BootstrapTemplateDrift"
+	// LabelsDrift means the labels that the MachineDeployment propagates to Nodes differ from the NodeClaim labels.
+	LabelsDrift cloudprovider.DriftReason = "LabelsDrift"
+)
+
+// isDrifted compares the Machine for a NodeClaim with the current template of its owning MachineDeployment
+// and returns the first reason for drift that is found, or an empty reason if the Machine is up to date.
+func (c *CloudProvider) isDrifted(ctx context.Context, nodeClaim *karpv1.NodeClaim, nodeClass *v1alpha1.ClusterAPINodeClass, machine *capiv1beta1.Machine, machineDeployment *capiv1beta1.MachineDeployment) (cloudprovider.DriftReason, error) {
+	if !inCluster(machine, c.clusterRefForNodeClass(nodeClass)) {
+		return NodeClassSelectorDrift, nil
+	}
+
+	selected, err := nodeClassSelectsMachineDeployment(nodeClass, machineDeployment)
+	if err != nil {
+		return "", err
+	}
+	if !selected {
+		return NodeClassSelectorDrift, nil
+	}
+
+	if ptr.Deref(machine.Spec.Version, "") != ptr.Deref(machineDeployment.Spec.Template.Spec.Version, "") {
+		return VersionDrift, nil
+	}
+
+	drifted, err := c.isClonedFromDifferentTemplate(ctx, &machine.Spec.InfrastructureRef, &machineDeployment.Spec.Template.Spec.InfrastructureRef, machine.Namespace)
+	if err != nil {
+		return "", fmt.Errorf("unable to determine infrastructure drift for Machine %q: %w", machine.Name, err)
+	}
+	if drifted {
+		return InfrastructureTemplateDrift, nil
+	}
+
+	drifted, err = c.isClonedFromDifferentTemplate(ctx, machine.Spec.Bootstrap.ConfigRef, machineDeployment.Spec.Template.Spec.Bootstrap.ConfigRef, machine.Namespace)
+	if err != nil {
+		return "", fmt.Errorf("unable to determine bootstrap drift for Machine %q: %w", machine.Name, err)
+	}
+	if drifted {
+		return BootstrapTemplateDrift, nil
+	}
+
+	if labelsDrifted(nodeClaim, machineDeployment) {
+		return LabelsDrift, nil
+	}
+
+	return "", nil
+}
+
+// isClonedFromDifferentTemplate follows the reference from a Machine to the object that Cluster API cloned
+// for it, and compares the cloned-from annotations on that object with the template reference from the
+// MachineDeployment. Objects without the annotations, such as those not created from a template, are
+// never considered drifted.
+func (c *CloudProvider) isClonedFromDifferentTemplate(ctx context.Context, ref *corev1.ObjectReference, templateRef *corev1.ObjectReference, namespace string) (bool, error) {
+	if ref == nil || ref.Name == "" {
+		// a Machine without a reference can only be drifted if the MachineDeployment now uses a template.
+		return templateRef != nil && templateRef.Name != "", nil
+	}
+
+	if templateRef == nil || templateRef.Name == "" {
+		return true, nil
+	}
+
+	if c.machineTemplateProvider == nil {
+		return false, nil
+	}
+
+	cloned, err := c.machineTemplateProvider.Get(ctx, ref, namespace)
+	if err != nil {
+		return false, err
+	}
+
+	annotations := cloned.GetAnnotations()
+	clonedFromName, found := annotations[capiv1beta1.TemplateClonedFromNameAnnotation]
+	if !found {
+		return false, nil
+	}
+	if clonedFromName != templateRef.Name {
+		return true, nil
+	}
+
+	clonedFromGroupKind, found := annotations[capiv1beta1.TemplateClonedFromGroupKindAnnotation]
+	if found && clonedFromGroupKind != templateRef.GroupVersionKind().GroupKind().String() {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// labelsDrifted returns true if any of the labels that the MachineDeployment propagates to its Nodes
+// are missing from, or have a different value on, the NodeClaim.
+func labelsDrifted(nodeClaim *karpv1.NodeClaim, machineDeployment *capiv1beta1.MachineDeployment) bool {
+	for key, value := range nodeLabelsFromMachineDeployment(machineDeployment) {
+		if current, found := nodeClaim.Labels[key]; !found || current != value {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeClassSelectsMachineDeployment returns true if the NodeClass scalable resource selector matches the
+// MachineDeployment. A nil selector matches all participating MachineDeployments. In the ownerless provisioning
+// mode the MachineDeployment is the view of an InfrastructureMachineTemplate, and the template selector is used.
+func nodeClassSelectsMachineDeployment(nodeClass *v1alpha1.ClusterAPINodeClass, machineDeployment *capiv1beta1.MachineDeployment) (bool, error) {
+	labelSelector := nodeClass.Spec.ScalableResourceSelector
+	if nodeClass.Spec.MachineTemplates != nil {
+		labelSelector = nodeClass.Spec.MachineTemplates.InfrastructureTemplates.Selector
+	}
+	if labelSelector == nil {
+		return true, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		return false, fmt.Errorf("unable to convert selector for NodeClass %q: %w", nodeClass.Name, err)
+	}
+
+	return selector.Matches(labels.Set(machineDeployment.GetLabels())), nil
+}
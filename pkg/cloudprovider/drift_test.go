@@ -0,0 +1,302 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machine"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machinedeployment"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machinetemplate"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/scalableresource"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+)
+
+var _ = Describe("CloudProvider.IsDrifted method", func() {
+	var provider *CloudProvider
+	var nodeClass *v1alpha1.ClusterAPINodeClass
+
+	BeforeEach(func() {
+		machineProvider := machine.NewDefaultProvider(context.Background(), cl)
+		machineDeploymentProvider := machinedeployment.NewDefaultProvider(context.Background(), cl)
+		provider = NewCloudProvider(context.Background(), cl, machineProvider, scalableresource.NewDefaultProvider(context.Background(), cl, machineProvider, machineDeploymentProvider, nil), nil)
+
+		nodeClass = &v1alpha1.ClusterAPINodeClass{}
+		nodeClass.Name = "default"
+		Expect(cl.Create(context.Background(), nodeClass)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		eventuallyDeleteAllOf(cl, &capiv1beta1.Machine{}, &capiv1beta1.MachineList{})
+		eventuallyDeleteAllOf(cl, &capiv1beta1.MachineDeployment{}, &capiv1beta1.MachineDeploymentList{})
+		eventuallyDeleteAllOf(cl, &v1alpha1.ClusterAPINodeClass{}, &v1alpha1.ClusterAPINodeClassList{})
+	})
+
+	// createMachineAndNodeClaim creates a Machine owned by the MachineDeployment and returns a NodeClaim for it.
+	createMachineAndNodeClaim := func(machineDeployment *capiv1beta1.MachineDeployment) *karpv1.NodeClaim {
+		GinkgoHelper()
+		m := newMachine("m-1", "test-cluster", true)
+		m.GetLabels()[capiv1beta1.MachineDeploymentNameLabel] = machineDeployment.Name
+		m.Spec.Version = machineDeployment.Spec.Template.Spec.Version
+		Expect(cl.Create(context.Background(), m)).To(Succeed())
+
+		nodeClaim := &karpv1.NodeClaim{}
+		nodeClaim.Name = "nodeclaim-1"
+		nodeClaim.Labels = nodeLabelsFromMachineDeployment(machineDeployment)
+		nodeClaim.Spec.NodeClassRef = &karpv1.NodeClassReference{Name: nodeClass.Name}
+		nodeClaim.Status.ProviderID = *m.Spec.ProviderID
+		return nodeClaim
+	}
+
+	It("returns an error when the NodeClaim has no Machine reference", func() {
+		nodeClaim := &karpv1.NodeClaim{}
+		nodeClaim.Name = "nodeclaim-1"
+		reason, err := provider.IsDrifted(context.Background(), nodeClaim)
+		Expect(err).To(HaveOccurred())
+		Expect(reason).To(BeEmpty())
+	})
+
+	It("returns no drift when the Machine matches its MachineDeployment", func() {
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		machineDeployment.Spec.Template.Spec.Version = ptr.To("v1.33.0")
+		machineDeployment.Spec.Template.Labels = map[string]string{"node-role.kubernetes.io/worker": ""}
+		Expect(cl.Create(context.Background(), machineDeployment)).To(Succeed())
+		nodeClaim := createMachineAndNodeClaim(machineDeployment)
+
+		reason, err := provider.IsDrifted(context.Background(), nodeClaim)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reason).To(BeEmpty())
+	})
+
+	It("returns VersionDrift when the MachineDeployment version changes", func() {
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		machineDeployment.Spec.Template.Spec.Version = ptr.To("v1.33.0")
+		Expect(cl.Create(context.Background(), machineDeployment)).To(Succeed())
+		nodeClaim := createMachineAndNodeClaim(machineDeployment)
+
+		machineDeployment.Spec.Template.Spec.Version = ptr.To("v1.34.0")
+		Expect(cl.Update(context.Background(), machineDeployment)).To(Succeed())
+
+		reason, err := provider.IsDrifted(context.Background(), nodeClaim)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reason).To(Equal(VersionDrift))
+	})
+
+	It("returns LabelsDrift when the MachineDeployment propagates a new label", func() {
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		Expect(cl.Create(context.Background(), machineDeployment)).To(Succeed())
+		nodeClaim := createMachineAndNodeClaim(machineDeployment)
+
+		machineDeployment.Spec.Template.Labels = map[string]string{"node-role.kubernetes.io/worker": ""}
+		Expect(cl.Update(context.Background(), machineDeployment)).To(Succeed())
+
+		reason, err := provider.IsDrifted(context.Background(), nodeClaim)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reason).To(Equal(LabelsDrift))
+	})
+
+	It("returns NodeClassSelectorDrift when the NodeClass no longer selects the MachineDeployment", func() {
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		Expect(cl.Create(context.Background(), machineDeployment)).To(Succeed())
+		nodeClaim := createMachineAndNodeClaim(machineDeployment)
+
+		nodeClass.Spec.ScalableResourceSelector = &metav1.LabelSelector{
+			MatchLabels: map[string]string{"pool": "other"},
+		}
+		Expect(cl.Update(context.Background(), nodeClass)).To(Succeed())
+
+		reason, err := provider.IsDrifted(context.Background(), nodeClaim)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reason).To(Equal(NodeClassSelectorDrift))
+	})
+})
+
+var _ = Describe("CloudProvider.isClonedFromDifferentTemplate method", func() {
+	var provider *CloudProvider
+	var templateProvider *fakeTemplateProvider
+	var ref, templateRef *corev1.ObjectReference
+
+	BeforeEach(func() {
+		templateProvider = &fakeTemplateProvider{objects: map[string]*unstructured.Unstructured{}}
+		provider = &CloudProvider{machineTemplateProvider: templateProvider}
+		ref = &corev1.ObjectReference{
+			APIVersion: "infrastructure.cluster.x-k8s.io/v1beta1",
+			Kind:       "TestMachine",
+			Name:       "machine-1",
+		}
+		templateRef = &corev1.ObjectReference{
+			APIVersion: "infrastructure.cluster.x-k8s.io/v1beta1",
+			Kind:       "TestMachineTemplate",
+			Name:       "template-1",
+		}
+	})
+
+	addClonedObject := func(annotations map[string]string) {
+		u := &unstructured.Unstructured{}
+		u.SetName(ref.Name)
+		u.SetAnnotations(annotations)
+		templateProvider.objects[ref.Name] = u
+	}
+
+	It("returns false when the object was cloned from the current template", func() {
+		addClonedObject(map[string]string{
+			capiv1beta1.TemplateClonedFromNameAnnotation:      "template-1",
+			capiv1beta1.TemplateClonedFromGroupKindAnnotation: "TestMachineTemplate.infrastructure.cluster.x-k8s.io",
+		})
+		drifted, err := provider.isClonedFromDifferentTemplate(context.Background(), ref, templateRef, testNamespace)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(drifted).To(BeFalse())
+	})
+
+	It("returns true when the object was cloned from a template with a different name", func() {
+		addClonedObject(map[string]string{
+			capiv1beta1.TemplateClonedFromNameAnnotation: "template-0",
+		})
+		drifted, err := provider.isClonedFromDifferentTemplate(context.Background(), ref, templateRef, testNamespace)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(drifted).To(BeTrue())
+	})
+
+	It("returns true when the object was cloned from a template of a different kind", func() {
+		addClonedObject(map[string]string{
+			capiv1beta1.TemplateClonedFromNameAnnotation:      "template-1",
+			capiv1beta1.TemplateClonedFromGroupKindAnnotation: "OtherMachineTemplate.infrastructure.cluster.x-k8s.io",
+		})
+		drifted, err := provider.isClonedFromDifferentTemplate(context.Background(), ref, templateRef, testNamespace)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(drifted).To(BeTrue())
+	})
+
+	It("returns false when the object has no cloned-from annotations", func() {
+		addClonedObject(nil)
+		drifted, err := provider.isClonedFromDifferentTemplate(context.Background(), ref, templateRef, testNamespace)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(drifted).To(BeFalse())
+	})
+
+	It("returns true when the MachineDeployment no longer references a template", func() {
+		drifted, err := provider.isClonedFromDifferentTemplate(context.Background(), ref, nil, testNamespace)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(drifted).To(BeTrue())
+	})
+
+	It("returns an error when the cloned object cannot be found", func() {
+		drifted, err := provider.isClonedFromDifferentTemplate(context.Background(), ref, templateRef, testNamespace)
+		Expect(err).To(HaveOccurred())
+		Expect(drifted).To(BeFalse())
+	})
+})
+
+var _ = Describe("nodeClassSelectsMachineDeployment function", func() {
+	It("returns true when the NodeClass has no selector", func() {
+		nodeClass := &v1alpha1.ClusterAPINodeClass{}
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		Expect(nodeClassSelectsMachineDeployment(nodeClass, machineDeployment)).To(BeTrue())
+	})
+
+	It("returns the result of matching the selector against the MachineDeployment labels", func() {
+		nodeClass := &v1alpha1.ClusterAPINodeClass{}
+		nodeClass.Spec.ScalableResourceSelector = &metav1.LabelSelector{
+			MatchLabels: map[string]string{providers.NodePoolMemberLabel: ""},
+		}
+		Expect(nodeClassSelectsMachineDeployment(nodeClass, newMachineDeployment("md-1", "test-cluster", true))).To(BeTrue())
+		Expect(nodeClassSelectsMachineDeployment(nodeClass, newMachineDeployment("md-2", "test-cluster", false))).To(BeFalse())
+	})
+})
+
+// fakeTemplateProvider returns unstructured objects by name for testing drift of cloned objects, and the
+// ownerless provisioning mode.
+type fakeTemplateProvider struct {
+	objects map[string]*unstructured.Unstructured
+}
+
+func (p *fakeTemplateProvider) Get(_ context.Context, ref *corev1.ObjectReference, _ string) (*unstructured.Unstructured, error) {
+	u, found := p.objects[ref.Kind+"/"+ref.Name]
+	if !found {
+		u, found = p.objects[ref.Name]
+	}
+	if !found {
+		return nil, fmt.Errorf("unable to get %s %s: %w", ref.Kind, ref.Name, apierrors.NewNotFound(schema.GroupResource{Resource: ref.Kind}, ref.Name))
+	}
+	return u, nil
+}
+
+func (p *fakeTemplateProvider) GetStatus(ctx context.Context, ref *corev1.ObjectReference, namespace string) (*machinetemplate.Status, error) {
+	u, err := p.Get(ctx, ref, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return machinetemplate.StatusFromTemplate(u), nil
+}
+
+func (p *fakeTemplateProvider) List(_ context.Context, apiVersion string, kind string, _ string, selector *metav1.LabelSelector) ([]*unstructured.Unstructured, error) {
+	sm := labels.Everything()
+	if selector != nil {
+		var err error
+		if sm, err = metav1.LabelSelectorAsSelector(selector); err != nil {
+			return nil, err
+		}
+	}
+
+	names := slices.Sorted(maps.Keys(p.objects))
+	templates := []*unstructured.Unstructured{}
+	for _, name := range names {
+		u := p.objects[name]
+		if u.GetAPIVersion() == apiVersion && u.GetKind() == kind && sm.Matches(labels.Set(u.GetLabels())) {
+			templates = append(templates, u)
+		}
+	}
+	return templates, nil
+}
+
+func (p *fakeTemplateProvider) Clone(_ context.Context, template *unstructured.Unstructured, name string, objectLabels map[string]string) (*corev1.ObjectReference, error) {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion(template.GetAPIVersion())
+	u.SetKind(strings.TrimSuffix(template.GetKind(), "Template"))
+	u.SetName(name)
+	u.SetNamespace(template.GetNamespace())
+	u.SetLabels(objectLabels)
+	u.SetAnnotations(map[string]string{
+		capiv1beta1.TemplateClonedFromNameAnnotation:      template.GetName(),
+		capiv1beta1.TemplateClonedFromGroupKindAnnotation: template.GroupVersionKind().GroupKind().String(),
+	})
+	// cloned objects are stored by kind, as the infrastructure machine and bootstrap config have the same name.
+	p.objects[u.GetKind()+"/"+name] = u
+	return &corev1.ObjectReference{APIVersion: u.GetAPIVersion(), Kind: u.GetKind(), Name: name, Namespace: u.GetNamespace()}, nil
+}
+
+func (p *fakeTemplateProvider) Delete(_ context.Context, ref *corev1.ObjectReference, _ string) error {
+	delete(p.objects, ref.Kind+"/"+ref.Name)
+	delete(p.objects, ref.Name)
+	return nil
+}
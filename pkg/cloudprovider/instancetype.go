@@ -0,0 +1,123 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/operator/options"
+)
+
+// instanceTypeNamePatternFromOptions returns the pattern for the instance family and size labels, the default
+// pattern is used when there are no options.
+func instanceTypeNamePatternFromOptions(ctx context.Context) *regexp.Regexp {
+	pattern := options.DefaultInstanceTypeNamePattern
+	if opts := options.FromContext(ctx); opts != nil {
+		pattern = opts.InstanceTypeNamePattern
+	}
+
+	compiled, err := options.ParseInstanceTypeNamePattern(pattern)
+	if err != nil {
+		// the options are validated when they are parsed, this should not happen.
+		log.FromContext(ctx).Error(err, "unable to parse instance type name pattern, ignoring it")
+		return nil
+	}
+	return compiled
+}
+
+// instanceTypeLabels returns the instance cpu, memory, family and size labels for an instance type. The cpu is the
+// number of cores and the memory is in mebibytes, they are omitted when the capacity does not have them. The family
+// and size are the named groups of the pattern in the instance type name, they are omitted when the pattern is nil
+// or does not match.
+func instanceTypeLabels(name string, capacity corev1.ResourceList, pattern *regexp.Regexp) map[string]string {
+	labels := map[string]string{}
+
+	if cpu, found := capacity[corev1.ResourceCPU]; found && !cpu.IsZero() {
+		labels[InstanceCPULabelKey] = strconv.FormatInt(cpu.Value(), 10)
+	}
+	if memory, found := capacity[corev1.ResourceMemory]; found && !memory.IsZero() {
+		labels[InstanceMemoryLabelKey] = strconv.FormatInt(memory.Value()>>20, 10)
+	}
+
+	if pattern == nil {
+		return labels
+	}
+	match := pattern.FindStringSubmatch(name)
+	if match == nil {
+		return labels
+	}
+	for key, group := range map[string]string{InstanceFamilyLabelKey: "family", InstanceSizeLabelKey: "size"} {
+		if index := pattern.SubexpIndex(group); index >= 0 && match[index] != "" {
+			labels[key] = match[index]
+		}
+	}
+
+	return labels
+}
+
+// applyInstanceTypeLabels adds the instance type labels to the requirements of the instance type, the labels which
+// the MachineDeployment already sets are not changed.
+func applyInstanceTypeLabels(instanceType *ClusterAPIInstanceType, pattern *regexp.Regexp) {
+	for key, value := range instanceTypeLabels(instanceType.Name, instanceType.Capacity, pattern) {
+		if !instanceType.Requirements.Has(key) {
+			instanceType.Requirements.Add(scheduling.NewRequirement(key, corev1.NodeSelectorOpIn, value))
+		}
+	}
+}
+
+// instanceTypeNameFromMachineDeployment returns the value of the node.kubernetes.io/instance-type label from the
+// MachineDeployment labels, or a synthesized name when the label is not set.
+func instanceTypeNameFromMachineDeployment(labels map[string]string, capacity corev1.ResourceList) string {
+	if name := labels[corev1.LabelInstanceTypeStable]; name != "" {
+		return name
+	}
+	return synthesizedInstanceTypeName(capacity)
+}
+
+// synthesizedInstanceTypeName returns the name for an instance type whose MachineDeployment does not have the
+// node.kubernetes.io/instance-type label. The name only has the cpu, memory and number of GPUs and MIG partitions
+// of the instance type, for example c4-m16g, c8-m64g-g2 or c8-m64g-mig7, so that it does not change when other labels
+// or annotations of the MachineDeployment are edited, and MachineDeployments of the same size in different zones
+// share it.
+func synthesizedInstanceTypeName(capacity corev1.ResourceList) string {
+	name := fmt.Sprintf("c%s-m%s", capacity.Cpu().String(), memoryNameFromQuantity(capacity.Memory()))
+	if count := acceleratorCount(capacity); count > 0 {
+		name = fmt.Sprintf("%s-g%d", name, count)
+	}
+	if count := migCount(capacity); count > 0 {
+		name = fmt.Sprintf("%s-mig%d", name, count)
+	}
+	return name
+}
+
+// memoryNameFromQuantity returns the memory for an instance type name in gibibytes, or in mebibytes when the
+// memory is not a whole number of gibibytes.
+func memoryNameFromQuantity(memory *resource.Quantity) string {
+	bytes := memory.Value()
+	if bytes%(1<<30) == 0 {
+		return fmt.Sprintf("%dg", bytes>>30)
+	}
+	return fmt.Sprintf("%dmi", (bytes+(1<<20)-1)>>20)
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+
+	"regexp"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/operator/options"
+)
+
+var _ = Describe("instanceTypeNameFromMachineDeployment function", func() {
+	var capacity corev1.ResourceList
+
+	BeforeEach(func() {
+		capacity = corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("4"),
+			corev1.ResourceMemory: resource.MustParse("16Gi"),
+		}
+	})
+
+	It("returns the instance type label when it is present", func() {
+		labels := map[string]string{corev1.LabelInstanceTypeStable: "m5.xlarge"}
+		Expect(instanceTypeNameFromMachineDeployment(labels, capacity)).To(Equal("m5.xlarge"))
+	})
+
+	It("returns a name with the cpu and memory when the instance type label is not present", func() {
+		Expect(instanceTypeNameFromMachineDeployment(map[string]string{}, capacity)).To(Equal("c4-m16g"))
+	})
+
+	It("adds the number of accelerators to the name", func() {
+		capacity[corev1.ResourceName("nvidia.com/gpu")] = resource.MustParse("2")
+		Expect(instanceTypeNameFromMachineDeployment(map[string]string{}, capacity)).To(Equal("c4-m16g-g2"))
+	})
+
+	It("adds the number of MIG partitions to the name separately from the GPUs", func() {
+		capacity[corev1.ResourceName("nvidia.com/mig-1g.5gb")] = resource.MustParse("7")
+		Expect(instanceTypeNameFromMachineDeployment(map[string]string{}, capacity)).To(Equal("c4-m16g-mig7"))
+	})
+
+	It("returns the same name when the other labels or capacity differ", func() {
+		name := instanceTypeNameFromMachineDeployment(map[string]string{}, capacity)
+		Expect(instanceTypeNameFromMachineDeployment(map[string]string{corev1.LabelTopologyZone: "zone-a", "disktype": "ssd"}, capacity)).To(Equal(name))
+		capacity[corev1.ResourceEphemeralStorage] = resource.MustParse("100Gi")
+		Expect(instanceTypeNameFromMachineDeployment(map[string]string{}, capacity)).To(Equal(name))
+	})
+})
+
+var _ = Describe("memoryNameFromQuantity function", func() {
+	It("returns gibibytes for a whole number of gibibytes", func() {
+		Expect(memoryNameFromQuantity(resource.NewQuantity(16<<30, resource.BinarySI))).To(Equal("16g"))
+	})
+
+	It("returns mebibytes rounded up otherwise", func() {
+		Expect(memoryNameFromQuantity(resource.NewQuantity(1536<<20, resource.BinarySI))).To(Equal("1536mi"))
+		Expect(memoryNameFromQuantity(resource.NewQuantity(16777220<<10, resource.BinarySI))).To(Equal("16385mi"))
+	})
+})
+
+var _ = Describe("instanceTypeLabels function", func() {
+	var capacity corev1.ResourceList
+	var pattern *regexp.Regexp
+
+	BeforeEach(func() {
+		capacity = corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("4"),
+			corev1.ResourceMemory: resource.MustParse("16Gi"),
+		}
+		pattern = regexp.MustCompile(options.DefaultInstanceTypeNamePattern)
+	})
+
+	It("returns the cpu, memory, family and size labels", func() {
+		Expect(instanceTypeLabels("m5.xlarge", capacity, pattern)).To(Equal(map[string]string{
+			InstanceCPULabelKey:    "4",
+			InstanceMemoryLabelKey: "16384",
+			InstanceFamilyLabelKey: "m5",
+			InstanceSizeLabelKey:   "xlarge",
+		}))
+	})
+
+	It("rounds the cpu up to whole cores", func() {
+		capacity[corev1.ResourceCPU] = resource.MustParse("1500m")
+		Expect(instanceTypeLabels("m5.xlarge", capacity, pattern)).To(HaveKeyWithValue(InstanceCPULabelKey, "2"))
+	})
+
+	It("omits the family and size labels when the name does not match the pattern", func() {
+		labels := instanceTypeLabels("c4-m16g", capacity, pattern)
+		Expect(labels).To(HaveLen(2))
+		Expect(labels).ToNot(HaveKey(InstanceFamilyLabelKey))
+		Expect(labels).ToNot(HaveKey(InstanceSizeLabelKey))
+	})
+
+	It("omits the family and size labels when there is no pattern", func() {
+		Expect(instanceTypeLabels("m5.xlarge", capacity, nil)).To(HaveLen(2))
+	})
+
+	It("uses only the groups which are present in the pattern", func() {
+		pattern = regexp.MustCompile(`^Standard_(?P<family>[A-Z]+)[0-9]+`)
+		labels := instanceTypeLabels("Standard_D4s_v3", capacity, pattern)
+		Expect(labels).To(HaveKeyWithValue(InstanceFamilyLabelKey, "D"))
+		Expect(labels).ToNot(HaveKey(InstanceSizeLabelKey))
+	})
+
+	It("omits the cpu and memory labels when the capacity does not have them", func() {
+		Expect(instanceTypeLabels("m5.xlarge", corev1.ResourceList{}, pattern)).To(Equal(map[string]string{
+			InstanceFamilyLabelKey: "m5",
+			InstanceSizeLabelKey:   "xlarge",
+		}))
+	})
+})
+
+var _ = Describe("applyInstanceTypeLabels function", func() {
+	It("adds the instance type labels to the requirements without changing labels from the MachineDeployment", func() {
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		machineDeployment.SetAnnotations(map[string]string{
+			cpuKey:    "4",
+			memoryKey: "16Gi",
+			labelsKey: corev1.LabelInstanceTypeStable + "=m5.xlarge," + InstanceSizeLabelKey + "=big",
+		})
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
+		applyInstanceTypeLabels(instanceType, regexp.MustCompile(options.DefaultInstanceTypeNamePattern))
+
+		Expect(instanceType.Requirements.Get(InstanceCPULabelKey).Values()).To(ConsistOf("4"))
+		Expect(instanceType.Requirements.Get(InstanceMemoryLabelKey).Values()).To(ConsistOf("16384"))
+		Expect(instanceType.Requirements.Get(InstanceFamilyLabelKey).Values()).To(ConsistOf("m5"))
+		Expect(instanceType.Requirements.Get(InstanceSizeLabelKey).Values()).To(ConsistOf("big"))
+	})
+})
@@ -0,0 +1,134 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/apis/v1alpha1"
+)
+
+// defaultMaxPods is the kubelet default for the maximum number of pods on a Node.
+const defaultMaxPods = 110
+
+// evictionSignalResources maps the hard eviction signals to the resources they reduce.
+var evictionSignalResources = map[string]corev1.ResourceName{
+	"memory.available": corev1.ResourceMemory,
+	"nodefs.available": corev1.ResourceEphemeralStorage,
+}
+
+// applyKubeletConfiguration sets the overhead of the instance type from the kubelet settings of the NodeClass and the
+// annotations on the MachineDeployment, the annotations take precedence for each resource or signal. When the
+// MachineDeployment does not declare a maxPods capacity, the pods capacity is set from the NodeClass or the kubelet default.
+func applyKubeletConfiguration(instanceType *ClusterAPIInstanceType, machineDeployment *capiv1beta1.MachineDeployment, nodeClass *v1alpha1.ClusterAPINodeClass) {
+	kubelet := &v1alpha1.KubeletConfiguration{}
+	if nodeClass != nil && nodeClass.Spec.Kubelet != nil {
+		kubelet = nodeClass.Spec.Kubelet
+	}
+	annotations := machineDeployment.GetAnnotations()
+
+	if _, found := instanceType.Capacity[corev1.ResourcePods]; !found {
+		maxPods := int64(defaultMaxPods)
+		if kubelet.MaxPods != nil {
+			maxPods = int64(*kubelet.MaxPods)
+		}
+		if instanceType.Capacity == nil {
+			instanceType.Capacity = corev1.ResourceList{}
+		}
+		instanceType.Capacity[corev1.ResourcePods] = *resource.NewQuantity(maxPods, resource.DecimalSI)
+	}
+
+	evictionHard := mergeKubeletSettings(kubelet.EvictionHard, annotations[EvictionHardAnnotation])
+	instanceType.Overhead = &cloudprovider.InstanceTypeOverhead{
+		KubeReserved:      reservedResourceList(mergeKubeletSettings(kubelet.KubeReserved, annotations[KubeReservedAnnotation])),
+		SystemReserved:    reservedResourceList(mergeKubeletSettings(kubelet.SystemReserved, annotations[SystemReservedAnnotation])),
+		EvictionThreshold: evictionThresholdResourceList(evictionHard, instanceType.Capacity),
+	}
+}
+
+// mergeKubeletSettings returns the settings with the values from the annotation added, the annotation has the
+// format "key=value,key=value". Malformed entries in the annotation are ignored.
+func mergeKubeletSettings(settings map[string]string, annotation string) map[string]string {
+	merged := map[string]string{}
+	for k, v := range settings {
+		merged[k] = v
+	}
+
+	for _, entry := range strings.Split(annotation, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || key == "" {
+			continue
+		}
+		merged[key] = value
+	}
+
+	return merged
+}
+
+// reservedResourceList converts reserved resource settings to a resource list, values that are not valid
+// quantities are ignored.
+func reservedResourceList(settings map[string]string) corev1.ResourceList {
+	resources := corev1.ResourceList{}
+	for name, value := range settings {
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			continue
+		}
+		resources[corev1.ResourceName(name)] = quantity
+	}
+	return resources
+}
+
+// evictionThresholdResourceList converts hard eviction thresholds to a resource list. A threshold can be a
+// quantity or a percentage of the capacity of the resource for the signal, unknown signals and invalid
+// values are ignored.
+func evictionThresholdResourceList(evictionHard map[string]string, capacity corev1.ResourceList) corev1.ResourceList {
+	resources := corev1.ResourceList{}
+	for signal, value := range evictionHard {
+		name, found := evictionSignalResources[signal]
+		if !found {
+			continue
+		}
+
+		if percentage, isPercentage := strings.CutSuffix(value, "%"); isPercentage {
+			p, err := strconv.ParseFloat(percentage, 64)
+			if err != nil {
+				continue
+			}
+			available, found := capacity[name]
+			if !found {
+				continue
+			}
+			resources[name] = *resource.NewQuantity(int64(math.Ceil(float64(available.Value())*p/100)), resource.BinarySI)
+			continue
+		}
+
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			continue
+		}
+		resources[name] = quantity
+	}
+	return resources
+}
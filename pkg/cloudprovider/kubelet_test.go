@@ -0,0 +1,120 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/apis/v1alpha1"
+)
+
+var _ = Describe("applyKubeletConfiguration function", func() {
+	var machineDeployment *capiv1beta1.MachineDeployment
+	var nodeClass *v1alpha1.ClusterAPINodeClass
+
+	BeforeEach(func() {
+		machineDeployment = newMachineDeployment("md-1", "test-cluster", true)
+		machineDeployment.SetAnnotations(map[string]string{
+			cpuKey:          "4",
+			memoryKey:       "16Gi",
+			diskCapacityKey: "100Gi",
+		})
+		nodeClass = &v1alpha1.ClusterAPINodeClass{}
+		nodeClass.Spec.Kubelet = &v1alpha1.KubeletConfiguration{
+			KubeReserved:   map[string]string{"cpu": "100m", "memory": "1Gi"},
+			SystemReserved: map[string]string{"memory": "500Mi"},
+			EvictionHard:   map[string]string{"memory.available": "100Mi", "nodefs.available": "10%"},
+		}
+	})
+
+	It("sets an empty overhead and the default pods capacity without a NodeClass", func() {
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
+		applyKubeletConfiguration(instanceType, machineDeployment, nil)
+		Expect(instanceType.Overhead.Total()).To(BeEmpty())
+		Expect(instanceType.Capacity.Pods().Value()).To(Equal(int64(110)))
+	})
+
+	It("sets the pods capacity from the NodeClass", func() {
+		nodeClass.Spec.Kubelet.MaxPods = ptr.To(int32(58))
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
+		applyKubeletConfiguration(instanceType, machineDeployment, nodeClass)
+		Expect(instanceType.Capacity.Pods().Value()).To(Equal(int64(58)))
+	})
+
+	It("does not change the pods capacity from the maxPods annotation", func() {
+		nodeClass.Spec.Kubelet.MaxPods = ptr.To(int32(58))
+		machineDeployment.GetAnnotations()[maxPodsKey] = "20"
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
+		applyKubeletConfiguration(instanceType, machineDeployment, nodeClass)
+		Expect(instanceType.Capacity.Pods().Value()).To(Equal(int64(20)))
+	})
+
+	It("sets the overhead from the NodeClass", func() {
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
+		applyKubeletConfiguration(instanceType, machineDeployment, nodeClass)
+		Expect(instanceType.Overhead.KubeReserved).To(HaveKeyWithValue(corev1.ResourceCPU, resource.MustParse("100m")))
+		Expect(instanceType.Overhead.KubeReserved).To(HaveKeyWithValue(corev1.ResourceMemory, resource.MustParse("1Gi")))
+		Expect(instanceType.Overhead.SystemReserved).To(HaveKeyWithValue(corev1.ResourceMemory, resource.MustParse("500Mi")))
+		Expect(instanceType.Overhead.EvictionThreshold.Memory().String()).To(Equal("100Mi"))
+		Expect(instanceType.Overhead.EvictionThreshold.StorageEphemeral().String()).To(Equal("10Gi"))
+
+		allocatable := instanceType.Allocatable()
+		Expect(allocatable.Cpu().String()).To(Equal("3900m"))
+		Expect(allocatable.Memory().String()).To(Equal("14760Mi"))
+	})
+
+	It("overrides the NodeClass settings with the MachineDeployment annotations", func() {
+		machineDeployment.GetAnnotations()[KubeReservedAnnotation] = "cpu=200m"
+		machineDeployment.GetAnnotations()[EvictionHardAnnotation] = "memory.available=5%"
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
+		applyKubeletConfiguration(instanceType, machineDeployment, nodeClass)
+		Expect(instanceType.Overhead.KubeReserved).To(HaveKeyWithValue(corev1.ResourceCPU, resource.MustParse("200m")))
+		Expect(instanceType.Overhead.KubeReserved).To(HaveKeyWithValue(corev1.ResourceMemory, resource.MustParse("1Gi")))
+		// 5% of 16Gi, rounded up to the next byte.
+		Expect(instanceType.Overhead.EvictionThreshold.Memory().Value()).To(Equal(int64(858993460)))
+	})
+
+	It("ignores invalid values and unknown eviction signals", func() {
+		machineDeployment.GetAnnotations()[SystemReservedAnnotation] = "memory=lots,cpu"
+		machineDeployment.GetAnnotations()[EvictionHardAnnotation] = "imagefs.available=15%"
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
+		applyKubeletConfiguration(instanceType, machineDeployment, nodeClass)
+		Expect(instanceType.Overhead.SystemReserved).To(BeEmpty())
+		Expect(instanceType.Overhead.EvictionThreshold).ToNot(HaveKey(corev1.ResourceName("imagefs.available")))
+	})
+})
+
+var _ = Describe("createNodeClaimFromMachineDeployment kubelet behavior", func() {
+	It("sets the allocatable resources from the kubelet settings", func() {
+		machineDeployment := newZonalMachineDeployment("md-1", "m5.xlarge", "zone-a")
+		nodeClass := &v1alpha1.ClusterAPINodeClass{}
+		nodeClass.Spec.Kubelet = &v1alpha1.KubeletConfiguration{
+			KubeReserved: map[string]string{"cpu": "500m"},
+		}
+		nodeClaim := createNodeClaimFromMachineDeployment(context.Background(), machineDeployment, nil, nodeClass, nil)
+		Expect(nodeClaim.Status.Capacity.Cpu().String()).To(Equal("4"))
+		Expect(nodeClaim.Status.Allocatable.Cpu().String()).To(Equal("3500m"))
+		Expect(nodeClaim.Status.Allocatable.Pods().Value()).To(Equal(int64(110)))
+	})
+})
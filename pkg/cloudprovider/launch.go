@@ -0,0 +1,89 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+)
+
+const (
+	// MachineFailedReason is the reason of the NodeClaim Launched condition when its Machine has failed.
+	MachineFailedReason = "MachineFailed"
+	// InfrastructureNotReadyReason is the reason of the NodeClaim Launched condition when the infrastructure of its
+	// Machine reports an error.
+	InfrastructureNotReadyReason = "InfrastructureNotReady"
+	// BootstrapNotReadyReason is the reason of the NodeClaim Launched condition when the bootstrap of its Machine
+	// reports an error.
+	BootstrapNotReadyReason = "BootstrapNotReady"
+)
+
+// machineConditionReasons maps the Machine conditions which are checked while a NodeClaim is launched to the reason
+// reported on the NodeClaim.
+var machineConditionReasons = []struct {
+	conditionType capiv1beta1.ConditionType
+	reason        string
+	description   string
+}{
+	{conditionType: capiv1beta1.InfrastructureReadyCondition, reason: InfrastructureNotReadyReason, description: "infrastructure"},
+	{conditionType: capiv1beta1.BootstrapReadyCondition, reason: BootstrapNotReadyReason, description: "bootstrap"},
+}
+
+// MachineFailedError returns the create error for a Machine which has failed, the message contains the failure
+// reason and message of the Machine when they are set.
+func MachineFailedError(machine *capiv1beta1.Machine) *cloudprovider.CreateError {
+	message := fmt.Sprintf("Machine %q has failed", machine.Name)
+	details := []string{}
+	if reason := ptr.Deref(machine.Status.FailureReason, ""); reason != "" {
+		details = append(details, string(reason))
+	}
+	if failureMessage := ptr.Deref(machine.Status.FailureMessage, ""); failureMessage != "" {
+		details = append(details, failureMessage)
+	}
+	if len(details) > 0 {
+		message = fmt.Sprintf("%s, %s", message, strings.Join(details, ": "))
+	}
+	return cloudprovider.NewCreateError(errors.New(message), MachineFailedReason, message)
+}
+
+// machineConditionError returns a create error when the InfrastructureReady or BootstrapReady condition of the
+// Machine is false with the error severity, and nil otherwise. Cluster API may still recover from these errors,
+// so the Machine is not treated as failed.
+func machineConditionError(machine *capiv1beta1.Machine) error {
+	for _, c := range machineConditionReasons {
+		for _, condition := range machine.Status.Conditions {
+			if condition.Type != c.conditionType || condition.Status != corev1.ConditionFalse || condition.Severity != capiv1beta1.ConditionSeverityError {
+				continue
+			}
+			message := fmt.Sprintf("%s of Machine %q is not ready", c.description, machine.Name)
+			if condition.Reason != "" {
+				message = fmt.Sprintf("%s, %s", message, condition.Reason)
+			}
+			if condition.Message != "" {
+				message = fmt.Sprintf("%s: %s", message, condition.Message)
+			}
+			return cloudprovider.NewCreateError(errors.New(message), c.reason, message)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+)
+
+var _ = Describe("MachineFailedError function", func() {
+	It("returns the failure reason and message of the Machine", func() {
+		machine := newMachine("m-1", "test-cluster", true)
+		machine.Status.FailureReason = ptr.To(capierrors.CreateMachineError)
+		machine.Status.FailureMessage = ptr.To("quota exceeded")
+
+		err := MachineFailedError(machine)
+		Expect(err.ConditionReason).To(Equal(MachineFailedReason))
+		Expect(err.ConditionMessage).To(Equal(`Machine "m-1" has failed, CreateError: quota exceeded`))
+	})
+
+	It("returns a message without details when the Machine has no failure reason or message", func() {
+		machine := newMachine("m-1", "test-cluster", true)
+		machine.Status.SetTypedPhase(capiv1beta1.MachinePhaseFailed)
+
+		err := MachineFailedError(machine)
+		Expect(err.ConditionReason).To(Equal(MachineFailedReason))
+		Expect(err.ConditionMessage).To(Equal(`Machine "m-1" has failed`))
+	})
+})
+
+var _ = Describe("machineConditionError function", func() {
+	var machine *capiv1beta1.Machine
+
+	BeforeEach(func() {
+		machine = newMachine("m-1", "test-cluster", true)
+	})
+
+	It("returns nil when the Machine has no conditions", func() {
+		Expect(machineConditionError(machine)).To(BeNil())
+	})
+
+	It("returns nil when the conditions are false with a warning severity", func() {
+		machine.Status.Conditions = capiv1beta1.Conditions{
+			{Type: capiv1beta1.InfrastructureReadyCondition, Status: corev1.ConditionFalse, Severity: capiv1beta1.ConditionSeverityWarning, Reason: "WaitingForInstance"},
+			{Type: capiv1beta1.BootstrapReadyCondition, Status: corev1.ConditionFalse, Severity: capiv1beta1.ConditionSeverityInfo, Reason: "WaitingForControlPlane"},
+		}
+		Expect(machineConditionError(machine)).To(BeNil())
+	})
+
+	It("returns a create error when the InfrastructureReady condition is false with an error severity", func() {
+		machine.Status.Conditions = capiv1beta1.Conditions{
+			{Type: capiv1beta1.InfrastructureReadyCondition, Status: corev1.ConditionFalse, Severity: capiv1beta1.ConditionSeverityError, Reason: "InstanceProvisionFailed", Message: "subnet is full"},
+		}
+		var createError *cloudprovider.CreateError
+		Expect(errors.As(machineConditionError(machine), &createError)).To(BeTrue())
+		Expect(createError.ConditionReason).To(Equal(InfrastructureNotReadyReason))
+		Expect(createError.ConditionMessage).To(Equal(`infrastructure of Machine "m-1" is not ready, InstanceProvisionFailed: subnet is full`))
+	})
+
+	It("returns a create error when the BootstrapReady condition is false with an error severity", func() {
+		machine.Status.Conditions = capiv1beta1.Conditions{
+			{Type: capiv1beta1.BootstrapReadyCondition, Status: corev1.ConditionFalse, Severity: capiv1beta1.ConditionSeverityError, Reason: "DataSecretGenerationFailed"},
+		}
+		var createError *cloudprovider.CreateError
+		Expect(errors.As(machineConditionError(machine), &createError)).To(BeTrue())
+		Expect(createError.ConditionReason).To(Equal(BootstrapNotReadyReason))
+		Expect(createError.ConditionMessage).To(Equal(`bootstrap of Machine "m-1" is not ready, DataSecretGenerationFailed`))
+	})
+})
@@ -0,0 +1,372 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machinetemplate"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+)
+
+// findInstanceTypesForMachineTemplates returns the instance types of a NodeClass in the ownerless provisioning mode.
+// Each selected InfrastructureMachineTemplate with the NodePoolMemberLabel becomes an instance type, with an
+// offering for each failure domain of the Cluster.
+func (c *CloudProvider) findInstanceTypesForMachineTemplates(ctx context.Context, nodeClass *v1alpha1.ClusterAPINodeClass) ([]*ClusterAPIInstanceType, error) {
+	instanceTypes := []*ClusterAPIInstanceType{}
+	spec := nodeClass.Spec.MachineTemplates
+
+	if c.machineTemplateProvider == nil {
+		return instanceTypes, fmt.Errorf("unable to find instance types for NodeClass %s, machine templates are not supported", nodeClass.Name)
+	}
+
+	zones, err := c.clusterFailureDomains(ctx, spec)
+	if err != nil {
+		return instanceTypes, fmt.Errorf("unable to find instance types for NodeClass %s: %w", nodeClass.Name, err)
+	}
+
+	infrastructureTemplates, err := c.machineTemplateProvider.List(ctx, spec.InfrastructureTemplates.APIVersion, spec.InfrastructureTemplates.Kind, spec.Namespace, spec.InfrastructureTemplates.Selector)
+	if err != nil {
+		return instanceTypes, fmt.Errorf("unable to list infrastructure templates for NodeClass %s: %w", nodeClass.Name, err)
+	}
+
+	bootstrapTemplates, err := c.machineTemplateProvider.List(ctx, spec.BootstrapTemplates.APIVersion, spec.BootstrapTemplates.Kind, spec.Namespace, spec.BootstrapTemplates.Selector)
+	if err != nil {
+		return instanceTypes, fmt.Errorf("unable to list bootstrap templates for NodeClass %s: %w", nodeClass.Name, err)
+	}
+
+	for _, template := range infrastructureTemplates {
+		if _, found := template.GetLabels()[providers.NodePoolMemberLabel]; !found {
+			continue
+		}
+
+		bootstrapRef, err := bootstrapTemplateRef(template, bootstrapTemplates)
+		if err != nil {
+			// a template without a bootstrap template cannot be used, the other templates are still usable.
+			log.FromContext(ctx).Error(err, "unable to use infrastructure template", "kind", template.GetKind(), "name", template.GetName())
+			continue
+		}
+
+		// a zone label on the template takes precedence over the failure domains of the Cluster.
+		templateZones := zones
+		if _, found := nodeLabelsFromMachineDeployment(machineDeploymentFromMachineTemplate(template, bootstrapRef, spec.ClusterName, spec.Version, ""))[corev1.LabelTopologyZone]; found {
+			templateZones = []string{""}
+		}
+
+		status := machinetemplate.StatusFromTemplate(template)
+		for _, zone := range templateZones {
+			machineDeployment := machineDeploymentFromMachineTemplate(template, bootstrapRef, spec.ClusterName, spec.Version, zone)
+			instanceTypes = append(instanceTypes, c.instanceTypeFromMachineDeployment(ctx, machineDeployment, status, nodeClass, template.GetAPIVersion(), template.GetKind()))
+		}
+	}
+
+	// the instance types of a template in different zones become a single instance type with an offering per zone.
+	instanceTypes = mergeInstanceTypes(instanceTypes)
+
+	return instanceTypes, nil
+}
+
+// clusterFailureDomains returns the sorted names of the failure domains of the Cluster, or a single empty zone
+// when the Cluster does not have failure domains.
+func (c *CloudProvider) clusterFailureDomains(ctx context.Context, spec *v1alpha1.MachineTemplatesSpec) ([]string, error) {
+	cluster := &capiv1beta1.Cluster{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: spec.ClusterName, Namespace: spec.Namespace}, cluster); err != nil {
+		return nil, fmt.Errorf("unable to get Cluster %s in namespace %s: %w", spec.ClusterName, spec.Namespace, err)
+	}
+
+	zones := slices.Sorted(maps.Keys(cluster.Status.FailureDomains))
+	if len(zones) == 0 {
+		return []string{""}, nil
+	}
+
+	return zones, nil
+}
+
+// createMachineFromTemplate creates a Machine for the NodeClaim in the ownerless provisioning mode. The infrastructure
+// machine and bootstrap config are cloned from the templates of the offering, and the Machine is created with the zone
+// of the offering and the labels of the NodeClaim. The objects are named after the NodeClaim, so that a retried
+// create reuses them. The NodeClaim is annotated with the Machine, and Create resumes from the Machine until it has
+// a provider ID, or returns the NodeClaim without a provider ID in the asynchronous launch mode.
+func (c *CloudProvider) createMachineFromTemplate(ctx context.Context, nodeClaim *karpv1.NodeClaim, nodeClass *v1alpha1.ClusterAPINodeClass, offering *MachineDeploymentOffering) (*karpv1.NodeClaim, error) {
+	spec := nodeClass.Spec.MachineTemplates
+
+	infrastructureTemplate, err := c.machineTemplateProvider.Get(ctx, &corev1.ObjectReference{
+		APIVersion: offering.APIVersion,
+		Kind:       offering.Kind,
+		Name:       offering.MachineDeploymentName,
+	}, offering.MachineDeploymentNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("cannot satisfy create, unable to get infrastructure template: %w", err)
+	}
+
+	bootstrapTemplates, err := c.machineTemplateProvider.List(ctx, spec.BootstrapTemplates.APIVersion, spec.BootstrapTemplates.Kind, spec.Namespace, spec.BootstrapTemplates.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("cannot satisfy create, unable to list bootstrap templates: %w", err)
+	}
+	bootstrapRef, err := bootstrapTemplateRef(infrastructureTemplate, bootstrapTemplates)
+	if err != nil {
+		return nil, fmt.Errorf("cannot satisfy create, %w", err)
+	}
+	bootstrapTemplate, err := c.machineTemplateProvider.Get(ctx, bootstrapRef, spec.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("cannot satisfy create, unable to get bootstrap template: %w", err)
+	}
+
+	clonedLabels := map[string]string{capiv1beta1.ClusterNameLabel: spec.ClusterName}
+	infrastructureRef, err := c.machineTemplateProvider.Clone(ctx, infrastructureTemplate, nodeClaim.Name, clonedLabels)
+	if err != nil {
+		return nil, fmt.Errorf("cannot satisfy create, %w", err)
+	}
+	bootstrapConfigRef, err := c.machineTemplateProvider.Clone(ctx, bootstrapTemplate, nodeClaim.Name, clonedLabels)
+	if err != nil {
+		c.deleteClonedObjects(ctx, infrastructureRef)
+		return nil, fmt.Errorf("cannot satisfy create, %w", err)
+	}
+
+	machine := machineForNodeClaim(nodeClaim, spec, infrastructureTemplate.GetName(), offering.Offering.Zone(), infrastructureRef, bootstrapConfigRef)
+	if err := c.machineProvider.Create(ctx, machine); err != nil && !apierrors.IsAlreadyExists(err) {
+		c.deleteClonedObjects(ctx, infrastructureRef, bootstrapConfigRef)
+		return nil, fmt.Errorf("cannot satisfy create, %w", err)
+	}
+
+	// annotate the NodeClaim with a merge-patch, in the same way as the create batcher, so that Create resumes
+	// from the Machine instead of creating another one.
+	machineRef := fmt.Sprintf("%s/%s", machine.Namespace, machine.Name)
+	patchBytes := []byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`, providers.MachineAnnotation, machineRef))
+	nc := &karpv1.NodeClaim{}
+	nc.Name = nodeClaim.Name
+	if err := c.kubeClient.Patch(ctx, nc, client.RawPatch(types.MergePatchType, patchBytes)); err != nil {
+		return nil, fmt.Errorf("cannot satisfy create, unable to annotate NodeClaim %q with Machine %q: %w", nodeClaim.Name, machineRef, err)
+	}
+
+	if !c.asyncLaunch {
+		return nil, fmt.Errorf("cannot satisfy create, waiting for Machine %q to have ProviderID", machine.Name)
+	}
+
+	// in the asynchronous launch mode the provider ID is filled in by the launch controller when the Machine has one.
+	machineDeployment := machineDeploymentFromMachineTemplate(infrastructureTemplate, bootstrapRef, spec.ClusterName, spec.Version, offering.Offering.Zone())
+	return createNodeClaimFromMachineDeployment(ctx, machineDeployment, machinetemplate.StatusFromTemplate(infrastructureTemplate), nodeClass, c.instanceTypeNamePattern), nil
+}
+
+// deleteClonedObjects removes the objects cloned for a Machine which could not be created, failures are only logged
+// because the create is retried with the same names.
+func (c *CloudProvider) deleteClonedObjects(ctx context.Context, refs ...*corev1.ObjectReference) {
+	for _, ref := range refs {
+		if err := c.machineTemplateProvider.Delete(ctx, ref, ref.Namespace); err != nil {
+			log.FromContext(ctx).Error(err, "unable to delete cloned object", "kind", ref.Kind, "name", ref.Name)
+		}
+	}
+}
+
+// isOwnerlessMachineDrifted compares an ownerless Machine with the current InfrastructureMachineTemplate it was created
+// from, and the bootstrap template and version of the NodeClass.
+func (c *CloudProvider) isOwnerlessMachineDrifted(ctx context.Context, nodeClaim *karpv1.NodeClaim, nodeClass *v1alpha1.ClusterAPINodeClass, machine *capiv1beta1.Machine) (cloudprovider.DriftReason, error) {
+	spec := nodeClass.Spec.MachineTemplates
+	if spec == nil {
+		// the NodeClass no longer uses the ownerless provisioning mode.
+		return NodeClassSelectorDrift, nil
+	}
+
+	templateRef := machineTemplateRefForMachine(machine)
+	if templateRef.APIVersion != spec.InfrastructureTemplates.APIVersion || templateRef.Kind != spec.InfrastructureTemplates.Kind {
+		return NodeClassSelectorDrift, nil
+	}
+
+	if c.machineTemplateProvider == nil {
+		return "", nil
+	}
+
+	template, err := c.machineTemplateProvider.Get(ctx, templateRef, machine.Namespace)
+	if apierrors.IsNotFound(err) {
+		return NodeClassSelectorDrift, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to determine drift for NodeClaim %q: %w", nodeClaim.Name, err)
+	}
+
+	bootstrapTemplates, err := c.machineTemplateProvider.List(ctx, spec.BootstrapTemplates.APIVersion, spec.BootstrapTemplates.Kind, spec.Namespace, spec.BootstrapTemplates.Selector)
+	if err != nil {
+		return "", fmt.Errorf("unable to determine drift for NodeClaim %q: %w", nodeClaim.Name, err)
+	}
+	bootstrapRef, err := bootstrapTemplateRef(template, bootstrapTemplates)
+	if err != nil {
+		return "", fmt.Errorf("unable to determine drift for NodeClaim %q: %w", nodeClaim.Name, err)
+	}
+
+	machineDeployment := machineDeploymentFromMachineTemplate(template, bootstrapRef, spec.ClusterName, spec.Version, ptr.Deref(machine.Spec.FailureDomain, ""))
+	return c.isDrifted(ctx, nodeClaim, nodeClass, machine, machineDeployment)
+}
+
+// machineDeploymentFromOwnerlessMachine returns the MachineDeployment view of the InfrastructureMachineTemplate that
+// an ownerless Machine was created from, it is used to read the capacity, labels and taints of the Machine.
+func (c *CloudProvider) machineDeploymentFromOwnerlessMachine(ctx context.Context, machine *capiv1beta1.Machine) (*capiv1beta1.MachineDeployment, error) {
+	if c.machineTemplateProvider == nil {
+		return nil, fmt.Errorf("unable to get infrastructure template for Machine %q, machine templates are not supported", machine.Name)
+	}
+
+	template, err := c.machineTemplateProvider.Get(ctx, machineTemplateRefForMachine(machine), machine.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get infrastructure template for Machine %q: %w", machine.Name, err)
+	}
+
+	return machineDeploymentFromMachineTemplate(template, machine.Spec.Bootstrap.ConfigRef, machine.Spec.ClusterName, machine.Spec.Version, ptr.Deref(machine.Spec.FailureDomain, "")), nil
+}
+
+// bootstrapTemplateRef returns the reference to the bootstrap template for an InfrastructureMachineTemplate. This is
+// the template named by the BootstrapTemplateAnnotation, or the only bootstrap template when there is exactly one.
+func bootstrapTemplateRef(infrastructureTemplate *unstructured.Unstructured, bootstrapTemplates []*unstructured.Unstructured) (*corev1.ObjectReference, error) {
+	var bootstrapTemplate *unstructured.Unstructured
+
+	if name, found := infrastructureTemplate.GetAnnotations()[BootstrapTemplateAnnotation]; found {
+		for _, t := range bootstrapTemplates {
+			if t.GetName() == name {
+				bootstrapTemplate = t
+				break
+			}
+		}
+		if bootstrapTemplate == nil {
+			return nil, fmt.Errorf("bootstrap template %q of %s %q is not selected by the NodeClass", name, infrastructureTemplate.GetKind(), infrastructureTemplate.GetName())
+		}
+	} else {
+		if len(bootstrapTemplates) != 1 {
+			return nil, fmt.Errorf("%s %q does not have the %s annotation, and the NodeClass selects %d bootstrap templates", infrastructureTemplate.GetKind(), infrastructureTemplate.GetName(), BootstrapTemplateAnnotation, len(bootstrapTemplates))
+		}
+		bootstrapTemplate = bootstrapTemplates[0]
+	}
+
+	return &corev1.ObjectReference{
+		APIVersion: bootstrapTemplate.GetAPIVersion(),
+		Kind:       bootstrapTemplate.GetKind(),
+		Name:       bootstrapTemplate.GetName(),
+		Namespace:  bootstrapTemplate.GetNamespace(),
+	}, nil
+}
+
+// machineDeploymentFromMachineTemplate returns an InfrastructureMachineTemplate as a MachineDeployment, so that the
+// capacity, labels, taints and offerings of its Machines are read in the same way as for the scalable resources.
+// The Machine template references the infrastructure and bootstrap templates, and uses the zone as its failure domain.
+func machineDeploymentFromMachineTemplate(template *unstructured.Unstructured, bootstrapRef *corev1.ObjectReference, clusterName string, version *string, zone string) *capiv1beta1.MachineDeployment {
+	machineDeployment := &capiv1beta1.MachineDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        template.GetName(),
+			Namespace:   template.GetNamespace(),
+			Labels:      template.GetLabels(),
+			Annotations: template.GetAnnotations(),
+		},
+		Spec: capiv1beta1.MachineDeploymentSpec{
+			ClusterName: clusterName,
+			Template: capiv1beta1.MachineTemplateSpec{
+				Spec: capiv1beta1.MachineSpec{
+					ClusterName: clusterName,
+					Version:     version,
+					Bootstrap: capiv1beta1.Bootstrap{
+						ConfigRef: bootstrapRef,
+					},
+					InfrastructureRef: corev1.ObjectReference{
+						APIVersion: template.GetAPIVersion(),
+						Kind:       template.GetKind(),
+						Name:       template.GetName(),
+						Namespace:  template.GetNamespace(),
+					},
+				},
+			},
+		},
+	}
+
+	if zone != "" {
+		machineDeployment.Spec.Template.Spec.FailureDomain = ptr.To(zone)
+	}
+
+	return machineDeployment
+}
+
+// machineForNodeClaim returns the ownerless Machine for a NodeClaim. The Machine has the labels of the NodeClaim,
+// the NodePoolMemberLabel, and the MachineTemplateLabel that identifies its InfrastructureMachineTemplate. It is
+// annotated with the NodeClaim, NodePool and NodeClass names in the same way as a Machine bound by the create batcher.
+func machineForNodeClaim(nodeClaim *karpv1.NodeClaim, spec *v1alpha1.MachineTemplatesSpec, templateName string, zone string, infrastructureRef *corev1.ObjectReference, bootstrapConfigRef *corev1.ObjectReference) *capiv1beta1.Machine {
+	labels := maps.Clone(nodeClaim.Labels)
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[capiv1beta1.ClusterNameLabel] = spec.ClusterName
+	labels[providers.NodePoolMemberLabel] = ""
+	labels[providers.MachineTemplateLabel] = templateName
+
+	nodeClassName := ""
+	if nodeClaim.Spec.NodeClassRef != nil {
+		nodeClassName = nodeClaim.Spec.NodeClassRef.Name
+	}
+
+	machine := &capiv1beta1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        nodeClaim.Name,
+			Namespace:   spec.Namespace,
+			Labels:      labels,
+			Annotations: providers.BindingAnnotations(nodeClaim.Name, nodeClaim.Labels[karpv1.NodePoolLabelKey], nodeClassName),
+		},
+		Spec: capiv1beta1.MachineSpec{
+			ClusterName: spec.ClusterName,
+			Version:     spec.Version,
+			Bootstrap: capiv1beta1.Bootstrap{
+				ConfigRef: bootstrapConfigRef,
+			},
+			InfrastructureRef: *infrastructureRef,
+		},
+	}
+
+	if zone != "" {
+		machine.Spec.FailureDomain = ptr.To(zone)
+	}
+
+	return machine
+}
+
+// isOwnerlessMachine returns true if Karpenter created the Machine from an InfrastructureMachineTemplate.
+func isOwnerlessMachine(machine *capiv1beta1.Machine) bool {
+	if machine == nil {
+		return false
+	}
+	_, found := machine.GetLabels()[providers.MachineTemplateLabel]
+	return found
+}
+
+// machineTemplateRefForMachine returns the reference to the InfrastructureMachineTemplate of an ownerless Machine.
+// Cloned infrastructure machines have the kind of their template without the "Template" suffix.
+func machineTemplateRefForMachine(machine *capiv1beta1.Machine) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		APIVersion: machine.Spec.InfrastructureRef.APIVersion,
+		Kind:       machine.Spec.InfrastructureRef.Kind + "Template",
+		Name:       machine.GetLabels()[providers.MachineTemplateLabel],
+		Namespace:  machine.Namespace,
+	}
+}
@@ -0,0 +1,306 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machine"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machinedeployment"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/scalableresource"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+)
+
+const (
+	testInfrastructureAPIVersion = "infrastructure.cluster.x-k8s.io/v1beta1"
+	testBootstrapAPIVersion      = "bootstrap.cluster.x-k8s.io/v1beta1"
+)
+
+func newTestTemplate(apiVersion, kind, name string, labels map[string]string, annotations map[string]string) *unstructured.Unstructured {
+	template := &unstructured.Unstructured{}
+	template.SetAPIVersion(apiVersion)
+	template.SetKind(kind)
+	template.SetName(name)
+	template.SetNamespace(testNamespace)
+	template.SetLabels(labels)
+	template.SetAnnotations(annotations)
+	return template
+}
+
+var _ = Describe("CloudProvider with machine templates", func() {
+	var provider *CloudProvider
+	var templateProvider *fakeTemplateProvider
+	var nodeClass *v1alpha1.ClusterAPINodeClass
+
+	BeforeEach(func() {
+		templateProvider = &fakeTemplateProvider{objects: map[string]*unstructured.Unstructured{}}
+		templateProvider.objects["small"] = newTestTemplate(testInfrastructureAPIVersion, "TestMachineTemplate", "small",
+			map[string]string{providers.NodePoolMemberLabel: ""},
+			map[string]string{cpuKey: "2", memoryKey: "8Gi", labelsKey: corev1.LabelInstanceTypeStable + "=small"})
+		templateProvider.objects["not-a-member"] = newTestTemplate(testInfrastructureAPIVersion, "TestMachineTemplate", "not-a-member", nil, nil)
+		templateProvider.objects["workers"] = newTestTemplate(testBootstrapAPIVersion, "TestConfigTemplate", "workers", nil, nil)
+
+		machineProvider := machine.NewDefaultProvider(context.Background(), cl)
+		machineDeploymentProvider := machinedeployment.NewDefaultProvider(context.Background(), cl)
+		provider = NewCloudProvider(context.Background(), cl, machineProvider, scalableresource.NewDefaultProvider(context.Background(), cl, machineProvider, machineDeploymentProvider, nil), templateProvider)
+
+		cluster := &capiv1beta1.Cluster{}
+		cluster.Name = "test-cluster"
+		cluster.Namespace = testNamespace
+		Expect(cl.Create(context.Background(), cluster)).To(Succeed())
+		cluster.Status.FailureDomains = capiv1beta1.FailureDomains{
+			"zone-b": capiv1beta1.FailureDomainSpec{},
+			"zone-a": capiv1beta1.FailureDomainSpec{},
+		}
+		Expect(cl.Status().Update(context.Background(), cluster)).To(Succeed())
+
+		nodeClass = &v1alpha1.ClusterAPINodeClass{}
+		nodeClass.Name = "default"
+		nodeClass.Spec.MachineTemplates = &v1alpha1.MachineTemplatesSpec{
+			ClusterName: "test-cluster",
+			Namespace:   testNamespace,
+			Version:     ptr.To("v1.33.0"),
+			InfrastructureTemplates: v1alpha1.TemplateSelector{
+				APIVersion: testInfrastructureAPIVersion,
+				Kind:       "TestMachineTemplate",
+			},
+			BootstrapTemplates: v1alpha1.TemplateSelector{
+				APIVersion: testBootstrapAPIVersion,
+				Kind:       "TestConfigTemplate",
+			},
+		}
+		Expect(cl.Create(context.Background(), nodeClass)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		eventuallyDeleteAllOf(cl, &capiv1beta1.Machine{}, &capiv1beta1.MachineList{})
+		eventuallyDeleteAllOf(cl, &capiv1beta1.Cluster{}, &capiv1beta1.ClusterList{})
+		eventuallyDeleteAllOf(cl, &karpv1.NodeClaim{}, &karpv1.NodeClaimList{})
+		eventuallyDeleteAllOf(cl, &v1alpha1.ClusterAPINodeClass{}, &v1alpha1.ClusterAPINodeClassList{})
+	})
+
+	// createNodeClaim creates a NodeClaim for the NodeClass, and returns it with the offering of the template in zone-a.
+	createNodeClaim := func() (*karpv1.NodeClaim, *MachineDeploymentOffering) {
+		GinkgoHelper()
+		nodeClaim := &karpv1.NodeClaim{}
+		nodeClaim.Name = "nodeclaim-1"
+		nodeClaim.Labels = map[string]string{karpv1.NodePoolLabelKey: "default"}
+		nodeClaim.Spec.NodeClassRef = &karpv1.NodeClassReference{Name: nodeClass.Name}
+		nodeClaim.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{}
+		Expect(cl.Create(context.Background(), nodeClaim)).To(Succeed())
+
+		instanceTypes, err := provider.findInstanceTypesForNodeClass(context.Background(), nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).To(HaveLen(1))
+		for i := range instanceTypes[0].MachineDeploymentOfferings {
+			if instanceTypes[0].MachineDeploymentOfferings[i].Offering.Zone() == "zone-a" {
+				return nodeClaim, &instanceTypes[0].MachineDeploymentOfferings[i]
+			}
+		}
+		Fail("no offering in zone-a")
+		return nil, nil
+	}
+
+	It("returns an instance type with an offering for each failure domain of the Cluster", func() {
+		instanceTypes, err := provider.findInstanceTypesForNodeClass(context.Background(), nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).To(HaveLen(1))
+		Expect(instanceTypes[0].Name).To(Equal("small"))
+		Expect(instanceTypes[0].Capacity.Cpu().String()).To(Equal("2"))
+
+		zones := []string{}
+		for _, offering := range instanceTypes[0].MachineDeploymentOfferings {
+			Expect(offering.Kind).To(Equal("TestMachineTemplate"))
+			Expect(offering.APIVersion).To(Equal(testInfrastructureAPIVersion))
+			Expect(offering.MachineDeploymentName).To(Equal("small"))
+			zones = append(zones, offering.Offering.Zone())
+		}
+		Expect(zones).To(ConsistOf("zone-a", "zone-b"))
+	})
+
+	It("skips a template when the bootstrap template is ambiguous", func() {
+		templateProvider.objects["other-workers"] = newTestTemplate(testBootstrapAPIVersion, "TestConfigTemplate", "other-workers", nil, nil)
+
+		instanceTypes, err := provider.findInstanceTypesForNodeClass(context.Background(), nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).To(BeEmpty())
+
+		annotations := templateProvider.objects["small"].GetAnnotations()
+		annotations[BootstrapTemplateAnnotation] = "other-workers"
+		templateProvider.objects["small"].SetAnnotations(annotations)
+		instanceTypes, err = provider.findInstanceTypesForNodeClass(context.Background(), nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).To(HaveLen(1))
+	})
+
+	It("returns an error when the Cluster is not found", func() {
+		nodeClass.Spec.MachineTemplates.ClusterName = "does-not-exist"
+		_, err := provider.findInstanceTypesForNodeClass(context.Background(), nodeClass)
+		Expect(err).To(MatchError(ContainSubstring("unable to get Cluster does-not-exist")))
+	})
+
+	It("creates an ownerless Machine for the NodeClaim and resumes from it", func() {
+		nodeClaim, offering := createNodeClaim()
+
+		createdNodeClaim, err := provider.createMachineFromTemplate(context.Background(), nodeClaim, nodeClass, offering)
+		Expect(err).To(MatchError(ContainSubstring("waiting for Machine \"nodeclaim-1\" to have ProviderID")))
+		Expect(createdNodeClaim).To(BeNil())
+
+		m := &capiv1beta1.Machine{}
+		Expect(cl.Get(context.Background(), client.ObjectKey{Name: nodeClaim.Name, Namespace: testNamespace}, m)).To(Succeed())
+		Expect(m.OwnerReferences).To(BeEmpty())
+		Expect(m.Labels).To(HaveKeyWithValue(providers.MachineTemplateLabel, "small"))
+		Expect(m.Labels).To(HaveKeyWithValue(karpv1.NodePoolLabelKey, "default"))
+		Expect(m.Labels).To(HaveKey(providers.NodePoolMemberLabel))
+		Expect(m.Annotations).To(HaveKeyWithValue(providers.NodeClaimAnnotation, nodeClaim.Name))
+		Expect(m.Annotations).To(HaveKeyWithValue(providers.NodePoolAnnotation, "default"))
+		Expect(m.Annotations).To(HaveKeyWithValue(providers.NodeClassAnnotation, nodeClass.Name))
+		Expect(m.Spec.FailureDomain).To(Equal(ptr.To("zone-a")))
+		Expect(m.Spec.Version).To(Equal(ptr.To("v1.33.0")))
+		Expect(m.Spec.InfrastructureRef.Kind).To(Equal("TestMachine"))
+		Expect(m.Spec.Bootstrap.ConfigRef.Kind).To(Equal("TestConfig"))
+
+		Expect(cl.Get(context.Background(), client.ObjectKeyFromObject(nodeClaim), nodeClaim)).To(Succeed())
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(providers.MachineAnnotation, testNamespace+"/"+nodeClaim.Name))
+
+		m.Spec.ProviderID = ptr.To("clusterapi://nodeclaim-1")
+		Expect(cl.Update(context.Background(), m)).To(Succeed())
+
+		createdNodeClaim, err = provider.Create(context.Background(), nodeClaim)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(createdNodeClaim.Status.ProviderID).To(Equal("clusterapi://nodeclaim-1"))
+		Expect(createdNodeClaim.Status.Capacity.Cpu().String()).To(Equal("2"))
+		Expect(createdNodeClaim.Labels).To(HaveKeyWithValue(corev1.LabelTopologyZone, "zone-a"))
+	})
+
+	It("deletes an ownerless Machine", func() {
+		nodeClaim, offering := createNodeClaim()
+		_, err := provider.createMachineFromTemplate(context.Background(), nodeClaim, nodeClass, offering)
+		Expect(err).To(HaveOccurred())
+
+		m := &capiv1beta1.Machine{}
+		Expect(cl.Get(context.Background(), client.ObjectKey{Name: nodeClaim.Name, Namespace: testNamespace}, m)).To(Succeed())
+		m.Spec.ProviderID = ptr.To("clusterapi://nodeclaim-1")
+		Expect(cl.Update(context.Background(), m)).To(Succeed())
+
+		nodeClaim.Status.ProviderID = "clusterapi://nodeclaim-1"
+		Expect(provider.Delete(context.Background(), nodeClaim)).To(Succeed())
+
+		Eventually(func() bool {
+			err := cl.Get(context.Background(), client.ObjectKeyFromObject(m), m)
+			return err != nil || !m.DeletionTimestamp.IsZero()
+		}).Should(BeTrue())
+	})
+
+	Context("IsDrifted", func() {
+		var nodeClaim *karpv1.NodeClaim
+
+		BeforeEach(func() {
+			var offering *MachineDeploymentOffering
+			nodeClaim, offering = createNodeClaim()
+			_, err := provider.createMachineFromTemplate(context.Background(), nodeClaim, nodeClass, offering)
+			Expect(err).To(HaveOccurred())
+
+			m := &capiv1beta1.Machine{}
+			Expect(cl.Get(context.Background(), client.ObjectKey{Name: nodeClaim.Name, Namespace: testNamespace}, m)).To(Succeed())
+			m.Spec.ProviderID = ptr.To("clusterapi://nodeclaim-1")
+			Expect(cl.Update(context.Background(), m)).To(Succeed())
+
+			Expect(cl.Get(context.Background(), client.ObjectKeyFromObject(nodeClaim), nodeClaim)).To(Succeed())
+			createdNodeClaim, err := provider.Create(context.Background(), nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			nodeClaim.Labels = createdNodeClaim.Labels
+			nodeClaim.Status.ProviderID = createdNodeClaim.Status.ProviderID
+		})
+
+		It("returns no drift when the Machine matches its template", func() {
+			reason, err := provider.IsDrifted(context.Background(), nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reason).To(BeEmpty())
+		})
+
+		It("returns VersionDrift when the NodeClass version changes", func() {
+			nodeClass.Spec.MachineTemplates.Version = ptr.To("v1.34.0")
+			Expect(cl.Update(context.Background(), nodeClass)).To(Succeed())
+
+			reason, err := provider.IsDrifted(context.Background(), nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reason).To(Equal(VersionDrift))
+		})
+
+		It("returns NodeClassSelectorDrift when the template is deleted", func() {
+			delete(templateProvider.objects, "small")
+
+			reason, err := provider.IsDrifted(context.Background(), nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reason).To(Equal(NodeClassSelectorDrift))
+		})
+
+		It("returns NodeClassSelectorDrift when the NodeClass selects scalable resources", func() {
+			nodeClass.Spec.MachineTemplates = nil
+			nodeClass.Spec.ScalableResourceSelector = &metav1.LabelSelector{}
+			Expect(cl.Update(context.Background(), nodeClass)).To(Succeed())
+
+			reason, err := provider.IsDrifted(context.Background(), nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reason).To(Equal(NodeClassSelectorDrift))
+		})
+	})
+})
+
+var _ = Describe("isOwnerlessMachine function", func() {
+	It("returns true only for Machines with the machine template label", func() {
+		m := newMachine("m-1", "test-cluster", true)
+		Expect(isOwnerlessMachine(m)).To(BeFalse())
+		m.GetLabels()[providers.MachineTemplateLabel] = "small"
+		Expect(isOwnerlessMachine(m)).To(BeTrue())
+		Expect(isOwnerlessMachine(nil)).To(BeFalse())
+	})
+})
+
+var _ = Describe("machineTemplateRefForMachine function", func() {
+	It("returns the template kind and name of the Machine", func() {
+		m := newMachine("m-1", "test-cluster", true)
+		m.GetLabels()[providers.MachineTemplateLabel] = "small"
+		m.Spec.InfrastructureRef = corev1.ObjectReference{APIVersion: testInfrastructureAPIVersion, Kind: "TestMachine", Name: "m-1"}
+
+		ref := machineTemplateRefForMachine(m)
+		Expect(ref.Kind).To(Equal("TestMachineTemplate"))
+		Expect(ref.Name).To(Equal("small"))
+		Expect(ref.Namespace).To(Equal(testNamespace))
+	})
+})
+
+var _ = Describe("CloudProvider.findInstanceTypesForMachineTemplates errors", func() {
+	It("returns an error without a machine template provider", func() {
+		provider := &CloudProvider{}
+		nodeClass := &v1alpha1.ClusterAPINodeClass{}
+		nodeClass.Spec.MachineTemplates = &v1alpha1.MachineTemplatesSpec{}
+		_, err := provider.findInstanceTypesForMachineTemplates(context.Background(), nodeClass)
+		Expect(err).To(MatchError(ContainSubstring("machine templates are not supported")))
+	})
+})
@@ -0,0 +1,53 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machinetemplate"
+)
+
+// nodeInfoLabelsFromMachineDeployment returns the kubernetes.io/arch and kubernetes.io/os labels for Nodes created
+// from the MachineDeployment. They are read from status.nodeInfo of the infrastructure machine template, and the
+// ArchitectureAnnotation and OperatingSystemAnnotation of the MachineDeployment are used when the template does not
+// have a value. Values which are not valid label values are ignored.
+func nodeInfoLabelsFromMachineDeployment(machineDeployment *capiv1beta1.MachineDeployment, templateStatus *machinetemplate.Status) map[string]string {
+	labels := map[string]string{}
+	annotations := machineDeployment.GetAnnotations()
+
+	nodeInfo := &machinetemplate.NodeInfo{}
+	if templateStatus != nil && templateStatus.NodeInfo != nil {
+		nodeInfo = templateStatus.NodeInfo
+	}
+
+	for key, values := range map[string][]string{
+		corev1.LabelArchStable: {nodeInfo.Architecture, annotations[ArchitectureAnnotation]},
+		corev1.LabelOSStable:   {nodeInfo.OperatingSystem, annotations[OperatingSystemAnnotation]},
+	} {
+		for _, value := range values {
+			if value != "" && len(validation.IsValidLabelValue(value)) == 0 {
+				labels[key] = value
+				break
+			}
+		}
+	}
+
+	return labels
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machinetemplate"
+)
+
+var _ = Describe("nodeInfoLabelsFromMachineDeployment function", func() {
+	var machineDeployment *capiv1beta1.MachineDeployment
+
+	BeforeEach(func() {
+		machineDeployment = newMachineDeployment("md-1", "test-cluster", true)
+	})
+
+	It("returns no labels when there is no node info or annotations", func() {
+		Expect(nodeInfoLabelsFromMachineDeployment(machineDeployment, nil)).To(BeEmpty())
+		Expect(nodeInfoLabelsFromMachineDeployment(machineDeployment, &machinetemplate.Status{})).To(BeEmpty())
+	})
+
+	It("returns the labels from the template node info", func() {
+		templateStatus := &machinetemplate.Status{
+			NodeInfo: &machinetemplate.NodeInfo{Architecture: "arm64", OperatingSystem: "linux"},
+		}
+
+		labels := nodeInfoLabelsFromMachineDeployment(machineDeployment, templateStatus)
+		Expect(labels).To(HaveLen(2))
+		Expect(labels).To(HaveKeyWithValue(corev1.LabelArchStable, "arm64"))
+		Expect(labels).To(HaveKeyWithValue(corev1.LabelOSStable, "linux"))
+	})
+
+	It("returns the labels from the annotations when the template has no node info", func() {
+		machineDeployment.SetAnnotations(map[string]string{
+			ArchitectureAnnotation:    "amd64",
+			OperatingSystemAnnotation: "windows",
+		})
+
+		labels := nodeInfoLabelsFromMachineDeployment(machineDeployment, &machinetemplate.Status{})
+		Expect(labels).To(HaveKeyWithValue(corev1.LabelArchStable, "amd64"))
+		Expect(labels).To(HaveKeyWithValue(corev1.LabelOSStable, "windows"))
+	})
+
+	It("prefers the template node info over the annotations", func() {
+		machineDeployment.SetAnnotations(map[string]string{
+			ArchitectureAnnotation:    "amd64",
+			OperatingSystemAnnotation: "windows",
+		})
+		templateStatus := &machinetemplate.Status{
+			NodeInfo: &machinetemplate.NodeInfo{Architecture: "arm64"},
+		}
+
+		labels := nodeInfoLabelsFromMachineDeployment(machineDeployment, templateStatus)
+		Expect(labels).To(HaveKeyWithValue(corev1.LabelArchStable, "arm64"))
+		Expect(labels).To(HaveKeyWithValue(corev1.LabelOSStable, "windows"))
+	})
+
+	It("ignores values which are not valid label values", func() {
+		machineDeployment.SetAnnotations(map[string]string{
+			ArchitectureAnnotation: "not a label value",
+		})
+
+		Expect(nodeInfoLabelsFromMachineDeployment(machineDeployment, nil)).To(BeEmpty())
+	})
+})
+
+var _ = Describe("machineDeploymentToInstanceType function with node info", func() {
+	It("adds the architecture and operating system requirements", func() {
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		templateStatus := &machinetemplate.Status{
+			NodeInfo: &machinetemplate.NodeInfo{Architecture: "arm64", OperatingSystem: "linux"},
+		}
+
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, templateStatus)
+		Expect(instanceType.Requirements.Get(corev1.LabelArchStable).Values()).To(ConsistOf("arm64"))
+		Expect(instanceType.Requirements.Get(corev1.LabelOSStable).Values()).To(ConsistOf("linux"))
+	})
+
+	It("prefers the MachineDeployment labels over the node info", func() {
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		machineDeployment.SetAnnotations(map[string]string{labelsKey: "kubernetes.io/arch=amd64"})
+		templateStatus := &machinetemplate.Status{
+			NodeInfo: &machinetemplate.NodeInfo{Architecture: "arm64"},
+		}
+
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, templateStatus)
+		Expect(instanceType.Requirements.Get(corev1.LabelArchStable).Values()).To(ConsistOf("amd64"))
+	})
+})
@@ -0,0 +1,251 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+)
+
+// MachineDeploymentOffering associates an offering of an instance type with the MachineDeployment
+// that will be scaled to launch it.
+type MachineDeploymentOffering struct {
+	Offering *cloudprovider.Offering
+
+	// Kind and APIVersion are the type of the scalable resource, the MachineDeployment name and
+	// namespace identify a scalable resource of another kind. An empty Kind is a MachineDeployment.
+	// In the ownerless provisioning mode they identify the InfrastructureMachineTemplate of the offering.
+	Kind       string
+	APIVersion string
+
+	MachineDeploymentName      string
+	MachineDeploymentNamespace string
+}
+
+// zoneFromMachineDeployment returns the zone for Nodes created from the MachineDeployment. The zone
+// label from the MachineDeployment takes precedence over the failure domain of the Machine template.
+func zoneFromMachineDeployment(machineDeployment *capiv1beta1.MachineDeployment, labels map[string]string) string {
+	if zone := zoneLabelFromLabels(labels); zone != "" {
+		return zone
+	}
+
+	return ptr.Deref(machineDeployment.Spec.Template.Spec.FailureDomain, "")
+}
+
+// offeringRequirementKeys are the requirements which differ between the offerings of a merged instance type.
+var offeringRequirementKeys = []string{corev1.LabelTopologyZone, karpv1.CapacityTypeLabelKey}
+
+// capacityTypeFromMachineDeployment returns the capacity type for Nodes created from the MachineDeployment.
+// The capacity type label from the MachineDeployment takes precedence over the CapacityTypeAnnotation,
+// and when neither is present, or the value is unknown, the capacity type is on-demand.
+func capacityTypeFromMachineDeployment(machineDeployment *capiv1beta1.MachineDeployment, labels map[string]string) string {
+	capacityType, found := labels[karpv1.CapacityTypeLabelKey]
+	if !found {
+		capacityType = machineDeployment.GetAnnotations()[CapacityTypeAnnotation]
+	}
+
+	switch capacityType {
+	case karpv1.CapacityTypeSpot, karpv1.CapacityTypeReserved:
+		return capacityType
+	default:
+		return karpv1.CapacityTypeOnDemand
+	}
+}
+
+// reservationIDFromMachineDeployment returns the reservation ID for a MachineDeployment with reserved capacity.
+func reservationIDFromMachineDeployment(machineDeployment *capiv1beta1.MachineDeployment) string {
+	if id, found := machineDeployment.GetAnnotations()[ReservationIDAnnotation]; found && id != "" {
+		return id
+	}
+	return machineDeployment.Name
+}
+
+// reservationCapacityFromMachineDeployment returns the number of Machines which can still be created in the
+// reservation, this is the difference between the replicas and the maximum size of the MachineDeployment.
+// A MachineDeployment without a maximum size has no limit.
+func reservationCapacityFromMachineDeployment(machineDeployment *capiv1beta1.MachineDeployment) int {
+	maxSize, err := strconv.Atoi(machineDeployment.GetAnnotations()[capiv1beta1.AutoscalerMaxSizeAnnotation])
+	if err != nil {
+		return math.MaxInt32
+	}
+	return max(maxSize-int(ptr.Deref(machineDeployment.Spec.Replicas, 0)), 0)
+}
+
+// offeringForMachineDeployment returns the offering for Nodes created from the MachineDeployment in the zone
+// and with the capacity type. Reserved offerings also carry the reservation ID and the remaining capacity.
+func offeringForMachineDeployment(machineDeployment *capiv1beta1.MachineDeployment, zone string, capacityType string) *cloudprovider.Offering {
+	offering := &cloudprovider.Offering{
+		Requirements: scheduling.NewRequirements(
+			scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, zone),
+			scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, capacityType),
+		),
+		Price:     0.0,
+		Available: isBelowMaxSize(machineDeployment),
+	}
+
+	if capacityType == karpv1.CapacityTypeReserved {
+		offering.Requirements.Add(scheduling.NewRequirement(cloudprovider.ReservationIDLabel, corev1.NodeSelectorOpIn, reservationIDFromMachineDeployment(machineDeployment)))
+		offering.ReservationCapacity = reservationCapacityFromMachineDeployment(machineDeployment)
+	}
+
+	return offering
+}
+
+// markUnavailableOfferings sets the offerings of the instance type to unavailable when a recent launch from
+// their MachineDeployment failed, so that Karpenter chooses other offerings until the failure expires.
+func (c *CloudProvider) markUnavailableOfferings(instanceType *ClusterAPIInstanceType) {
+	for _, o := range instanceType.MachineDeploymentOfferings {
+		if c.unavailableOfferings.IsUnavailable(o.MachineDeploymentNamespace, o.MachineDeploymentName, o.Offering.Zone()) {
+			o.Offering.Available = false
+		}
+	}
+}
+
+// copyInstanceType returns a copy of the instance type with its own offerings, so that the availability of the
+// offerings can be set without changing the cached instance type.
+func copyInstanceType(instanceType *ClusterAPIInstanceType) *ClusterAPIInstanceType {
+	copied := &ClusterAPIInstanceType{
+		InstanceType: cloudprovider.InstanceType{
+			Name:         instanceType.Name,
+			Requirements: instanceType.Requirements,
+			Offerings:    make(cloudprovider.Offerings, 0, len(instanceType.Offerings)),
+			Capacity:     instanceType.Capacity,
+			Overhead:     instanceType.Overhead,
+		},
+		Taints: instanceType.Taints,
+		Weight: instanceType.Weight,
+	}
+	offerings := map[*cloudprovider.Offering]*cloudprovider.Offering{}
+	for _, o := range instanceType.Offerings {
+		offering := *o
+		offerings[o] = &offering
+		copied.Offerings = append(copied.Offerings, &offering)
+	}
+	copied.MachineDeploymentOfferings = lo.Map(instanceType.MachineDeploymentOfferings, func(o MachineDeploymentOffering, _ int) MachineDeploymentOffering {
+		if offering, found := offerings[o.Offering]; found {
+			o.Offering = offering
+		}
+		return o
+	})
+	return copied
+}
+
+// mergeInstanceTypes combines instance types that have the same name and shape, but come from different
+// MachineDeployments, into a single instance type with the offerings of all of them. This allows Karpenter
+// to see one instance type that is offered in several zones. Instance types without a name are not merged.
+func mergeInstanceTypes(instanceTypes []*ClusterAPIInstanceType) []*ClusterAPIInstanceType {
+	merged := []*ClusterAPIInstanceType{}
+	byKey := map[string]*ClusterAPIInstanceType{}
+
+	for _, instanceType := range instanceTypes {
+		if instanceType.Name == "" {
+			merged = append(merged, instanceType)
+			continue
+		}
+
+		key := instanceTypeShapeKey(instanceType)
+		existing, found := byKey[key]
+		if !found {
+			byKey[key] = instanceType
+			merged = append(merged, instanceType)
+			continue
+		}
+
+		for _, key := range offeringRequirementKeys {
+			if existing.Requirements.Has(key) && instanceType.Requirements.Has(key) {
+				existing.Requirements.Get(key).Insert(instanceType.Requirements.Get(key).Values()...)
+			}
+		}
+		existing.Offerings = append(existing.Offerings, instanceType.Offerings...)
+		existing.MachineDeploymentOfferings = append(existing.MachineDeploymentOfferings, instanceType.MachineDeploymentOfferings...)
+		existing.Weight = max(existing.Weight, instanceType.Weight)
+	}
+
+	return merged
+}
+
+// instanceTypeShapeKey returns a string that is equal for instance types which only differ in their zone
+// or capacity type.
+func instanceTypeShapeKey(instanceType *ClusterAPIInstanceType) string {
+	parts := []string{instanceType.Name}
+
+	keys := instanceType.Requirements.Keys().UnsortedList()
+	slices.Sort(keys)
+	for _, key := range keys {
+		if slices.Contains(offeringRequirementKeys, key) {
+			continue
+		}
+		parts = append(parts, instanceType.Requirements.Get(key).String())
+	}
+
+	for _, resources := range []corev1.ResourceList{instanceType.Capacity, instanceType.Allocatable()} {
+		names := lo.Keys(resources)
+		slices.Sort(names)
+		for _, name := range names {
+			quantity := resources[name]
+			parts = append(parts, fmt.Sprintf("%s=%s", name, quantity.String()))
+		}
+	}
+
+	taints := lo.Map(instanceType.Taints, func(t corev1.Taint, _ int) string { return t.ToString() })
+	slices.Sort(taints)
+	parts = append(parts, taints...)
+
+	return strings.Join(parts, ";")
+}
+
+// machineDeploymentOfferingForNodeClaim returns the MachineDeployment offering of the instance type that should
+// be used to launch the NodeClaim. This is the cheapest available offering that is compatible with the NodeClaim
+// requirements, with ties broken by the name of the MachineDeployment.
+func machineDeploymentOfferingForNodeClaim(instanceType *ClusterAPIInstanceType, nodeClaim *karpv1.NodeClaim) *MachineDeploymentOffering {
+	reqs := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	compatible := lo.Filter(instanceType.MachineDeploymentOfferings, func(o MachineDeploymentOffering, _ int) bool {
+		return o.Offering.Available && reqs.IsCompatible(o.Offering.Requirements, scheduling.AllowUndefinedWellKnownLabels)
+	})
+	if len(compatible) == 0 {
+		return nil
+	}
+
+	slices.SortFunc(compatible, func(a, b MachineDeploymentOffering) int {
+		if c := cmp.Compare(a.Offering.Price, b.Offering.Price); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.MachineDeploymentNamespace+"/"+a.MachineDeploymentName, b.MachineDeploymentNamespace+"/"+b.MachineDeploymentName)
+	})
+	return &compatible[0]
+}
+
+// setOfferingsKind records the kind of the scalable resource, or InfrastructureMachineTemplate, that launches
+// the offerings of the instance type.
+func setOfferingsKind(instanceType *ClusterAPIInstanceType, apiVersion string, kind string) {
+	for i := range instanceType.MachineDeploymentOfferings {
+		instanceType.MachineDeploymentOfferings[i].Kind = kind
+		instanceType.MachineDeploymentOfferings[i].APIVersion = apiVersion
+	}
+}
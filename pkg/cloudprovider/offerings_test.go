@@ -0,0 +1,296 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/cache"
+)
+
+// newZonalMachineDeployment returns a MachineDeployment with the same shape and instance type name for each zone.
+func newZonalMachineDeployment(name, instanceType, zone string) *capiv1beta1.MachineDeployment {
+	machineDeployment := newMachineDeployment(name, "test-cluster", true)
+	machineDeployment.SetAnnotations(map[string]string{
+		cpuKey:    "4",
+		memoryKey: "16Gi",
+		labelsKey: corev1.LabelInstanceTypeStable + "=" + instanceType,
+	})
+	machineDeployment.Spec.Template.Spec.FailureDomain = ptr.To(zone)
+	return machineDeployment
+}
+
+var _ = Describe("zoneFromMachineDeployment function", func() {
+	It("returns the zone label before the failure domain", func() {
+		machineDeployment := newZonalMachineDeployment("md-1", "m5.xlarge", "zone-a")
+		labels := map[string]string{corev1.LabelTopologyZone: "zone-b"}
+		Expect(zoneFromMachineDeployment(machineDeployment, labels)).To(Equal("zone-b"))
+	})
+
+	It("returns the failure domain when there is no zone label", func() {
+		machineDeployment := newZonalMachineDeployment("md-1", "m5.xlarge", "zone-a")
+		Expect(zoneFromMachineDeployment(machineDeployment, map[string]string{})).To(Equal("zone-a"))
+	})
+
+	It("returns an empty string when there is no zone", func() {
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		Expect(zoneFromMachineDeployment(machineDeployment, map[string]string{})).To(BeEmpty())
+	})
+})
+
+var _ = Describe("machineDeploymentToInstanceType zone behavior", func() {
+	It("adds the failure domain to the requirements and the offering", func() {
+		instanceType := machineDeploymentToInstanceType(context.Background(), newZonalMachineDeployment("md-1", "m5.xlarge", "zone-a"), nil)
+		Expect(instanceType.Requirements.Get(corev1.LabelTopologyZone).Values()).To(ConsistOf("zone-a"))
+		Expect(instanceType.Offerings).To(HaveLen(1))
+		Expect(instanceType.Offerings[0].Zone()).To(Equal("zone-a"))
+	})
+})
+
+var _ = Describe("mergeInstanceTypes function", func() {
+	It("merges equivalent instance types from different zones", func() {
+		instanceTypes := mergeInstanceTypes([]*ClusterAPIInstanceType{
+			machineDeploymentToInstanceType(context.Background(), newZonalMachineDeployment("md-a", "m5.xlarge", "zone-a"), nil),
+			machineDeploymentToInstanceType(context.Background(), newZonalMachineDeployment("md-b", "m5.xlarge", "zone-b"), nil),
+		})
+		Expect(instanceTypes).To(HaveLen(1))
+		Expect(instanceTypes[0].Offerings).To(HaveLen(2))
+		Expect(instanceTypes[0].MachineDeploymentOfferings).To(HaveLen(2))
+		Expect(instanceTypes[0].Requirements.Get(corev1.LabelTopologyZone).Values()).To(ConsistOf("zone-a", "zone-b"))
+	})
+
+	It("does not merge instance types with a different shape", func() {
+		larger := newZonalMachineDeployment("md-b", "m5.xlarge", "zone-b")
+		larger.GetAnnotations()[cpuKey] = "8"
+		instanceTypes := mergeInstanceTypes([]*ClusterAPIInstanceType{
+			machineDeploymentToInstanceType(context.Background(), newZonalMachineDeployment("md-a", "m5.xlarge", "zone-a"), nil),
+			machineDeploymentToInstanceType(context.Background(), larger, nil),
+		})
+		Expect(instanceTypes).To(HaveLen(2))
+	})
+
+	It("does not merge instance types with a different name", func() {
+		instanceTypes := mergeInstanceTypes([]*ClusterAPIInstanceType{
+			machineDeploymentToInstanceType(context.Background(), newZonalMachineDeployment("md-a", "m5.xlarge", "zone-a"), nil),
+			machineDeploymentToInstanceType(context.Background(), newZonalMachineDeployment("md-b", "m5a.xlarge", "zone-b"), nil),
+		})
+		Expect(instanceTypes).To(HaveLen(2))
+	})
+
+	It("merges instance types with a synthesized name when they have the same shape", func() {
+		first := newZonalMachineDeployment("md-a", "", "zone-a")
+		delete(first.GetAnnotations(), labelsKey)
+		second := newZonalMachineDeployment("md-b", "", "zone-b")
+		delete(second.GetAnnotations(), labelsKey)
+		instanceTypes := mergeInstanceTypes([]*ClusterAPIInstanceType{
+			machineDeploymentToInstanceType(context.Background(), first, nil),
+			machineDeploymentToInstanceType(context.Background(), second, nil),
+		})
+		Expect(instanceTypes).To(HaveLen(1))
+		Expect(instanceTypes[0].Name).To(Equal("c4-m16g"))
+		Expect(instanceTypes[0].MachineDeploymentOfferings).To(HaveLen(2))
+	})
+
+	It("does not merge instance types with a synthesized name when they have a different shape", func() {
+		first := newZonalMachineDeployment("md-a", "", "zone-a")
+		delete(first.GetAnnotations(), labelsKey)
+		second := newZonalMachineDeployment("md-b", "", "zone-b")
+		second.GetAnnotations()[labelsKey] = "disktype=ssd"
+		instanceTypes := mergeInstanceTypes([]*ClusterAPIInstanceType{
+			machineDeploymentToInstanceType(context.Background(), first, nil),
+			machineDeploymentToInstanceType(context.Background(), second, nil),
+		})
+		Expect(instanceTypes).To(HaveLen(2))
+		Expect(instanceTypes[0].Name).ToNot(Equal(instanceTypes[1].Name))
+	})
+})
+
+var _ = Describe("machineDeploymentOfferingForNodeClaim function", func() {
+	var instanceType *ClusterAPIInstanceType
+
+	BeforeEach(func() {
+		instanceTypes := mergeInstanceTypes([]*ClusterAPIInstanceType{
+			machineDeploymentToInstanceType(context.Background(), newZonalMachineDeployment("md-a", "m5.xlarge", "zone-a"), nil),
+			machineDeploymentToInstanceType(context.Background(), newZonalMachineDeployment("md-b", "m5.xlarge", "zone-b"), nil),
+		})
+		Expect(instanceTypes).To(HaveLen(1))
+		instanceType = instanceTypes[0]
+	})
+
+	It("returns the MachineDeployment for the zone in the NodeClaim requirements", func() {
+		nodeClaim := &karpv1.NodeClaim{}
+		nodeClaim.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
+			{
+				NodeSelectorRequirement: corev1.NodeSelectorRequirement{
+					Key:      corev1.LabelTopologyZone,
+					Operator: corev1.NodeSelectorOpIn,
+					Values:   []string{"zone-b"},
+				},
+			},
+		}
+		offering := machineDeploymentOfferingForNodeClaim(instanceType, nodeClaim)
+		Expect(offering).ToNot(BeNil())
+		Expect(offering.MachineDeploymentName).To(Equal("md-b"))
+	})
+
+	It("returns the cheapest MachineDeployment when the NodeClaim allows any zone", func() {
+		setOfferingPrices(instanceType, 1.0)
+		instanceType.MachineDeploymentOfferings[1].Offering.Price = 0.5
+		offering := machineDeploymentOfferingForNodeClaim(instanceType, &karpv1.NodeClaim{})
+		Expect(offering).ToNot(BeNil())
+		Expect(offering.MachineDeploymentName).To(Equal("md-b"))
+	})
+
+	It("returns nil when the offering for the zone is not available", func() {
+		instanceType.MachineDeploymentOfferings[1].Offering.Available = false
+		nodeClaim := &karpv1.NodeClaim{}
+		nodeClaim.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
+			{
+				NodeSelectorRequirement: corev1.NodeSelectorRequirement{
+					Key:      corev1.LabelTopologyZone,
+					Operator: corev1.NodeSelectorOpIn,
+					Values:   []string{"zone-b"},
+				},
+			},
+		}
+		Expect(machineDeploymentOfferingForNodeClaim(instanceType, nodeClaim)).To(BeNil())
+	})
+})
+
+var _ = Describe("capacityTypeFromMachineDeployment function", func() {
+	It("returns on-demand when no capacity type is declared", func() {
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		Expect(capacityTypeFromMachineDeployment(machineDeployment, map[string]string{})).To(Equal(karpv1.CapacityTypeOnDemand))
+	})
+
+	It("returns the capacity type from the annotation", func() {
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		machineDeployment.SetAnnotations(map[string]string{CapacityTypeAnnotation: karpv1.CapacityTypeSpot})
+		Expect(capacityTypeFromMachineDeployment(machineDeployment, map[string]string{})).To(Equal(karpv1.CapacityTypeSpot))
+	})
+
+	It("returns the capacity type from the labels before the annotation", func() {
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		machineDeployment.SetAnnotations(map[string]string{CapacityTypeAnnotation: karpv1.CapacityTypeSpot})
+		labels := map[string]string{karpv1.CapacityTypeLabelKey: karpv1.CapacityTypeReserved}
+		Expect(capacityTypeFromMachineDeployment(machineDeployment, labels)).To(Equal(karpv1.CapacityTypeReserved))
+	})
+
+	It("returns on-demand for an unknown capacity type", func() {
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		machineDeployment.SetAnnotations(map[string]string{CapacityTypeAnnotation: "preemptible"})
+		Expect(capacityTypeFromMachineDeployment(machineDeployment, map[string]string{})).To(Equal(karpv1.CapacityTypeOnDemand))
+	})
+})
+
+var _ = Describe("machineDeploymentToInstanceType capacity type behavior", func() {
+	It("creates a spot offering", func() {
+		machineDeployment := newZonalMachineDeployment("md-1", "m5.xlarge", "zone-a")
+		machineDeployment.GetAnnotations()[CapacityTypeAnnotation] = karpv1.CapacityTypeSpot
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
+		Expect(instanceType.Offerings).To(HaveLen(1))
+		Expect(instanceType.Offerings[0].CapacityType()).To(Equal(karpv1.CapacityTypeSpot))
+	})
+
+	It("creates a reserved offering with a reservation ID and capacity", func() {
+		machineDeployment := newZonalMachineDeployment("md-1", "m5.xlarge", "zone-a")
+		machineDeployment.GetAnnotations()[CapacityTypeAnnotation] = karpv1.CapacityTypeReserved
+		machineDeployment.GetAnnotations()[ReservationIDAnnotation] = "cr-1234"
+		machineDeployment.GetAnnotations()[capiv1beta1.AutoscalerMaxSizeAnnotation] = "5"
+		machineDeployment.Spec.Replicas = ptr.To(int32(2))
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
+		Expect(instanceType.Offerings).To(HaveLen(1))
+		Expect(instanceType.Offerings[0].CapacityType()).To(Equal(karpv1.CapacityTypeReserved))
+		Expect(instanceType.Offerings[0].ReservationID()).To(Equal("cr-1234"))
+		Expect(instanceType.Offerings[0].ReservationCapacity).To(Equal(3))
+	})
+
+	It("merges instance types with different capacity types", func() {
+		spot := newZonalMachineDeployment("md-spot", "m5.xlarge", "zone-a")
+		spot.GetAnnotations()[CapacityTypeAnnotation] = karpv1.CapacityTypeSpot
+		instanceTypes := mergeInstanceTypes([]*ClusterAPIInstanceType{
+			machineDeploymentToInstanceType(context.Background(), newZonalMachineDeployment("md-on-demand", "m5.xlarge", "zone-a"), nil),
+			machineDeploymentToInstanceType(context.Background(), spot, nil),
+		})
+		Expect(instanceTypes).To(HaveLen(1))
+		Expect(instanceTypes[0].Offerings).To(HaveLen(2))
+	})
+})
+
+var _ = Describe("createNodeClaimFromMachineDeployment capacity type behavior", func() {
+	It("sets the capacity type label", func() {
+		machineDeployment := newZonalMachineDeployment("md-1", "m5.xlarge", "zone-a")
+		machineDeployment.GetAnnotations()[CapacityTypeAnnotation] = karpv1.CapacityTypeSpot
+		nodeClaim := createNodeClaimFromMachineDeployment(context.Background(), machineDeployment, nil, nil, nil)
+		Expect(nodeClaim.Labels).To(HaveKeyWithValue(karpv1.CapacityTypeLabelKey, karpv1.CapacityTypeSpot))
+		Expect(nodeClaim.Labels).To(HaveKeyWithValue(corev1.LabelTopologyZone, "zone-a"))
+	})
+
+	It("sets the reservation ID label for reserved capacity", func() {
+		machineDeployment := newZonalMachineDeployment("md-1", "m5.xlarge", "zone-a")
+		machineDeployment.GetAnnotations()[CapacityTypeAnnotation] = karpv1.CapacityTypeReserved
+		nodeClaim := createNodeClaimFromMachineDeployment(context.Background(), machineDeployment, nil, nil, nil)
+		Expect(nodeClaim.Labels).To(HaveKeyWithValue(karpv1.CapacityTypeLabelKey, karpv1.CapacityTypeReserved))
+		Expect(nodeClaim.Labels).To(HaveKeyWithValue(v1alpha1.LabelReservationID, "md-1"))
+	})
+})
+
+var _ = Describe("copyInstanceType function", func() {
+	It("copies the offerings and keeps the MachineDeployment offerings pointing to the copies", func() {
+		instanceTypes := mergeInstanceTypes([]*ClusterAPIInstanceType{
+			machineDeploymentToInstanceType(context.Background(), newZonalMachineDeployment("md-a", "m5.xlarge", "zone-a"), nil),
+			machineDeploymentToInstanceType(context.Background(), newZonalMachineDeployment("md-b", "m5.xlarge", "zone-b"), nil),
+		})
+		Expect(instanceTypes).To(HaveLen(1))
+
+		copied := copyInstanceType(instanceTypes[0])
+		Expect(copied.Offerings).To(HaveLen(2))
+		Expect(copied.MachineDeploymentOfferings).To(HaveLen(2))
+		copied.MachineDeploymentOfferings[1].Offering.Available = false
+
+		Expect(copied.Offerings[1].Available).To(BeFalse())
+		Expect(instanceTypes[0].Offerings[1].Available).To(BeTrue())
+		Expect(instanceTypes[0].MachineDeploymentOfferings[1].Offering.Available).To(BeTrue())
+	})
+})
+
+var _ = Describe("markUnavailableOfferings method", func() {
+	It("marks the offerings of MachineDeployments with a recent failure as unavailable", func() {
+		provider := &CloudProvider{unavailableOfferings: cache.NewUnavailableOfferings()}
+		provider.unavailableOfferings.MarkUnavailable(context.Background(), "test", testNamespace, "md-b", "zone-b")
+		instanceTypes := mergeInstanceTypes([]*ClusterAPIInstanceType{
+			machineDeploymentToInstanceType(context.Background(), newZonalMachineDeployment("md-a", "m5.xlarge", "zone-a"), nil),
+			machineDeploymentToInstanceType(context.Background(), newZonalMachineDeployment("md-b", "m5.xlarge", "zone-b"), nil),
+		})
+		Expect(instanceTypes).To(HaveLen(1))
+		provider.markUnavailableOfferings(instanceTypes[0])
+		Expect(instanceTypes[0].MachineDeploymentOfferings[0].Offering.Available).To(BeTrue())
+		Expect(instanceTypes[0].MachineDeploymentOfferings[1].Offering.Available).To(BeFalse())
+
+		offering := machineDeploymentOfferingForNodeClaim(instanceTypes[0], &karpv1.NodeClaim{})
+		Expect(offering).ToNot(BeNil())
+		Expect(offering.MachineDeploymentName).To(Equal("md-a"))
+	})
+})
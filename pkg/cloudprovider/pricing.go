@@ -0,0 +1,107 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/apis/v1alpha1"
+)
+
+// gibibyte is the unit of memory used with the memory weight of a synthetic price.
+var gibibyte = resource.MustParse("1Gi")
+
+// priceForInstanceType returns the price for the instance type created from the MachineDeployment.
+// The sources of the price, in order of precedence, are:
+//  1. the PriceAnnotation on the MachineDeployment
+//  2. the instance type name in the price table of the NodeClass
+//  3. a synthetic price from the CPU and memory capacity, using the weights of the NodeClass
+//
+// If no source is found the price is zero.
+func priceForInstanceType(machineDeployment *capiv1beta1.MachineDeployment, instanceType *ClusterAPIInstanceType, nodeClass *v1alpha1.ClusterAPINodeClass) (float64, error) {
+	if value, found := machineDeployment.GetAnnotations()[PriceAnnotation]; found {
+		price, err := parsePrice(value)
+		if err != nil {
+			return 0.0, fmt.Errorf("unable to parse annotation %q on MachineDeployment %q: %w", PriceAnnotation, machineDeployment.Name, err)
+		}
+		return price, nil
+	}
+
+	if nodeClass == nil || nodeClass.Spec.Pricing == nil {
+		return 0.0, nil
+	}
+	pricing := nodeClass.Spec.Pricing
+
+	if value, found := pricing.InstanceTypePrices[instanceType.Name]; found && instanceType.Name != "" {
+		price, err := parsePrice(value)
+		if err != nil {
+			return 0.0, fmt.Errorf("unable to parse price of instance type %q in NodeClass %q: %w", instanceType.Name, nodeClass.Name, err)
+		}
+		return price, nil
+	}
+
+	if pricing.CPUWeight == nil && pricing.MemoryWeight == nil {
+		return 0.0, nil
+	}
+
+	cpuWeight, err := parsePrice(ptr.Deref(pricing.CPUWeight, "0"))
+	if err != nil {
+		return 0.0, fmt.Errorf("unable to parse cpuWeight in NodeClass %q: %w", nodeClass.Name, err)
+	}
+	memoryWeight, err := parsePrice(ptr.Deref(pricing.MemoryWeight, "0"))
+	if err != nil {
+		return 0.0, fmt.Errorf("unable to parse memoryWeight in NodeClass %q: %w", nodeClass.Name, err)
+	}
+
+	price := 0.0
+	if cpu, found := instanceType.Capacity[corev1.ResourceCPU]; found {
+		price += cpu.AsApproximateFloat64() * cpuWeight
+	}
+	if memory, found := instanceType.Capacity[corev1.ResourceMemory]; found {
+		price += memory.AsApproximateFloat64() / gibibyte.AsApproximateFloat64() * memoryWeight
+	}
+
+	return price, nil
+}
+
+// setOfferingPrices sets the price on all offerings of the instance type.
+func setOfferingPrices(instanceType *ClusterAPIInstanceType, price float64) {
+	for _, offering := range instanceType.Offerings {
+		offering.Price = price
+	}
+}
+
+func parsePrice(value string) (float64, error) {
+	price, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0.0, err
+	}
+	if math.IsNaN(price) || math.IsInf(price, 0) {
+		return 0.0, fmt.Errorf("price %q is not a finite number", value)
+	}
+	if price < 0 {
+		return 0.0, fmt.Errorf("price %q is negative", value)
+	}
+	return price, nil
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machinedeployment"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/scalableresource"
+)
+
+var _ = Describe("priceForInstanceType function", func() {
+	var machineDeployment *capiv1beta1.MachineDeployment
+	var nodeClass *v1alpha1.ClusterAPINodeClass
+
+	BeforeEach(func() {
+		machineDeployment = newMachineDeployment("md-1", "test-cluster", true)
+		machineDeployment.SetAnnotations(map[string]string{
+			cpuKey:    "4",
+			memoryKey: "16Gi",
+			labelsKey: corev1.LabelInstanceTypeStable + "=m5.xlarge",
+		})
+		nodeClass = &v1alpha1.ClusterAPINodeClass{}
+		nodeClass.Name = "default"
+	})
+
+	It("returns zero when there is no pricing information", func() {
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
+		Expect(priceForInstanceType(machineDeployment, instanceType, nodeClass)).To(BeZero())
+		Expect(priceForInstanceType(machineDeployment, instanceType, nil)).To(BeZero())
+	})
+
+	It("returns the price from the MachineDeployment annotation before the NodeClass", func() {
+		machineDeployment.GetAnnotations()[PriceAnnotation] = "0.5"
+		nodeClass.Spec.Pricing = &v1alpha1.PricingSpec{
+			InstanceTypePrices: map[string]string{"m5.xlarge": "0.192"},
+		}
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
+		Expect(priceForInstanceType(machineDeployment, instanceType, nodeClass)).To(Equal(0.5))
+	})
+
+	It("returns the price from the NodeClass price table before the weights", func() {
+		nodeClass.Spec.Pricing = &v1alpha1.PricingSpec{
+			InstanceTypePrices: map[string]string{"m5.xlarge": "0.192"},
+			CPUWeight:          ptr.To("1"),
+		}
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
+		Expect(priceForInstanceType(machineDeployment, instanceType, nodeClass)).To(Equal(0.192))
+	})
+
+	It("returns a synthetic price from the CPU and memory weights", func() {
+		nodeClass.Spec.Pricing = &v1alpha1.PricingSpec{
+			CPUWeight:    ptr.To("0.25"),
+			MemoryWeight: ptr.To("0.125"),
+		}
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
+		// 4 CPU * 0.25 + 16 GiB * 0.125
+		Expect(priceForInstanceType(machineDeployment, instanceType, nodeClass)).To(BeNumerically("~", 3.0, 0.0001))
+	})
+
+	It("returns an error when the price annotation is invalid", func() {
+		machineDeployment.GetAnnotations()[PriceAnnotation] = "cheap"
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
+		_, err := priceForInstanceType(machineDeployment, instanceType, nodeClass)
+		Expect(err).To(MatchError(ContainSubstring(PriceAnnotation)))
+	})
+
+	It("returns an error when the price annotation is negative", func() {
+		machineDeployment.GetAnnotations()[PriceAnnotation] = "-1"
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
+		_, err := priceForInstanceType(machineDeployment, instanceType, nodeClass)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("returns an error when the price annotation is not a number", func() {
+		machineDeployment.GetAnnotations()[PriceAnnotation] = "NaN"
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
+		_, err := priceForInstanceType(machineDeployment, instanceType, nodeClass)
+		Expect(err).To(MatchError(ContainSubstring("not a finite number")))
+	})
+
+	It("returns an error when the price annotation is infinite", func() {
+		machineDeployment.GetAnnotations()[PriceAnnotation] = "+Inf"
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
+		_, err := priceForInstanceType(machineDeployment, instanceType, nodeClass)
+		Expect(err).To(MatchError(ContainSubstring("not a finite number")))
+	})
+
+	It("returns an error when the NodeClass price of the instance type is infinite", func() {
+		nodeClass.Spec.Pricing = &v1alpha1.PricingSpec{
+			InstanceTypePrices: map[string]string{"m5.xlarge": "Inf"},
+		}
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
+		_, err := priceForInstanceType(machineDeployment, instanceType, nodeClass)
+		Expect(err).To(MatchError(ContainSubstring("not a finite number")))
+	})
+})
+
+var _ = Describe("CloudProvider.findInstanceTypesForNodeClass pricing", func() {
+	var provider *CloudProvider
+
+	BeforeEach(func() {
+		machineDeploymentProvider := machinedeployment.NewDefaultProvider(context.Background(), cl)
+		provider = NewCloudProvider(context.Background(), cl, nil, scalableresource.NewDefaultProvider(context.Background(), cl, nil, machineDeploymentProvider, nil), nil)
+	})
+
+	AfterEach(func() {
+		eventuallyDeleteAllOf(cl, &capiv1beta1.MachineDeployment{}, &capiv1beta1.MachineDeploymentList{})
+	})
+
+	It("sets the price on the offerings", func() {
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		machineDeployment.SetAnnotations(map[string]string{
+			cpuKey:          "4",
+			memoryKey:       "16Gi",
+			PriceAnnotation: "1.5",
+		})
+		Expect(cl.Create(context.Background(), machineDeployment)).To(Succeed())
+
+		nodeClass := &v1alpha1.ClusterAPINodeClass{}
+		instanceTypes, err := provider.findInstanceTypesForNodeClass(context.Background(), nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).To(HaveLen(1))
+		Expect(instanceTypes[0].Offerings).To(HaveLen(1))
+		Expect(instanceTypes[0].Offerings[0].Price).To(Equal(1.5))
+	})
+})
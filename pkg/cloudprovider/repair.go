@@ -0,0 +1,114 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/operator/options"
+)
+
+const (
+	// KubeletUnhealthyCondition is reported by the node-problem-detector health checker when the kubelet is unhealthy.
+	KubeletUnhealthyCondition corev1.NodeConditionType = "KubeletUnhealthy"
+	// ContainerRuntimeUnhealthyCondition is reported by the node-problem-detector health checker when the container
+	// runtime is unhealthy.
+	ContainerRuntimeUnhealthyCondition corev1.NodeConditionType = "ContainerRuntimeUnhealthy"
+)
+
+// DefaultRepairPolicies are the node conditions that will cause Karpenter to replace an unhealthy node
+// when the NodeRepair feature gate is enabled.
+var DefaultRepairPolicies = []cloudprovider.RepairPolicy{
+	// the kubelet has reported that the node is not ready.
+	{
+		ConditionType:      corev1.NodeReady,
+		ConditionStatus:    corev1.ConditionFalse,
+		TolerationDuration: 30 * time.Minute,
+	},
+	// the kubelet has stopped reporting the node status.
+	{
+		ConditionType:      corev1.NodeReady,
+		ConditionStatus:    corev1.ConditionUnknown,
+		TolerationDuration: 30 * time.Minute,
+	},
+	// the network for the node has not been configured correctly.
+	{
+		ConditionType:      corev1.NodeNetworkUnavailable,
+		ConditionStatus:    corev1.ConditionTrue,
+		TolerationDuration: 30 * time.Minute,
+	},
+	{
+		ConditionType:      KubeletUnhealthyCondition,
+		ConditionStatus:    corev1.ConditionTrue,
+		TolerationDuration: 10 * time.Minute,
+	},
+	{
+		ConditionType:      ContainerRuntimeUnhealthyCondition,
+		ConditionStatus:    corev1.ConditionTrue,
+		TolerationDuration: 10 * time.Minute,
+	},
+}
+
+// repairPoliciesFromOptions returns the repair policies configured through the controller options.
+// Policies from the options extend the default policies, and replace a default policy that has the
+// same condition type and status.
+func repairPoliciesFromOptions(ctx context.Context) []cloudprovider.RepairPolicy {
+	opts := options.FromContext(ctx)
+	if opts == nil {
+		return mergeRepairPolicies(DefaultRepairPolicies, nil)
+	}
+
+	overrides, err := options.ParseRepairPolicies(opts.RepairPolicies)
+	if err != nil {
+		// the options are validated when they are parsed, this should not happen.
+		log.FromContext(ctx).Error(err, "unable to parse repair policies, ignoring them")
+		overrides = nil
+	}
+
+	if opts.DisableDefaultRepairPolicies {
+		return mergeRepairPolicies(nil, overrides)
+	}
+	return mergeRepairPolicies(DefaultRepairPolicies, overrides)
+}
+
+// mergeRepairPolicies combines the base policies with the overrides. An override with the same condition
+// type and status as a base policy replaces it, all other overrides are appended in order.
+func mergeRepairPolicies(base []cloudprovider.RepairPolicy, overrides []cloudprovider.RepairPolicy) []cloudprovider.RepairPolicy {
+	merged := make([]cloudprovider.RepairPolicy, 0, len(base)+len(overrides))
+	merged = append(merged, base...)
+
+	for _, override := range overrides {
+		replaced := false
+		for i := range merged {
+			if merged[i].ConditionType == override.ConditionType && merged[i].ConditionStatus == override.ConditionStatus {
+				merged[i].TolerationDuration = override.TolerationDuration
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, override)
+		}
+	}
+
+	return merged
+}
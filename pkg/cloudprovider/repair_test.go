@@ -0,0 +1,107 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/operator/options"
+)
+
+var _ = Describe("CloudProvider.RepairPolicies method", func() {
+	It("returns the default repair policies when there are no options", func() {
+		provider := NewCloudProvider(context.Background(), cl, nil, nil, nil)
+		Expect(provider.RepairPolicies()).To(Equal(DefaultRepairPolicies))
+	})
+
+	It("overrides and extends the default repair policies from the options", func() {
+		opts := &options.Options{RepairPolicies: "Ready=False:5m,MemoryPressure=True:1h"}
+		provider := NewCloudProvider(opts.ToContext(context.Background()), cl, nil, nil, nil)
+
+		policies := provider.RepairPolicies()
+		Expect(policies).To(HaveLen(len(DefaultRepairPolicies) + 1))
+		Expect(policies).To(ContainElement(cloudprovider.RepairPolicy{
+			ConditionType:      corev1.NodeReady,
+			ConditionStatus:    corev1.ConditionFalse,
+			TolerationDuration: 5 * time.Minute,
+		}))
+		Expect(policies).To(ContainElement(cloudprovider.RepairPolicy{
+			ConditionType:      corev1.NodeMemoryPressure,
+			ConditionStatus:    corev1.ConditionTrue,
+			TolerationDuration: time.Hour,
+		}))
+	})
+
+	It("returns only the policies from the options when the defaults are disabled", func() {
+		opts := &options.Options{RepairPolicies: "Ready=Unknown:10m", DisableDefaultRepairPolicies: true}
+		provider := NewCloudProvider(opts.ToContext(context.Background()), cl, nil, nil, nil)
+
+		Expect(provider.RepairPolicies()).To(Equal([]cloudprovider.RepairPolicy{
+			{
+				ConditionType:      corev1.NodeReady,
+				ConditionStatus:    corev1.ConditionUnknown,
+				TolerationDuration: 10 * time.Minute,
+			},
+		}))
+	})
+})
+
+var _ = Describe("mergeRepairPolicies function", func() {
+	It("does not modify the base policies", func() {
+		overrides := []cloudprovider.RepairPolicy{
+			{ConditionType: corev1.NodeReady, ConditionStatus: corev1.ConditionFalse, TolerationDuration: time.Minute},
+		}
+		merged := mergeRepairPolicies(DefaultRepairPolicies, overrides)
+		Expect(merged[0].TolerationDuration).To(Equal(time.Minute))
+		Expect(DefaultRepairPolicies[0].TolerationDuration).To(Equal(30 * time.Minute))
+	})
+})
+
+var _ = Describe("options.ParseRepairPolicies function", func() {
+	It("returns an empty list for an empty string", func() {
+		policies, err := options.ParseRepairPolicies("")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(policies).To(BeEmpty())
+	})
+
+	It("parses a list of policies with whitespace", func() {
+		policies, err := options.ParseRepairPolicies(" Ready=False:15m , NetworkUnavailable=True:1h")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(policies).To(Equal([]cloudprovider.RepairPolicy{
+			{ConditionType: corev1.NodeReady, ConditionStatus: corev1.ConditionFalse, TolerationDuration: 15 * time.Minute},
+			{ConditionType: corev1.NodeNetworkUnavailable, ConditionStatus: corev1.ConditionTrue, TolerationDuration: time.Hour},
+		}))
+	})
+
+	DescribeTable("returns an error for invalid policies",
+		func(policies string) {
+			_, err := options.ParseRepairPolicies(policies)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("missing duration", "Ready=False"),
+		Entry("missing status", "Ready:15m"),
+		Entry("unknown status", "Ready=Maybe:15m"),
+		Entry("invalid duration", "Ready=False:soon"),
+		Entry("negative duration", "Ready=False:-5m"),
+	)
+})
@@ -0,0 +1,228 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	expv1beta1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machine"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machinedeployment"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machinepool"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machinetemplate"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/scalableresource"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+)
+
+var _ = Describe("CloudProvider with MachinePools", func() {
+	var provider *CloudProvider
+
+	BeforeEach(func() {
+		machineProvider := machine.NewDefaultProvider(context.Background(), cl)
+		machineDeploymentProvider := machinedeployment.NewDefaultProvider(context.Background(), cl)
+		machinePoolProvider := machinepool.NewDefaultProvider(context.Background(), cl)
+		machineTemplateProvider := machinetemplate.NewDefaultProvider(context.Background(), cl)
+		provider = NewCloudProvider(context.Background(), cl, machineProvider, scalableresource.NewDefaultProvider(context.Background(), cl, machineProvider, machineDeploymentProvider, machinePoolProvider), machineTemplateProvider)
+	})
+
+	AfterEach(func() {
+		eventuallyDeleteAllOf(cl, &capiv1beta1.Machine{}, &capiv1beta1.MachineList{})
+		eventuallyDeleteAllOf(cl, &capiv1beta1.MachineDeployment{}, &capiv1beta1.MachineDeploymentList{})
+		eventuallyDeleteAllOf(cl, &expv1beta1.MachinePool{}, &expv1beta1.MachinePoolList{})
+		eventuallyDeleteAllOf(cl, &v1alpha1.ClusterAPINodeClass{}, &v1alpha1.ClusterAPINodeClassList{})
+	})
+
+	It("returns instance types for MachineDeployments and MachinePools", func() {
+		nodeClass := &v1alpha1.ClusterAPINodeClass{}
+		nodeClass.Name = "default"
+		Expect(cl.Create(context.Background(), nodeClass)).To(Succeed())
+
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		machineDeployment.SetAnnotations(map[string]string{cpuKey: "4", memoryKey: "16Gi", labelsKey: corev1.LabelInstanceTypeStable + "=m5.xlarge"})
+		Expect(cl.Create(context.Background(), machineDeployment)).To(Succeed())
+
+		machinePool := newMachinePool("mp-1", "test-cluster", true)
+		machinePool.SetAnnotations(map[string]string{cpuKey: "8", memoryKey: "32Gi", labelsKey: corev1.LabelInstanceTypeStable + "=m5.2xlarge"})
+		Expect(cl.Create(context.Background(), machinePool)).To(Succeed())
+
+		Expect(cl.Create(context.Background(), newMachinePool("mp-2", "other-cluster", false))).To(Succeed())
+
+		instanceTypes, err := provider.findInstanceTypesForNodeClass(context.Background(), nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).To(HaveLen(2))
+
+		names := map[string]string{}
+		for _, it := range instanceTypes {
+			Expect(it.MachineDeploymentOfferings).To(HaveLen(1))
+			names[it.Name] = it.MachineDeploymentOfferings[0].Kind
+		}
+		Expect(names).To(HaveKeyWithValue("m5.xlarge", providers.MachineDeploymentKind))
+		Expect(names).To(HaveKeyWithValue("m5.2xlarge", providers.MachinePoolKind))
+	})
+
+	It("converts a Machine owned by a MachinePool to a NodeClaim", func() {
+		machinePool := newMachinePool("mp-1", "test-cluster", true)
+		machinePool.SetAnnotations(map[string]string{cpuKey: "8", memoryKey: "32Gi"})
+		Expect(cl.Create(context.Background(), machinePool)).To(Succeed())
+
+		machine := newMachine("m-1", "test-cluster", true)
+		machine.GetLabels()[capiv1beta1.MachinePoolNameLabel] = machinePool.Name
+		Expect(cl.Create(context.Background(), machine)).To(Succeed())
+
+		nodeClaim, err := provider.Get(context.Background(), *machine.Spec.ProviderID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nodeClaim.Status.Capacity.Cpu().String()).To(Equal("8"))
+	})
+
+	It("annotates the Machine and reduces the MachinePool replicas on Delete", func() {
+		machinePool := newMachinePool("mp-1", "test-cluster", true)
+		machinePool.Spec.Replicas = ptr.To(int32(2))
+		Expect(cl.Create(context.Background(), machinePool)).To(Succeed())
+
+		// the Machine is matched to the MachinePool by the providerIDList when it has no pool label.
+		machine := newMachine("m-1", "test-cluster", true)
+		providerID := *machine.Spec.ProviderID
+		Expect(cl.Create(context.Background(), machine)).To(Succeed())
+		machinePool.Spec.ProviderIDList = []string{providerID}
+		Expect(cl.Update(context.Background(), machinePool)).To(Succeed())
+
+		nodeClaim := karpv1.NodeClaim{
+			Status: karpv1.NodeClaimStatus{
+				ProviderID: providerID,
+			},
+		}
+		Expect(provider.Delete(context.Background(), &nodeClaim)).To(Succeed())
+
+		Eventually(func() map[string]string {
+			m, err := provider.machineProvider.GetByProviderID(context.Background(), providerID)
+			Expect(err).ToNot(HaveOccurred())
+			return m.GetAnnotations()
+		}).Should(HaveKey(capiv1beta1.DeleteMachineAnnotation))
+
+		Eventually(func() *int32 {
+			mp := &expv1beta1.MachinePool{}
+			Expect(cl.Get(context.Background(), client.ObjectKeyFromObject(machinePool), mp)).To(Succeed())
+			return mp.Spec.Replicas
+		}).Should(Equal(ptr.To(int32(1))))
+	})
+})
+
+var _ = Describe("CloudProvider with MachineSets", func() {
+	var provider *CloudProvider
+
+	BeforeEach(func() {
+		machineProvider := machine.NewDefaultProvider(context.Background(), cl)
+		machineDeploymentProvider := machinedeployment.NewDefaultProvider(context.Background(), cl)
+		machineTemplateProvider := machinetemplate.NewDefaultProvider(context.Background(), cl)
+		provider = NewCloudProvider(context.Background(), cl, machineProvider, scalableresource.NewDefaultProvider(context.Background(), cl, machineProvider, machineDeploymentProvider, nil), machineTemplateProvider)
+	})
+
+	AfterEach(func() {
+		eventuallyDeleteAllOf(cl, &capiv1beta1.Machine{}, &capiv1beta1.MachineList{})
+		eventuallyDeleteAllOf(cl, &capiv1beta1.MachineSet{}, &capiv1beta1.MachineSetList{})
+		eventuallyDeleteAllOf(cl, &capiv1beta1.MachineDeployment{}, &capiv1beta1.MachineDeploymentList{})
+		eventuallyDeleteAllOf(cl, &v1alpha1.ClusterAPINodeClass{}, &v1alpha1.ClusterAPINodeClassList{})
+	})
+
+	It("returns instance types for standalone MachineSets only", func() {
+		nodeClass := &v1alpha1.ClusterAPINodeClass{}
+		nodeClass.Name = "default"
+		Expect(cl.Create(context.Background(), nodeClass)).To(Succeed())
+
+		machineSet := newMachineSet("ms-1", "test-cluster", true)
+		machineSet.SetAnnotations(map[string]string{cpuKey: "4", memoryKey: "16Gi", labelsKey: corev1.LabelInstanceTypeStable + "=m5.xlarge"})
+		Expect(cl.Create(context.Background(), machineSet)).To(Succeed())
+
+		// MachineSets created by a MachineDeployment are scaled through the MachineDeployment.
+		ownedMachineSet := newMachineSet("ms-2", "test-cluster", true)
+		ownedMachineSet.GetLabels()[capiv1beta1.MachineDeploymentNameLabel] = "md-1"
+		ownedMachineSet.SetAnnotations(map[string]string{cpuKey: "8", memoryKey: "32Gi", labelsKey: corev1.LabelInstanceTypeStable + "=m5.2xlarge"})
+		Expect(cl.Create(context.Background(), ownedMachineSet)).To(Succeed())
+
+		instanceTypes, err := provider.findInstanceTypesForNodeClass(context.Background(), nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).To(HaveLen(1))
+		Expect(instanceTypes[0].Name).To(Equal("m5.xlarge"))
+		Expect(instanceTypes[0].MachineDeploymentOfferings).To(HaveLen(1))
+		Expect(instanceTypes[0].MachineDeploymentOfferings[0].Kind).To(Equal(providers.MachineSetKind))
+	})
+
+	It("annotates the Machine and reduces the MachineSet replicas on Delete", func() {
+		machineSet := newMachineSet("ms-1", "test-cluster", true)
+		machineSet.Spec.Replicas = ptr.To(int32(2))
+		Expect(cl.Create(context.Background(), machineSet)).To(Succeed())
+
+		machine := newMachine("m-1", "test-cluster", true)
+		machine.GetLabels()[capiv1beta1.MachineSetNameLabel] = machineSet.Name
+		providerID := *machine.Spec.ProviderID
+		Expect(cl.Create(context.Background(), machine)).To(Succeed())
+
+		nodeClaim := karpv1.NodeClaim{
+			Status: karpv1.NodeClaimStatus{
+				ProviderID: providerID,
+			},
+		}
+		Expect(provider.Delete(context.Background(), &nodeClaim)).To(Succeed())
+
+		Eventually(func() map[string]string {
+			m, err := provider.machineProvider.GetByProviderID(context.Background(), providerID)
+			Expect(err).ToNot(HaveOccurred())
+			return m.GetAnnotations()
+		}).Should(HaveKey(capiv1beta1.DeleteMachineAnnotation))
+
+		Eventually(func() *int32 {
+			ms := &capiv1beta1.MachineSet{}
+			Expect(cl.Get(context.Background(), client.ObjectKeyFromObject(machineSet), ms)).To(Succeed())
+			return ms.Spec.Replicas
+		}).Should(Equal(ptr.To(int32(1))))
+	})
+})
+
+func newMachinePool(name string, clusterName string, karpenterMember bool) *expv1beta1.MachinePool {
+	machinePool := &expv1beta1.MachinePool{}
+	machinePool.SetName(name)
+	machinePool.SetNamespace(testNamespace)
+	if karpenterMember {
+		machinePool.SetLabels(map[string]string{providers.NodePoolMemberLabel: ""})
+	}
+	machinePool.Spec.ClusterName = clusterName
+	machinePool.Spec.Template.Spec.ClusterName = clusterName
+	return machinePool
+}
+
+func newMachineSet(name string, clusterName string, karpenterMember bool) *capiv1beta1.MachineSet {
+	machineSet := &capiv1beta1.MachineSet{}
+	machineSet.SetName(name)
+	machineSet.SetNamespace(testNamespace)
+	machineSet.SetLabels(map[string]string{})
+	if karpenterMember {
+		machineSet.GetLabels()[providers.NodePoolMemberLabel] = ""
+	}
+	machineSet.Spec.ClusterName = clusterName
+	machineSet.Spec.Selector.MatchLabels = map[string]string{capiv1beta1.MachineSetNameLabel: name}
+	machineSet.Spec.Template.Labels = map[string]string{capiv1beta1.MachineSetNameLabel: name}
+	machineSet.Spec.Template.Spec.ClusterName = clusterName
+	return machineSet
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/operator/options"
+)
+
+// selectionStrategyFromOptions returns the controller default instance type selection strategy.
+func selectionStrategyFromOptions(ctx context.Context) v1alpha1.InstanceTypeSelectionStrategy {
+	if opts := options.FromContext(ctx); opts != nil && opts.InstanceTypeSelectionStrategy != "" {
+		return v1alpha1.InstanceTypeSelectionStrategy(opts.InstanceTypeSelectionStrategy)
+	}
+	return v1alpha1.InstanceTypeSelectionStrategyCheapest
+}
+
+// selectionStrategyForNodeClass returns the strategy from the NodeClass, or the default if it is not set.
+func selectionStrategyForNodeClass(nodeClass *v1alpha1.ClusterAPINodeClass, defaultStrategy v1alpha1.InstanceTypeSelectionStrategy) v1alpha1.InstanceTypeSelectionStrategy {
+	if nodeClass != nil && nodeClass.Spec.InstanceTypeSelectionStrategy != nil {
+		return *nodeClass.Spec.InstanceTypeSelectionStrategy
+	}
+	return defaultStrategy
+}
+
+// selectInstanceType chooses one of the compatible instance types for the NodeClaim using the strategy.
+// Instance types that are equal according to the strategy are ordered by name so that the choice is stable.
+func selectInstanceType(strategy v1alpha1.InstanceTypeSelectionStrategy, instanceTypes []*ClusterAPIInstanceType, nodeClaim *karpv1.NodeClaim) *ClusterAPIInstanceType {
+	if len(instanceTypes) == 0 {
+		return nil
+	}
+
+	sorted := slices.Clone(instanceTypes)
+	slices.SortFunc(sorted, func(a, b *ClusterAPIInstanceType) int {
+		return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+
+	switch strategy {
+	case v1alpha1.InstanceTypeSelectionStrategyRandom:
+		return sorted[rand.IntN(len(sorted))]
+	case v1alpha1.InstanceTypeSelectionStrategyWeighted:
+		slices.SortStableFunc(sorted, func(a, b *ClusterAPIInstanceType) int {
+			// higher weights first
+			return cmp.Compare(b.Weight, a.Weight)
+		})
+	case v1alpha1.InstanceTypeSelectionStrategyLeastWaste:
+		slices.SortStableFunc(sorted, func(a, b *ClusterAPIInstanceType) int {
+			return cmp.Compare(resourceWaste(a, nodeClaim), resourceWaste(b, nodeClaim))
+		})
+	default:
+		reqs := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+		slices.SortStableFunc(sorted, func(a, b *ClusterAPIInstanceType) int {
+			return cmp.Compare(cheapestPrice(a, reqs), cheapestPrice(b, reqs))
+		})
+	}
+
+	return sorted[0]
+}
+
+// cheapestPrice returns the lowest price of the available offerings that are compatible with the requirements.
+func cheapestPrice(instanceType *ClusterAPIInstanceType, reqs scheduling.Requirements) float64 {
+	offerings := instanceType.Offerings.Available().Compatible(reqs)
+	if len(offerings) == 0 {
+		return math.MaxFloat64
+	}
+	return offerings.Cheapest().Price
+}
+
+// resourceWaste returns the fraction of the allocatable CPU and memory of the instance type that would
+// not be used by the resource requests of the NodeClaim. The fractions for each resource are added together.
+func resourceWaste(instanceType *ClusterAPIInstanceType, nodeClaim *karpv1.NodeClaim) float64 {
+	allocatable := instanceType.Allocatable()
+	waste := 0.0
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		available, found := allocatable[name]
+		if !found || available.IsZero() {
+			continue
+		}
+		requested := nodeClaim.Spec.Resources.Requests[name]
+		waste += (available.AsApproximateFloat64() - requested.AsApproximateFloat64()) / available.AsApproximateFloat64()
+	}
+	return waste
+}
+
+// weightFromMachineDeployment returns the value of the WeightAnnotation, or zero if it is missing or invalid.
+func weightFromMachineDeployment(machineDeployment *capiv1beta1.MachineDeployment) int {
+	value, found := machineDeployment.GetAnnotations()[WeightAnnotation]
+	if !found {
+		return 0
+	}
+	weight, err := strconv.Atoi(value)
+	if err != nil {
+		return 0
+	}
+	return weight
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/operator/options"
+)
+
+var _ = Describe("selectInstanceType function", func() {
+	var small, large, medium *ClusterAPIInstanceType
+	var nodeClaim *karpv1.NodeClaim
+
+	// newSelectionInstanceType returns an instance type with a single offering at the given price.
+	newSelectionInstanceType := func(name, cpu, memory, price string) *ClusterAPIInstanceType {
+		machineDeployment := newMachineDeployment(name, "test-cluster", true)
+		machineDeployment.SetAnnotations(map[string]string{
+			cpuKey:          cpu,
+			memoryKey:       memory,
+			labelsKey:       corev1.LabelInstanceTypeStable + "=" + name,
+			PriceAnnotation: price,
+		})
+		instanceType := machineDeploymentToInstanceType(context.Background(), machineDeployment, nil)
+		p, err := priceForInstanceType(machineDeployment, instanceType, nil)
+		Expect(err).ToNot(HaveOccurred())
+		setOfferingPrices(instanceType, p)
+		return instanceType
+	}
+
+	BeforeEach(func() {
+		// names are chosen so that alphabetical order differs from size and price order.
+		large = newSelectionInstanceType("a-large", "64", "256Gi", "4.0")
+		medium = newSelectionInstanceType("b-medium", "8", "32Gi", "0.5")
+		small = newSelectionInstanceType("c-small", "2", "8Gi", "1.0")
+		nodeClaim = &karpv1.NodeClaim{}
+		nodeClaim.Spec.Resources.Requests = corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("2Gi"),
+		}
+	})
+
+	It("returns nil when there are no instance types", func() {
+		Expect(selectInstanceType(v1alpha1.InstanceTypeSelectionStrategyCheapest, nil, nodeClaim)).To(BeNil())
+	})
+
+	It("chooses the cheapest instance type", func() {
+		selected := selectInstanceType(v1alpha1.InstanceTypeSelectionStrategyCheapest, []*ClusterAPIInstanceType{large, medium, small}, nodeClaim)
+		Expect(selected).To(Equal(medium))
+	})
+
+	It("chooses by name when all prices are equal", func() {
+		setOfferingPrices(medium, 0.0)
+		setOfferingPrices(small, 0.0)
+		selected := selectInstanceType(v1alpha1.InstanceTypeSelectionStrategyCheapest, []*ClusterAPIInstanceType{small, medium}, nodeClaim)
+		Expect(selected).To(Equal(medium))
+	})
+
+	It("chooses the instance type with the least waste", func() {
+		selected := selectInstanceType(v1alpha1.InstanceTypeSelectionStrategyLeastWaste, []*ClusterAPIInstanceType{large, medium, small}, nodeClaim)
+		Expect(selected).To(Equal(small))
+	})
+
+	It("chooses the instance type with the highest weight", func() {
+		large.Weight = 10
+		medium.Weight = 5
+		selected := selectInstanceType(v1alpha1.InstanceTypeSelectionStrategyWeighted, []*ClusterAPIInstanceType{small, medium, large}, nodeClaim)
+		Expect(selected).To(Equal(large))
+	})
+
+	It("chooses one of the instance types at random", func() {
+		instanceTypes := []*ClusterAPIInstanceType{large, medium, small}
+		selected := selectInstanceType(v1alpha1.InstanceTypeSelectionStrategyRandom, instanceTypes, nodeClaim)
+		Expect(instanceTypes).To(ContainElement(selected))
+	})
+})
+
+var _ = Describe("selectionStrategyForNodeClass function", func() {
+	It("returns the default strategy when the NodeClass does not set one", func() {
+		nodeClass := &v1alpha1.ClusterAPINodeClass{}
+		Expect(selectionStrategyForNodeClass(nodeClass, v1alpha1.InstanceTypeSelectionStrategyRandom)).To(Equal(v1alpha1.InstanceTypeSelectionStrategyRandom))
+	})
+
+	It("returns the strategy from the NodeClass", func() {
+		nodeClass := &v1alpha1.ClusterAPINodeClass{}
+		nodeClass.Spec.InstanceTypeSelectionStrategy = ptr.To(v1alpha1.InstanceTypeSelectionStrategyLeastWaste)
+		Expect(selectionStrategyForNodeClass(nodeClass, v1alpha1.InstanceTypeSelectionStrategyRandom)).To(Equal(v1alpha1.InstanceTypeSelectionStrategyLeastWaste))
+	})
+})
+
+var _ = Describe("selectionStrategyFromOptions function", func() {
+	It("returns Cheapest when there are no options", func() {
+		Expect(selectionStrategyFromOptions(context.Background())).To(Equal(v1alpha1.InstanceTypeSelectionStrategyCheapest))
+	})
+
+	It("returns the strategy from the options", func() {
+		opts := &options.Options{InstanceTypeSelectionStrategy: string(v1alpha1.InstanceTypeSelectionStrategyWeighted)}
+		Expect(selectionStrategyFromOptions(opts.ToContext(context.Background()))).To(Equal(v1alpha1.InstanceTypeSelectionStrategyWeighted))
+	})
+})
+
+var _ = Describe("weightFromMachineDeployment function", func() {
+	It("returns the weight from the annotation", func() {
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		machineDeployment.SetAnnotations(map[string]string{WeightAnnotation: "42"})
+		Expect(weightFromMachineDeployment(machineDeployment)).To(Equal(42))
+	})
+
+	It("returns zero when the annotation is missing or invalid", func() {
+		machineDeployment := newMachineDeployment("md-1", "test-cluster", true)
+		Expect(weightFromMachineDeployment(machineDeployment)).To(BeZero())
+		machineDeployment.SetAnnotations(map[string]string{WeightAnnotation: "heavy"})
+		Expect(weightFromMachineDeployment(machineDeployment)).To(BeZero())
+	})
+})
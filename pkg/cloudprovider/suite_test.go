@@ -58,6 +58,7 @@ var _ = BeforeSuite(func() {
 	testEnv = &envtest.Environment{
 		CRDDirectoryPaths: []string{
 			filepath.Join("..", "..", "vendor", "sigs.k8s.io", "cluster-api", "api", "v1beta1"),
+			filepath.Join("..", "..", "vendor", "sigs.k8s.io", "cluster-api", "exp", "api", "v1beta1"),
 			filepath.Join("..", "apis", "crds"),
 		},
 		ErrorIfCRDPathMissing: true,
@@ -20,11 +20,18 @@ import (
 	"context"
 
 	"github.com/awslabs/operatorpkg/controller"
+	"github.com/samber/lo"
 
 	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	garbagecollectioncontroller "sigs.k8s.io/karpenter-provider-cluster-api/pkg/controllers/machine/garbagecollection"
+	launchcontroller "sigs.k8s.io/karpenter-provider-cluster-api/pkg/controllers/nodeclaim/launch"
+	instancetypecontroller "sigs.k8s.io/karpenter-provider-cluster-api/pkg/controllers/nodeclass/instancetype"
 	statuscontroller "sigs.k8s.io/karpenter-provider-cluster-api/pkg/controllers/nodeclass/status"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/operator/options"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machine"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/events"
 )
@@ -36,9 +43,23 @@ func NewControllers(
 	kubeClient client.Client,
 	recorder events.Recorder,
 	cloudProvider cloudprovider.CloudProvider,
+	machineProvider machine.Provider,
+	managementCluster cluster.Cluster,
+	instanceTypeInvalidator instancetypecontroller.Invalidator,
+	machineCollector garbagecollectioncontroller.MachineCollector,
 ) []controller.Controller {
+	opts := lo.FromPtr(options.FromContext(ctx))
+	// the Machines are in the management cluster, so their events are recorded there.
+	machineRecorder := events.NewRecorder(managementCluster.GetEventRecorderFor("karpenter"))
 	controllers := []controller.Controller{
-		statuscontroller.NewController(kubeClient),
+		statuscontroller.NewController(kubeClient, opts.ClusterName),
+		launchcontroller.NewController(kubeClient, recorder, cloudProvider, machineProvider),
+		instancetypecontroller.NewController(kubeClient, managementCluster, instanceTypeInvalidator),
+	}
+	// the claimed Machines can only be attributed to this Karpenter when it is scoped to a workload Cluster, so
+	// orphaned Machines are not collected otherwise.
+	if opts.ClusterName != "" {
+		controllers = append(controllers, garbagecollectioncontroller.NewController(clock, kubeClient, machineRecorder, machineCollector, opts.ClusterName, opts.OrphanedMachineGracePeriod, opts.OrphanedMachineDryRun))
 	}
 	return controllers
 }
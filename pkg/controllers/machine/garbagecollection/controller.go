@@ -0,0 +1,243 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package garbagecollection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/awslabs/operatorpkg/singleton"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers"
+)
+
+// pollInterval is how often the claimed Machines are compared with the NodeClaims.
+const pollInterval = 2 * time.Minute
+
+// MachineCollector lists the Machines claimed for NodeClaims and deletes them, the CloudProvider implements it.
+type MachineCollector interface {
+	ListClaimedMachines(ctx context.Context) ([]*capiv1beta1.Machine, error)
+	DeleteMachine(ctx context.Context, machine *capiv1beta1.Machine) error
+}
+
+// Controller deletes orphaned Machines, which are claimed for a NodeClaim that does not exist. This happens when a
+// NodeClaim is removed without its finalizer, or when the NodeClaim of a claimed Machine was never annotated. A
+// Machine is only deleted after it has been orphaned for the grace period, and in the dry run mode it is only
+// reported. Only the Machines of the workload Cluster whose binding annotations name a NodePool and a
+// ClusterAPINodeClass of this Karpenter are collected, so that the Machines of other Clusters, and of other
+// Karpenters which share the management cluster, are never deleted.
+type Controller struct {
+	clock       clock.Clock
+	kubeClient  client.Client
+	recorder    events.Recorder
+	collector   MachineCollector
+	clusterName string
+	gracePeriod time.Duration
+	dryRun      bool
+
+	// orphanedSince is when each orphaned Machine was first seen, Machines which are no longer orphaned are removed.
+	orphanedSince map[types.UID]time.Time
+}
+
+func NewController(clk clock.Clock, kubeClient client.Client, recorder events.Recorder, collector MachineCollector, clusterName string, gracePeriod time.Duration, dryRun bool) *Controller {
+	return &Controller{
+		clock:         clk,
+		kubeClient:    kubeClient,
+		recorder:      recorder,
+		collector:     collector,
+		clusterName:   clusterName,
+		gracePeriod:   gracePeriod,
+		dryRun:        dryRun,
+		orphanedSince: map[types.UID]time.Time{},
+	}
+}
+
+func (c *Controller) Name() string {
+	return "machine.garbagecollection"
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, c.Name())
+
+	machines, err := c.collector.ListClaimedMachines(ctx)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("unable to list claimed Machines: %w", err)
+	}
+	nodeClaimList := &karpv1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaimList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("unable to list NodeClaims: %w", err)
+	}
+	owners := newNodeClaimOwners(nodeClaimList.Items)
+	bindings, err := c.karpenterBindings(ctx)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	var errs []error
+	orphaned := sets.New[types.UID]()
+	for _, machine := range machines {
+		if !c.isCollectable(machine, bindings) || !isOrphaned(machine, owners) {
+			continue
+		}
+		orphaned.Insert(machine.UID)
+		since, found := c.orphanedSince[machine.UID]
+		if !found {
+			since = c.clock.Now()
+			c.orphanedSince[machine.UID] = since
+		}
+		if c.clock.Since(since) < c.gracePeriod {
+			continue
+		}
+
+		logger := log.FromContext(ctx).WithValues("Machine", klog.KObj(machine), "NodeClaim", machine.GetAnnotations()[providers.NodeClaimAnnotation])
+		if c.dryRun {
+			logger.Info("found orphaned Machine, not deleting it in the dry run mode")
+			c.recorder.Publish(OrphanedMachineDryRun(machine))
+			continue
+		}
+		if err := c.collector.DeleteMachine(ctx, machine); err != nil {
+			c.recorder.Publish(OrphanedMachineDeleteFailed(machine, err))
+			errs = append(errs, fmt.Errorf("unable to delete orphaned Machine %q: %w", machine.Name, err))
+			continue
+		}
+		logger.Info("deleted orphaned Machine")
+		c.recorder.Publish(OrphanedMachineDeleted(machine))
+	}
+
+	for uid := range c.orphanedSince {
+		if !orphaned.Has(uid) {
+			delete(c.orphanedSince, uid)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: pollInterval}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named(c.Name()).
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
+
+// karpenterBindings are the names of the NodePools and ClusterAPINodeClasses of this Karpenter.
+type karpenterBindings struct {
+	nodePools   sets.Set[string]
+	nodeClasses sets.Set[string]
+}
+
+func (c *Controller) karpenterBindings(ctx context.Context) (karpenterBindings, error) {
+	bindings := karpenterBindings{
+		nodePools:   sets.New[string](),
+		nodeClasses: sets.New[string](),
+	}
+	nodePoolList := &karpv1.NodePoolList{}
+	if err := c.kubeClient.List(ctx, nodePoolList); err != nil {
+		return bindings, fmt.Errorf("unable to list NodePools: %w", err)
+	}
+	for _, nodePool := range nodePoolList.Items {
+		bindings.nodePools.Insert(nodePool.Name)
+	}
+	nodeClassList := &v1alpha1.ClusterAPINodeClassList{}
+	if err := c.kubeClient.List(ctx, nodeClassList); err != nil {
+		return bindings, fmt.Errorf("unable to list NodeClasses: %w", err)
+	}
+	for _, nodeClass := range nodeClassList.Items {
+		bindings.nodeClasses.Insert(nodeClass.Name)
+	}
+
+	return bindings, nil
+}
+
+// isCollectable returns true if the Machine has the cluster name label of the workload Cluster of this Karpenter,
+// and its binding annotations name a NodeClaim and an existing NodePool and ClusterAPINodeClass. A Machine without
+// the binding annotations is never deleted, because it cannot be told apart from a Machine of another Karpenter.
+func (c *Controller) isCollectable(machine *capiv1beta1.Machine, bindings karpenterBindings) bool {
+	if c.clusterName == "" || machine.GetLabels()[capiv1beta1.ClusterNameLabel] != c.clusterName {
+		return false
+	}
+	annotations := machine.GetAnnotations()
+	if annotations[providers.NodeClaimAnnotation] == "" {
+		return false
+	}
+	return bindings.nodePools.Has(annotations[providers.NodePoolAnnotation]) && bindings.nodeClasses.Has(annotations[providers.NodeClassAnnotation])
+}
+
+// nodeClaimOwners are the references from the NodeClaims to their Machines, and the names of the NodeClaims that
+// Machines refer to.
+type nodeClaimOwners struct {
+	names       sets.Set[string]
+	providerIDs sets.Set[string]
+	machines    sets.Set[string]
+}
+
+func newNodeClaimOwners(nodeClaims []karpv1.NodeClaim) nodeClaimOwners {
+	owners := nodeClaimOwners{
+		names:       sets.New[string](),
+		providerIDs: sets.New[string](),
+		machines:    sets.New[string](),
+	}
+	for _, nodeClaim := range nodeClaims {
+		owners.names.Insert(nodeClaim.Name)
+		if nodeClaim.Status.ProviderID != "" {
+			owners.providerIDs.Insert(nodeClaim.Status.ProviderID)
+		}
+		if machineAnno, ok := nodeClaim.Annotations[providers.MachineAnnotation]; ok {
+			owners.machines.Insert(machineAnno)
+		}
+	}
+
+	return owners
+}
+
+// isOrphaned returns true if no NodeClaim refers to the Machine, and the Machine does not refer to a NodeClaim. The
+// Machines which are already being deleted, or are marked for deletion from their scalable resource, are skipped.
+func isOrphaned(machine *capiv1beta1.Machine, owners nodeClaimOwners) bool {
+	if !machine.DeletionTimestamp.IsZero() {
+		return false
+	}
+	if _, marked := machine.GetAnnotations()[capiv1beta1.DeleteMachineAnnotation]; marked {
+		return false
+	}
+	if name, ok := machine.GetAnnotations()[providers.NodeClaimAnnotation]; ok && owners.names.Has(name) {
+		return false
+	}
+	if machine.Spec.ProviderID != nil && owners.providerIDs.Has(*machine.Spec.ProviderID) {
+		return false
+	}
+
+	return !owners.machines.Has(fmt.Sprintf("%s/%s", machine.Namespace, machine.Name))
+}
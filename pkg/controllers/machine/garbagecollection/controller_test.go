@@ -0,0 +1,222 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package garbagecollection_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/controllers/machine/garbagecollection"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/test"
+)
+
+var _ = Describe("Machine GarbageCollection Controller", func() {
+	var m *capiv1beta1.Machine
+
+	newNodeClaim := func(name string) *karpv1.NodeClaim {
+		nodeClaim := &karpv1.NodeClaim{}
+		nodeClaim.Name = name
+		nodeClaim.Labels = map[string]string{karpv1.NodePoolLabelKey: "default"}
+		nodeClaim.Spec.NodeClassRef = &karpv1.NodeClassReference{Group: v1alpha1.Group, Kind: "ClusterAPINodeClass", Name: "default"}
+		nodeClaim.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{}
+		return nodeClaim
+	}
+
+	BeforeEach(func() {
+		m = &capiv1beta1.Machine{}
+		m.Name = "m-1"
+		m.Namespace = testNamespace
+		m.UID = types.UID("m-1-uid")
+		m.Labels = map[string]string{providers.NodePoolMemberLabel: "", capiv1beta1.ClusterNameLabel: testClusterName}
+		m.Annotations = providers.BindingAnnotations("nodeclaim-1", "default", "default")
+		m.Spec.ProviderID = ptr.To("clusterapi://m-1")
+
+		nodeClass := &v1alpha1.ClusterAPINodeClass{}
+		nodeClass.Name = "default"
+		nodePool := coretest.NodePool(karpv1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
+		nodePool.Spec.Template.Spec.NodeClassRef = &karpv1.NodeClassReference{Group: v1alpha1.Group, Kind: "ClusterAPINodeClass", Name: "default"}
+		ExpectApplied(ctx, cl, nodeClass, nodePool)
+
+		collector.machines = []*capiv1beta1.Machine{m}
+		collector.deleted = nil
+		collector.deleteErr = nil
+		recorder.Reset()
+	})
+
+	AfterEach(func() {
+		test.EventuallyDeleteAllOf(cl, &karpv1.NodeClaim{}, &karpv1.NodeClaimList{}, "")
+		test.EventuallyDeleteAllOf(cl, &karpv1.NodePool{}, &karpv1.NodePoolList{}, "")
+		test.EventuallyDeleteAllOf(cl, &v1alpha1.ClusterAPINodeClass{}, &v1alpha1.ClusterAPINodeClassList{}, "")
+	})
+
+	It("does not delete a Machine whose NodeClaim exists", func() {
+		ExpectApplied(ctx, cl, newNodeClaim("nodeclaim-1"))
+		controller := newController(false)
+
+		ExpectSingletonReconciled(ctx, controller)
+		fakeClock.Step(2 * gracePeriod)
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(collector.deleted).To(BeEmpty())
+	})
+
+	It("does not delete a Machine referenced by the Machine annotation or provider ID of a NodeClaim", func() {
+		m.Annotations = providers.BindingAnnotations("nodeclaim-0", "default", "default")
+		byAnnotation := newNodeClaim("nodeclaim-2")
+		byAnnotation.Annotations = map[string]string{providers.MachineAnnotation: testNamespace + "/" + m.Name}
+		ExpectApplied(ctx, cl, byAnnotation)
+
+		other := m.DeepCopy()
+		other.Name = "m-2"
+		other.UID = types.UID("m-2-uid")
+		other.Spec.ProviderID = ptr.To("clusterapi://m-2")
+		byProviderID := newNodeClaim("nodeclaim-3")
+		ExpectApplied(ctx, cl, byProviderID)
+		byProviderID.Status.ProviderID = "clusterapi://m-2"
+		ExpectApplied(ctx, cl, byProviderID)
+		collector.machines = append(collector.machines, other)
+		controller := newController(false)
+
+		ExpectSingletonReconciled(ctx, controller)
+		fakeClock.Step(2 * gracePeriod)
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(collector.deleted).To(BeEmpty())
+	})
+
+	It("deletes an orphaned Machine after the grace period", func() {
+		controller := newController(false)
+
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(collector.deleted).To(BeEmpty())
+
+		fakeClock.Step(gracePeriod)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(collector.deleted).To(ConsistOf("m-1"))
+		Expect(recorder.Calls("OrphanedMachineDeleted")).To(Equal(1))
+	})
+
+	It("does not delete an orphaned Machine of another Cluster", func() {
+		m.Labels[capiv1beta1.ClusterNameLabel] = "other-cluster"
+		controller := newController(false)
+
+		ExpectSingletonReconciled(ctx, controller)
+		fakeClock.Step(2 * gracePeriod)
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(collector.deleted).To(BeEmpty())
+	})
+
+	It("does not delete orphaned Machines when it is not scoped to a Cluster", func() {
+		controller := garbagecollection.NewController(fakeClock, cl, recorder, collector, "", gracePeriod, false)
+
+		ExpectSingletonReconciled(ctx, controller)
+		fakeClock.Step(2 * gracePeriod)
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(collector.deleted).To(BeEmpty())
+	})
+
+	It("does not delete an orphaned Machine whose binding annotations do not name this Karpenter", func() {
+		withoutAnnotations := m.DeepCopy()
+		withoutAnnotations.Name = "m-2"
+		withoutAnnotations.UID = types.UID("m-2-uid")
+		withoutAnnotations.Annotations = nil
+		otherNodePool := m.DeepCopy()
+		otherNodePool.Name = "m-3"
+		otherNodePool.UID = types.UID("m-3-uid")
+		otherNodePool.Annotations = providers.BindingAnnotations("nodeclaim-3", "other", "default")
+		otherNodeClass := m.DeepCopy()
+		otherNodeClass.Name = "m-4"
+		otherNodeClass.UID = types.UID("m-4-uid")
+		otherNodeClass.Annotations = providers.BindingAnnotations("nodeclaim-4", "default", "other")
+		collector.machines = []*capiv1beta1.Machine{withoutAnnotations, otherNodePool, otherNodeClass}
+		controller := newController(false)
+
+		ExpectSingletonReconciled(ctx, controller)
+		fakeClock.Step(2 * gracePeriod)
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(collector.deleted).To(BeEmpty())
+	})
+
+	It("restarts the grace period when a Machine is no longer orphaned", func() {
+		controller := newController(false)
+
+		ExpectSingletonReconciled(ctx, controller)
+		fakeClock.Step(gracePeriod / 2)
+		ExpectApplied(ctx, cl, newNodeClaim("nodeclaim-1"))
+		ExpectSingletonReconciled(ctx, controller)
+
+		test.EventuallyDeleteAllOf(cl, &karpv1.NodeClaim{}, &karpv1.NodeClaimList{}, "")
+		ExpectSingletonReconciled(ctx, controller)
+		fakeClock.Step(gracePeriod / 2)
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(collector.deleted).To(BeEmpty())
+	})
+
+	It("skips Machines which are already marked for deletion", func() {
+		m.Annotations[capiv1beta1.DeleteMachineAnnotation] = ""
+		deleting := m.DeepCopy()
+		deleting.Name = "m-2"
+		deleting.UID = types.UID("m-2-uid")
+		deleting.DeletionTimestamp = ptr.To(metav1.Now())
+		collector.machines = append(collector.machines, deleting)
+		controller := newController(false)
+
+		ExpectSingletonReconciled(ctx, controller)
+		fakeClock.Step(gracePeriod)
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(collector.deleted).To(BeEmpty())
+	})
+
+	It("only publishes an event in the dry run mode", func() {
+		controller := newController(true)
+
+		ExpectSingletonReconciled(ctx, controller)
+		fakeClock.Step(gracePeriod)
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(collector.deleted).To(BeEmpty())
+		Expect(recorder.Calls("OrphanedMachine")).To(Equal(1))
+	})
+
+	It("publishes an event and returns an error when the Machine cannot be deleted", func() {
+		collector.deleteErr = fmt.Errorf("scalable resource is at its minimum size")
+		controller := newController(false)
+
+		ExpectSingletonReconciled(ctx, controller)
+		fakeClock.Step(gracePeriod)
+		_, err := controller.Reconcile(ctx)
+
+		Expect(err).To(MatchError(ContainSubstring("unable to delete orphaned Machine \"m-1\"")))
+		Expect(recorder.Calls("OrphanedMachineDeleteFailed")).To(Equal(1))
+	})
+})
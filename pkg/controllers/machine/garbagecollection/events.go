@@ -0,0 +1,58 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package garbagecollection
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/events"
+)
+
+// OrphanedMachineDeleted is published when an orphaned Machine is deleted.
+func OrphanedMachineDeleted(machine *capiv1beta1.Machine) events.Event {
+	return events.Event{
+		InvolvedObject: machine,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "OrphanedMachineDeleted",
+		Message:        "Deleted Machine without a NodeClaim",
+		DedupeValues:   []string{string(machine.UID)},
+	}
+}
+
+// OrphanedMachineDeleteFailed is published when an orphaned Machine could not be deleted.
+func OrphanedMachineDeleteFailed(machine *capiv1beta1.Machine, err error) events.Event {
+	return events.Event{
+		InvolvedObject: machine,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "OrphanedMachineDeleteFailed",
+		Message:        fmt.Sprintf("Failed to delete Machine without a NodeClaim, %s", err),
+		DedupeValues:   []string{string(machine.UID)},
+	}
+}
+
+// OrphanedMachineDryRun is published instead of deleting an orphaned Machine in the dry run mode.
+func OrphanedMachineDryRun(machine *capiv1beta1.Machine) events.Event {
+	return events.Event{
+		InvolvedObject: machine,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "OrphanedMachine",
+		Message:        "Machine does not have a NodeClaim, it is not deleted in the dry run mode",
+		DedupeValues:   []string{string(machine.UID)},
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package garbagecollection_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2/textlogger"
+	clock "k8s.io/utils/clock/testing"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/controllers/machine/garbagecollection"
+	"sigs.k8s.io/karpenter/pkg/test"
+)
+
+const (
+	testNamespace   = "karpenter-cluster-api"
+	testClusterName = "test-cluster"
+	gracePeriod     = 10 * time.Minute
+)
+
+var ctx context.Context
+var cfg *rest.Config
+var cl client.Client
+var testEnv *envtest.Environment
+var testScheme *runtime.Scheme
+var fakeClock *clock.FakeClock
+var recorder *test.EventRecorder
+var collector *fakeCollector
+
+func TestGarbageCollectionController(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Machine.GarbageCollection Suite")
+}
+
+var _ = BeforeSuite(func() {
+	var err error
+	logf.SetLogger(textlogger.NewLogger(textlogger.NewConfig()))
+
+	By("bootstrapping test environment")
+	testEnv = &envtest.Environment{
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "..", "..", "apis", "crds"),
+		},
+		ErrorIfCRDPathMissing: true,
+	}
+
+	ctx = context.Background()
+
+	cfg, err = testEnv.Start()
+	Expect(err).NotTo(HaveOccurred())
+	Expect(cfg).NotTo(BeNil())
+
+	testScheme = scheme.Scheme
+	Expect(capiv1beta1.AddToScheme(testScheme)).To(Succeed())
+	Expect(v1alpha1.AddToScheme(testScheme)).To(Succeed())
+
+	cl, err = client.New(cfg, client.Options{Scheme: testScheme})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(cl).NotTo(BeNil())
+
+	fakeClock = clock.NewFakeClock(time.Now())
+	recorder = test.NewEventRecorder()
+	collector = &fakeCollector{}
+})
+
+var _ = AfterSuite(func() {
+	By("tearing down the test environment")
+	err := testEnv.Stop()
+	Expect(err).NotTo(HaveOccurred())
+})
+
+func newController(dryRun bool) *garbagecollection.Controller {
+	return garbagecollection.NewController(fakeClock, cl, recorder, collector, testClusterName, gracePeriod, dryRun)
+}
+
+// fakeCollector returns its Machines as the claimed Machines, and records the names of the deleted Machines.
+type fakeCollector struct {
+	machines  []*capiv1beta1.Machine
+	deleted   []string
+	deleteErr error
+}
+
+func (f *fakeCollector) ListClaimedMachines(_ context.Context) ([]*capiv1beta1.Machine, error) {
+	return f.machines, nil
+}
+
+func (f *fakeCollector) DeleteMachine(_ context.Context, machine *capiv1beta1.Machine) error {
+	if f.deleteErr != nil {
+		return f.deleteErr
+	}
+	f.deleted = append(f.deleted, machine.Name)
+	return nil
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package launch
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/utils/ptr"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+
+	clusterapi "sigs.k8s.io/karpenter-provider-cluster-api/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machine"
+)
+
+// pollInterval is how often a launched NodeClaim is checked while its Machine does not have a provider ID.
+const pollInterval = 5 * time.Second
+
+// Controller completes the launch of NodeClaims which were created in the asynchronous launch mode. These
+// NodeClaims are launched as soon as a Machine is bound to them, and this controller fills in the provider ID,
+// capacity and labels of the NodeClaim when the Machine has a provider ID.
+type Controller struct {
+	kubeClient      client.Client
+	recorder        events.Recorder
+	cloudProvider   cloudprovider.CloudProvider
+	machineProvider machine.Provider
+}
+
+func NewController(kubeClient client.Client, recorder events.Recorder, cloudProvider cloudprovider.CloudProvider, machineProvider machine.Provider) *Controller {
+	return &Controller{
+		kubeClient:      kubeClient,
+		recorder:        recorder,
+		cloudProvider:   cloudProvider,
+		machineProvider: machineProvider,
+	}
+}
+
+func (c *Controller) Name() string {
+	return "nodeclaim.launch"
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *karpv1.NodeClaim) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, c.Name())
+
+	if !nodeclaimutils.IsManaged(nodeClaim, c.cloudProvider) || !nodeClaim.DeletionTimestamp.IsZero() || nodeClaim.Status.ProviderID != "" {
+		return reconcile.Result{}, nil
+	}
+	// NodeClaims which are not launched yet are still handled by CloudProvider.Create.
+	if !nodeClaim.StatusConditions().Get(karpv1.ConditionTypeLaunched).IsTrue() {
+		return reconcile.Result{}, nil
+	}
+	machineAnno, ok := nodeClaim.Annotations[providers.MachineAnnotation]
+	if !ok {
+		return reconcile.Result{}, nil
+	}
+
+	machineNamespace, machineName, err := providers.ParseMachineAnnotation(machineAnno)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("unable to parse Machine annotation of NodeClaim %q: %w", nodeClaim.Name, err)
+	}
+	m, err := c.machineProvider.Get(ctx, machineName, machineNamespace)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("unable to get Machine %q of NodeClaim %q: %w", machineName, nodeClaim.Name, err)
+	}
+
+	if c.machineProvider.IsFailed(m) {
+		// the Machine will not become a Node, delete the NodeClaim so that Karpenter provisions a replacement
+		// instead of waiting for the registration timeout. The failure of the Machine is described in the same way
+		// as the create error of a NodeClaim which is not launched yet.
+		log.FromContext(ctx).Info("deleting NodeClaim with a failed Machine", "NodeClaim", nodeClaim.Name, "Machine", m.Name,
+			"failureReason", ptr.Deref(m.Status.FailureReason, ""), "failureMessage", ptr.Deref(m.Status.FailureMessage, ""))
+		c.recorder.Publish(MachineFailed(nodeClaim, clusterapi.MachineFailedError(m)))
+		return reconcile.Result{}, client.IgnoreNotFound(c.kubeClient.Delete(ctx, nodeClaim))
+	}
+
+	if m.Spec.ProviderID == nil {
+		return reconcile.Result{RequeueAfter: pollInterval}, nil
+	}
+
+	retrieved, err := c.cloudProvider.Get(ctx, *m.Spec.ProviderID)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("unable to get NodeClaim details for Machine %q: %w", m.Name, err)
+	}
+
+	stored := nodeClaim.DeepCopy()
+	// the labels of the NodeClaim take precedence, in the same way as when Karpenter launches a NodeClaim.
+	nodeClaim.Labels = lo.Assign(retrieved.Labels, nodeClaim.Labels)
+	nodeClaim.Status.ProviderID = retrieved.Status.ProviderID
+	if len(retrieved.Status.Capacity) > 0 {
+		nodeClaim.Status.Capacity = retrieved.Status.Capacity
+	}
+	if len(retrieved.Status.Allocatable) > 0 {
+		nodeClaim.Status.Allocatable = retrieved.Status.Allocatable
+	}
+
+	if !equality.Semantic.DeepEqual(stored, nodeClaim) {
+		// the metadata and status are patched separately, the status is kept as the patch of the metadata
+		// returns the stored status.
+		statusCopy := nodeClaim.DeepCopy()
+		if err := c.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(stored)); err != nil {
+			return reconcile.Result{}, client.IgnoreNotFound(err)
+		}
+		if err := c.kubeClient.Status().Patch(ctx, statusCopy, client.MergeFrom(stored)); err != nil {
+			return reconcile.Result{}, client.IgnoreNotFound(err)
+		}
+		log.FromContext(ctx).Info("set provider ID of launched NodeClaim", "NodeClaim", nodeClaim.Name, "Machine", m.Name, "provider-id", retrieved.Status.ProviderID)
+	}
+
+	return reconcile.Result{}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named(c.Name()).
+		For(&karpv1.NodeClaim{}).
+		WithEventFilter(nodeclaimutils.IsManagedPredicateFuncs(c.cloudProvider)).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 10}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
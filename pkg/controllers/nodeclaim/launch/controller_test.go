@@ -0,0 +1,138 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package launch_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/apis/v1alpha1"
+	clusterapi "sigs.k8s.io/karpenter-provider-cluster-api/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/test"
+)
+
+var _ = Describe("NodeClaim Launch Controller", func() {
+	var nodeClass *v1alpha1.ClusterAPINodeClass
+	var m *capiv1beta1.Machine
+	var nodeClaim *karpv1.NodeClaim
+
+	BeforeEach(func() {
+		recorder.Reset()
+
+		nodeClass = &v1alpha1.ClusterAPINodeClass{}
+		nodeClass.Name = "default"
+		ExpectApplied(ctx, cl, nodeClass)
+
+		machineDeployment := &capiv1beta1.MachineDeployment{}
+		machineDeployment.Name = "md-1"
+		machineDeployment.Namespace = testNamespace
+		machineDeployment.Labels = map[string]string{providers.NodePoolMemberLabel: ""}
+		machineDeployment.Annotations = map[string]string{
+			"capacity.cluster-autoscaler.kubernetes.io/cpu":    "4",
+			"capacity.cluster-autoscaler.kubernetes.io/memory": "16Gi",
+			"capacity.cluster-autoscaler.kubernetes.io/labels": corev1.LabelInstanceTypeStable + "=m5.xlarge",
+		}
+		machineDeployment.Spec.ClusterName = "test-cluster"
+		machineDeployment.Spec.Template.Spec.ClusterName = "test-cluster"
+		ExpectApplied(ctx, cl, machineDeployment)
+
+		m = &capiv1beta1.Machine{}
+		m.Name = "m-1"
+		m.Namespace = testNamespace
+		m.Labels = map[string]string{
+			providers.NodePoolMemberLabel:          "",
+			capiv1beta1.MachineDeploymentNameLabel: machineDeployment.Name,
+		}
+		m.Spec.ClusterName = "test-cluster"
+		ExpectApplied(ctx, cl, m)
+
+		nodeClaim = &karpv1.NodeClaim{}
+		nodeClaim.Name = "nodeclaim-1"
+		nodeClaim.Labels = map[string]string{karpv1.NodePoolLabelKey: "default"}
+		nodeClaim.Annotations = map[string]string{providers.MachineAnnotation: testNamespace + "/" + m.Name}
+		nodeClaim.Spec.NodeClassRef = &karpv1.NodeClassReference{Group: v1alpha1.Group, Kind: "ClusterAPINodeClass", Name: nodeClass.Name}
+		nodeClaim.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{}
+		nodeClaim.StatusConditions().SetTrue(karpv1.ConditionTypeLaunched)
+	})
+
+	AfterEach(func() {
+		test.EventuallyDeleteAllOf(cl, &karpv1.NodeClaim{}, &karpv1.NodeClaimList{}, "")
+		test.EventuallyDeleteAllOf(cl, &capiv1beta1.Machine{}, &capiv1beta1.MachineList{}, testNamespace)
+		test.EventuallyDeleteAllOf(cl, &capiv1beta1.MachineDeployment{}, &capiv1beta1.MachineDeploymentList{}, testNamespace)
+		test.EventuallyDeleteAllOf(cl, &v1alpha1.ClusterAPINodeClass{}, &v1alpha1.ClusterAPINodeClassList{}, "")
+	})
+
+	It("requeues a launched NodeClaim while its Machine does not have a provider ID", func() {
+		ExpectApplied(ctx, cl, nodeClaim)
+
+		result := ExpectObjectReconciled(ctx, cl, controller, nodeClaim)
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+		Expect(ExpectExists(ctx, cl, nodeClaim).Status.ProviderID).To(BeEmpty())
+	})
+
+	It("sets the provider ID, capacity and labels when the Machine has a provider ID", func() {
+		ExpectApplied(ctx, cl, nodeClaim)
+		m.Spec.ProviderID = ptr.To("clusterapi://m-1")
+		ExpectApplied(ctx, cl, m)
+
+		result := ExpectObjectReconciled(ctx, cl, controller, nodeClaim)
+		Expect(result.RequeueAfter).To(BeZero())
+
+		nodeClaim = ExpectExists(ctx, cl, nodeClaim)
+		Expect(nodeClaim.Status.ProviderID).To(Equal("clusterapi://m-1"))
+		Expect(nodeClaim.Status.Capacity.Cpu().String()).To(Equal("4"))
+		Expect(nodeClaim.Labels).To(HaveKeyWithValue(corev1.LabelInstanceTypeStable, "m5.xlarge"))
+		Expect(nodeClaim.Labels).To(HaveKeyWithValue(karpv1.NodePoolLabelKey, "default"))
+	})
+
+	It("deletes the NodeClaim when its Machine has failed", func() {
+		ExpectApplied(ctx, cl, nodeClaim)
+		m.Status.FailureMessage = ptr.To("quota exceeded")
+		ExpectApplied(ctx, cl, m)
+
+		ExpectObjectReconciled(ctx, cl, controller, nodeClaim)
+		ExpectNotFound(ctx, cl, nodeClaim)
+	})
+
+	It("publishes the failure of the Machine in an event when the NodeClaim is deleted", func() {
+		ExpectApplied(ctx, cl, nodeClaim)
+		m.Status.FailureMessage = ptr.To("quota exceeded")
+		ExpectApplied(ctx, cl, m)
+
+		ExpectObjectReconciled(ctx, cl, controller, nodeClaim)
+		ExpectNotFound(ctx, cl, nodeClaim)
+		Expect(recorder.Calls(clusterapi.MachineFailedReason)).To(Equal(1))
+		Expect(recorder.DetectedEvent(clusterapi.MachineFailedError(m).ConditionMessage)).To(BeTrue())
+	})
+
+	It("ignores NodeClaims which are not launched", func() {
+		nodeClaim.StatusConditions().SetUnknown(karpv1.ConditionTypeLaunched)
+		ExpectApplied(ctx, cl, nodeClaim)
+		m.Spec.ProviderID = ptr.To("clusterapi://m-1")
+		ExpectApplied(ctx, cl, m)
+
+		result := ExpectObjectReconciled(ctx, cl, controller, nodeClaim)
+		Expect(result.RequeueAfter).To(BeZero())
+		Expect(ExpectExists(ctx, cl, nodeClaim).Status.ProviderID).To(BeEmpty())
+	})
+})
@@ -0,0 +1,234 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancetype
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	expv1beta1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/scalableresource"
+)
+
+// infrastructureGroup is the API group of the InfrastructureMachineTemplates which are watched.
+const infrastructureGroup = "infrastructure.cluster.x-k8s.io"
+
+// Invalidator removes the cached instance types of a NodeClass, the CloudProvider implements it.
+type Invalidator interface {
+	InvalidateInstanceTypes(nodeClassName string)
+}
+
+// Controller invalidates the cached instance types of a NodeClass when the NodeClass, or any resource in the
+// management cluster that the instance types are built from, changes, so that they are rebuilt on the next request.
+// The watched resources are the scalable resources, the InfrastructureMachineTemplates and the Clusters.
+type Controller struct {
+	kubeClient        client.Client
+	managementCluster cluster.Cluster
+	invalidator       Invalidator
+}
+
+func NewController(kubeClient client.Client, managementCluster cluster.Cluster, invalidator Invalidator) *Controller {
+	return &Controller{
+		kubeClient:        kubeClient,
+		managementCluster: managementCluster,
+		invalidator:       invalidator,
+	}
+}
+
+func (c *Controller) Name() string {
+	return "nodeclass.instancetype"
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClass *v1alpha1.ClusterAPINodeClass) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, c.Name())
+
+	c.invalidator.InvalidateInstanceTypes(nodeClass.Name)
+	log.FromContext(ctx).V(1).Info("invalidated instance types", "NodeClass", nodeClass.Name)
+
+	return reconcile.Result{}, nil
+}
+
+func (c *Controller) Register(ctx context.Context, m manager.Manager) error {
+	b := controllerruntime.NewControllerManagedBy(m).
+		Named(c.Name()).
+		For(&v1alpha1.ClusterAPINodeClass{})
+
+	// the NodeClasses which select a resource are not known, so a change to any of them invalidates the instance
+	// types of every NodeClass. Status updates of the scalable resources do not change the instance types and are
+	// ignored.
+	scalableResources := []client.Object{&capiv1beta1.MachineDeployment{}, &capiv1beta1.MachineSet{}}
+	// MachinePools and the additional scalable resource types are optional, they are only watched when their
+	// CustomResourceDefinition is installed.
+	machinePoolKind := expv1beta1.GroupVersion.WithKind(providers.MachinePoolKind)
+	for _, gvk := range append([]schema.GroupVersionKind{machinePoolKind}, scalableresource.ScaleKindsFromOptions(ctx)...) {
+		installed, err := c.isInstalled(gvk)
+		if err != nil {
+			return err
+		}
+		if !installed {
+			continue
+		}
+		if gvk == machinePoolKind {
+			scalableResources = append(scalableResources, &expv1beta1.MachinePool{})
+		} else {
+			scalableResources = append(scalableResources, newUnstructured(gvk))
+		}
+	}
+	for _, obj := range scalableResources {
+		b = b.WatchesRawSource(source.Kind[client.Object](
+			c.managementCluster.GetCache(),
+			obj,
+			handler.EnqueueRequestsFromMapFunc(c.requestsForAllNodeClasses),
+			predicate.Or[client.Object](
+				predicate.GenerationChangedPredicate{},
+				predicate.LabelChangedPredicate{},
+				predicate.AnnotationChangedPredicate{},
+			),
+		))
+	}
+
+	// the capacity of an InfrastructureMachineTemplate is in its status, so all of its changes are watched. Only
+	// the metadata of the templates is cached, the resource version changes with every update of the template.
+	templateKinds, err := c.infrastructureMachineTemplateKinds()
+	if err != nil {
+		return err
+	}
+	for _, gvk := range templateKinds {
+		b = b.WatchesRawSource(source.Kind[client.Object](
+			c.managementCluster.GetCache(),
+			newPartialObjectMetadata(gvk),
+			handler.EnqueueRequestsFromMapFunc(c.requestsForAllNodeClasses),
+		))
+	}
+
+	// the failure domains of a Cluster are the zones of the instance types of machine templates.
+	b = b.WatchesRawSource(source.Kind[client.Object](
+		c.managementCluster.GetCache(),
+		&capiv1beta1.Cluster{},
+		handler.EnqueueRequestsFromMapFunc(c.requestsForAllNodeClasses),
+		predicate.Funcs{UpdateFunc: failureDomainsChanged},
+	))
+
+	return b.WithOptions(controller.Options{MaxConcurrentReconciles: 10}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
+
+// isInstalled returns true when the kind is served by the management cluster.
+func (c *Controller) isInstalled(gvk schema.GroupVersionKind) (bool, error) {
+	if _, err := c.managementCluster.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+		if meta.IsNoMatchError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("unable to get the mapping of %s: %w", gvk, err)
+	}
+	return true, nil
+}
+
+// infrastructureMachineTemplateKinds returns the kinds in the preferred version of the infrastructure group whose
+// name ends with MachineTemplate. Kinds which are installed after Karpenter started are not watched, the cached
+// instance types still expire after five minutes.
+func (c *Controller) infrastructureMachineTemplateKinds() ([]schema.GroupVersionKind, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(c.managementCluster.GetConfig())
+	if err != nil {
+		return nil, fmt.Errorf("unable to create discovery client: %w", err)
+	}
+	groups, err := discoveryClient.ServerGroups()
+	if err != nil {
+		return nil, fmt.Errorf("unable to discover API groups: %w", err)
+	}
+
+	kinds := []schema.GroupVersionKind{}
+	for _, group := range groups.Groups {
+		if group.Name != infrastructureGroup {
+			continue
+		}
+		resources, err := discoveryClient.ServerResourcesForGroupVersion(group.PreferredVersion.GroupVersion)
+		if err != nil {
+			return nil, fmt.Errorf("unable to discover the resources of %s: %w", group.PreferredVersion.GroupVersion, err)
+		}
+		for _, r := range resources.APIResources {
+			// subresources, such as the status, are skipped.
+			if strings.Contains(r.Name, "/") || !strings.HasSuffix(r.Kind, "MachineTemplate") {
+				continue
+			}
+			kinds = append(kinds, schema.FromAPIVersionAndKind(group.PreferredVersion.GroupVersion, r.Kind))
+		}
+	}
+	return kinds, nil
+}
+
+// failureDomainsChanged returns true when the failure domains in the status of a Cluster changed.
+func failureDomainsChanged(e event.UpdateEvent) bool {
+	oldCluster, ok := e.ObjectOld.(*capiv1beta1.Cluster)
+	if !ok {
+		return false
+	}
+	newCluster, ok := e.ObjectNew.(*capiv1beta1.Cluster)
+	if !ok {
+		return false
+	}
+	return !equality.Semantic.DeepEqual(oldCluster.Status.FailureDomains, newCluster.Status.FailureDomains)
+}
+
+func newUnstructured(gvk schema.GroupVersionKind) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	return obj
+}
+
+func newPartialObjectMetadata(gvk schema.GroupVersionKind) *metav1.PartialObjectMetadata {
+	obj := &metav1.PartialObjectMetadata{}
+	obj.SetGroupVersionKind(gvk)
+	return obj
+}
+
+// requestsForAllNodeClasses returns a request for every ClusterAPINodeClass.
+func (c *Controller) requestsForAllNodeClasses(ctx context.Context, _ client.Object) []reconcile.Request {
+	nodeClassList := &v1alpha1.ClusterAPINodeClassList{}
+	if err := c.kubeClient.List(ctx, nodeClassList); err != nil {
+		log.FromContext(ctx).Error(err, "unable to list NodeClasses to invalidate instance types")
+		return nil
+	}
+
+	return lo.Map(nodeClassList.Items, func(nodeClass v1alpha1.ClusterAPINodeClass, _ int) reconcile.Request {
+		return reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&nodeClass)}
+	})
+}
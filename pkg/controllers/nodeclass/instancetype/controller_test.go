@@ -0,0 +1,140 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancetype_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	expv1beta1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
+
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("NodeClass InstanceType Controller", func() {
+	BeforeEach(func() {
+		invalidator.Reset()
+	})
+
+	AfterEach(func() {
+		test.EventuallyDeleteAllOf(cl, &v1alpha1.ClusterAPINodeClass{}, &v1alpha1.ClusterAPINodeClassList{}, testNamespace)
+	})
+
+	It("invalidates the instance types of a reconciled NodeClass", func() {
+		nodeClass := &v1alpha1.ClusterAPINodeClass{}
+		nodeClass.Name = "default"
+		ExpectApplied(ctx, cl, nodeClass)
+		ExpectObjectReconciled(ctx, cl, controller, nodeClass)
+
+		Expect(invalidator.Invalidated()).To(ConsistOf("default"))
+	})
+})
+
+var _ = Describe("NodeClass InstanceType Controller watches", func() {
+	BeforeEach(func() {
+		nodeClass := &v1alpha1.ClusterAPINodeClass{}
+		nodeClass.Name = "watched"
+		ExpectApplied(ctx, cl, nodeClass)
+		Eventually(watchInvalidator.Invalidated).Should(ContainElement("watched"))
+		watchInvalidator.Reset()
+	})
+
+	AfterEach(func() {
+		test.EventuallyDeleteAllOf(cl, &capiv1beta1.MachineSet{}, &capiv1beta1.MachineSetList{}, testNamespace)
+		test.EventuallyDeleteAllOf(cl, &expv1beta1.MachinePool{}, &expv1beta1.MachinePoolList{}, testNamespace)
+		test.EventuallyDeleteAllOf(cl, &capiv1beta1.Cluster{}, &capiv1beta1.ClusterList{}, testNamespace)
+		test.EventuallyDeleteAllOf(cl, newUnstructured(testTemplateVersion, testTemplateGroup, testTemplateKind), newUnstructuredList(testTemplateVersion, testTemplateGroup, testTemplateKind), testNamespace)
+		test.EventuallyDeleteAllOf(cl, newUnstructured(testScaleVersion, testScaleGroup, testScaleKind), newUnstructuredList(testScaleVersion, testScaleGroup, testScaleKind), testNamespace)
+		test.EventuallyDeleteAllOf(cl, &v1alpha1.ClusterAPINodeClass{}, &v1alpha1.ClusterAPINodeClassList{}, testNamespace)
+	})
+
+	It("invalidates the instance types when a MachineSet changes", func() {
+		machineSet := &capiv1beta1.MachineSet{}
+		machineSet.SetName("ms-0")
+		machineSet.SetNamespace(testNamespace)
+		machineSet.Spec.ClusterName = "test-cluster"
+		machineSet.Spec.Selector.MatchLabels = map[string]string{capiv1beta1.MachineSetNameLabel: "ms-0"}
+		machineSet.Spec.Template.Labels = map[string]string{capiv1beta1.MachineSetNameLabel: "ms-0"}
+		machineSet.Spec.Template.Spec.ClusterName = "test-cluster"
+		ExpectApplied(ctx, cl, machineSet)
+
+		Eventually(watchInvalidator.Invalidated).Should(ContainElement("watched"))
+	})
+
+	It("invalidates the instance types when a MachinePool changes", func() {
+		machinePool := &expv1beta1.MachinePool{}
+		machinePool.SetName("mp-0")
+		machinePool.SetNamespace(testNamespace)
+		machinePool.Spec.ClusterName = "test-cluster"
+		machinePool.Spec.Template.Spec.ClusterName = "test-cluster"
+		ExpectApplied(ctx, cl, machinePool)
+
+		Eventually(watchInvalidator.Invalidated).Should(ContainElement("watched"))
+	})
+
+	It("invalidates the instance types when a resource of an additional scalable resource type changes", func() {
+		nodeGroup := newUnstructured(testScaleVersion, testScaleGroup, testScaleKind)
+		nodeGroup.SetName("ng-0")
+		nodeGroup.SetNamespace(testNamespace)
+		Expect(cl.Create(ctx, nodeGroup)).To(Succeed())
+
+		Eventually(watchInvalidator.Invalidated).Should(ContainElement("watched"))
+	})
+
+	It("invalidates the instance types when an InfrastructureMachineTemplate changes", func() {
+		template := newUnstructured(testTemplateVersion, testTemplateGroup, testTemplateKind)
+		template.SetName("template-0")
+		template.SetNamespace(testNamespace)
+		Expect(cl.Create(ctx, template)).To(Succeed())
+		Eventually(watchInvalidator.Invalidated).Should(ContainElement("watched"))
+		watchInvalidator.Reset()
+
+		Expect(unstructured.SetNestedField(template.Object, "4", "status", "capacity", "cpu")).To(Succeed())
+		Expect(cl.Update(ctx, template)).To(Succeed())
+		Eventually(watchInvalidator.Invalidated).Should(ContainElement("watched"))
+	})
+
+	It("invalidates the instance types when the failure domains of a Cluster change", func() {
+		cluster := &capiv1beta1.Cluster{}
+		cluster.SetName("test-cluster")
+		cluster.SetNamespace(testNamespace)
+		ExpectApplied(ctx, cl, cluster)
+		Eventually(watchInvalidator.Invalidated).Should(ContainElement("watched"))
+		watchInvalidator.Reset()
+
+		cluster.Status.FailureDomains = capiv1beta1.FailureDomains{"zone-a": capiv1beta1.FailureDomainSpec{ControlPlane: false}}
+		Expect(cl.Status().Update(ctx, cluster)).To(Succeed())
+		Eventually(watchInvalidator.Invalidated).Should(ContainElement("watched"))
+	})
+})
+
+func newUnstructured(version, group, kind string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(group + "/" + version)
+	obj.SetKind(kind)
+	return obj
+}
+
+func newUnstructuredList(version, group, kind string) *unstructured.UnstructuredList {
+	list := &unstructured.UnstructuredList{}
+	list.SetAPIVersion(group + "/" + version)
+	list.SetKind(kind + "List")
+	return list
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancetype_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2/textlogger"
+	"k8s.io/utils/ptr"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	expv1beta1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/controllers/nodeclass/instancetype"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/operator/options"
+)
+
+const (
+	testNamespace = "karpenter-cluster-api"
+
+	testTemplateGroup   = "infrastructure.cluster.x-k8s.io"
+	testTemplateVersion = "v1beta1"
+	testTemplateKind    = "TestMachineTemplate"
+
+	testScaleGroup   = "example.com"
+	testScaleVersion = "v1"
+	testScaleKind    = "NodeGroup"
+)
+
+var ctx context.Context
+var cfg *rest.Config
+var cl client.Client
+var testEnv *envtest.Environment
+var testScheme *runtime.Scheme
+var controller *instancetype.Controller
+var invalidator *fakeInvalidator
+
+// watchInvalidator is used by the controller which runs in the manager, so that its watches are tested.
+var watchInvalidator *fakeInvalidator
+var cancelManager context.CancelFunc
+
+func TestInstanceTypeController(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "NodeClass.InstanceType Suite")
+}
+
+var _ = BeforeSuite(func() {
+	var err error
+	logf.SetLogger(textlogger.NewLogger(textlogger.NewConfig()))
+
+	By("bootstrapping test environment")
+	testEnv = &envtest.Environment{
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "..", "..", "..", "vendor", "sigs.k8s.io", "cluster-api", "api", "v1beta1"),
+			filepath.Join("..", "..", "..", "..", "vendor", "sigs.k8s.io", "cluster-api", "exp", "api", "v1beta1"),
+			filepath.Join("..", "..", "..", "apis", "crds"),
+		},
+		CRDs: []*apiextensionsv1.CustomResourceDefinition{
+			newTestCRD(testTemplateGroup, testTemplateVersion, testTemplateKind, "testmachinetemplates"),
+			newTestCRD(testScaleGroup, testScaleVersion, testScaleKind, "nodegroups"),
+		},
+		ErrorIfCRDPathMissing: true,
+	}
+
+	ctx = context.Background()
+
+	cfg, err = testEnv.Start()
+	Expect(err).NotTo(HaveOccurred())
+	Expect(cfg).NotTo(BeNil())
+
+	testScheme = scheme.Scheme
+	Expect(capiv1beta1.AddToScheme(testScheme)).To(Succeed())
+	Expect(expv1beta1.AddToScheme(testScheme)).To(Succeed())
+	Expect(v1alpha1.AddToScheme(testScheme)).To(Succeed())
+
+	cl, err = client.New(cfg, client.Options{Scheme: testScheme})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(cl).NotTo(BeNil())
+
+	namespace := &corev1.Namespace{}
+	namespace.SetName(testNamespace)
+	Expect(cl.Create(context.Background(), namespace)).To(Succeed())
+
+	invalidator = &fakeInvalidator{}
+	controller = instancetype.NewController(cl, nil, invalidator)
+
+	mgr, err := manager.New(cfg, manager.Options{Scheme: testScheme, Metrics: metricsserver.Options{BindAddress: "0"}})
+	Expect(err).NotTo(HaveOccurred())
+	watchInvalidator = &fakeInvalidator{}
+	opts := &options.Options{ScalableResourceTypes: testScaleKind + "." + testScaleVersion + "." + testScaleGroup}
+	Expect(instancetype.NewController(mgr.GetClient(), mgr, watchInvalidator).Register(opts.ToContext(ctx), mgr)).To(Succeed())
+
+	var managerCtx context.Context
+	managerCtx, cancelManager = context.WithCancel(ctx)
+	go func() {
+		defer GinkgoRecover()
+		Expect(mgr.Start(managerCtx)).To(Succeed())
+	}()
+})
+
+var _ = AfterSuite(func() {
+	By("tearing down the test environment")
+	cancelManager()
+	err := testEnv.Stop()
+	Expect(err).NotTo(HaveOccurred())
+})
+
+// fakeInvalidator records the names of the NodeClasses whose instance types were invalidated.
+type fakeInvalidator struct {
+	mu          sync.Mutex
+	invalidated []string
+}
+
+func (f *fakeInvalidator) InvalidateInstanceTypes(nodeClassName string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.invalidated = append(f.invalidated, nodeClassName)
+}
+
+func (f *fakeInvalidator) Invalidated() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string{}, f.invalidated...)
+}
+
+func (f *fakeInvalidator) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.invalidated = nil
+}
+
+// newTestCRD returns a CustomResourceDefinition for a schemaless kind, such as an infrastructure machine template or
+// a scalable resource outside of Cluster API.
+func newTestCRD(group, version, kind, plural string) *apiextensionsv1.CustomResourceDefinition {
+	return &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: plural + "." + group,
+		},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: group,
+			Names: apiextensionsv1.CustomResourceDefinitionNames{
+				Kind:     kind,
+				ListKind: kind + "List",
+				Plural:   plural,
+				Singular: strings.TrimSuffix(plural, "s"),
+			},
+			Scope: apiextensionsv1.NamespaceScoped,
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{
+					Name:    version,
+					Served:  true,
+					Storage: true,
+					Schema: &apiextensionsv1.CustomResourceValidation{
+						OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+							Type:                   "object",
+							XPreserveUnknownFields: ptr.To(true),
+						},
+					},
+				},
+			},
+		},
+	}
+}
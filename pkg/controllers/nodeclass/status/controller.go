@@ -32,13 +32,21 @@ import (
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
 )
 
+// ClusterNotSpecifiedReason is the reason of the ready condition of a NodeClass which is not scoped to a workload
+// Cluster.
+const ClusterNotSpecifiedReason = "ClusterNotSpecified"
+
 type Controller struct {
-	kubeClient client.Client
+	kubeClient  client.Client
+	clusterName string
 }
 
-func NewController(kubeClient client.Client) *Controller {
+// NewController returns the NodeClass status controller, the cluster name is the workload Cluster from the
+// controller options which is used for NodeClasses without a clusterRef.
+func NewController(kubeClient client.Client, clusterName string) *Controller {
 	return &Controller{
-		kubeClient: kubeClient,
+		kubeClient:  kubeClient,
+		clusterName: clusterName,
 	}
 }
 
@@ -50,11 +58,13 @@ func (c *Controller) Reconcile(ctx context.Context, nodeClass *v1alpha1.ClusterA
 	ctx = injection.WithControllerName(ctx, c.Name())
 	stored := nodeClass.DeepCopy()
 
-	// for now, we just want to set all nodeclasses to ready. in the future we may want
-	// to add other types of checks to ensure that the underlying cluster-api objects are
-	// also ready.
-	ready := nodeClass.StatusConditions().Get(status.ConditionReady)
-	if ready.IsUnknown() || ready.IsFalse() {
+	// a NodeClass which is not scoped to a workload Cluster could select the scalable resources of every Cluster in
+	// the management cluster, so it is not ready until it has a clusterRef or the cluster name option is set. in the
+	// future we may want to add other types of checks to ensure that the underlying cluster-api objects are also
+	// ready.
+	if nodeClass.Spec.ClusterRef == nil && nodeClass.Spec.MachineTemplates == nil && c.clusterName == "" {
+		nodeClass.StatusConditions().SetFalse(status.ConditionReady, ClusterNotSpecifiedReason, "NodeClass does not have a clusterRef and the --cluster-name option is not set")
+	} else if ready := nodeClass.StatusConditions().Get(status.ConditionReady); ready.IsUnknown() || ready.IsFalse() {
 		nodeClass.StatusConditions().SetTrue(status.ConditionReady)
 	}
 
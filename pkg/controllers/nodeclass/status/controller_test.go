@@ -22,6 +22,7 @@ import (
 
 	awsstatus "github.com/awslabs/operatorpkg/status"
 	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/controllers/nodeclass/status"
 	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/test"
 	. "sigs.k8s.io/karpenter/pkg/test/expectations"
 )
@@ -40,4 +41,27 @@ var _ = Describe("NodeClass Status Controller", func() {
 
 		Expect(nodeClass.StatusConditions().IsTrue(awsstatus.ConditionReady)).To(BeTrue())
 	})
+
+	It("sets the ready condition to false when the NodeClass is not scoped to a Cluster", func() {
+		nodeClass := &v1alpha1.ClusterAPINodeClass{}
+		nodeClass.Name = "default"
+		ExpectApplied(ctx, cl, nodeClass)
+		ExpectObjectReconciled(ctx, cl, status.NewController(cl, ""), nodeClass)
+		nodeClass = ExpectExists(ctx, cl, nodeClass)
+
+		ready := nodeClass.StatusConditions().Get(awsstatus.ConditionReady)
+		Expect(ready.IsFalse()).To(BeTrue())
+		Expect(ready.Reason).To(Equal(status.ClusterNotSpecifiedReason))
+	})
+
+	It("adds the ready condition to a NodeClass with a clusterRef", func() {
+		nodeClass := &v1alpha1.ClusterAPINodeClass{}
+		nodeClass.Name = "default"
+		nodeClass.Spec.ClusterRef = &v1alpha1.ClusterReference{Name: testClusterName}
+		ExpectApplied(ctx, cl, nodeClass)
+		ExpectObjectReconciled(ctx, cl, status.NewController(cl, ""), nodeClass)
+		nodeClass = ExpectExists(ctx, cl, nodeClass)
+
+		Expect(nodeClass.StatusConditions().IsTrue(awsstatus.ConditionReady)).To(BeTrue())
+	})
 })
@@ -37,7 +37,8 @@ import (
 )
 
 const (
-	testNamespace = "karpenter-cluster-api"
+	testNamespace   = "karpenter-cluster-api"
+	testClusterName = "test-cluster"
 )
 
 var ctx context.Context
@@ -83,7 +84,7 @@ var _ = BeforeSuite(func() {
 	namespace.SetName(testNamespace)
 	Expect(cl.Create(context.Background(), namespace)).To(Succeed())
 
-	controller = status.NewController(cl)
+	controller = status.NewController(cl, testClusterName)
 })
 
 var _ = AfterSuite(func() {
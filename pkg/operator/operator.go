@@ -25,7 +25,6 @@ import (
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/cluster"
 	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/apis"
 	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/apis/v1alpha1"
@@ -33,6 +32,9 @@ import (
 	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/operator/options"
 	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machine"
 	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machinedeployment"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machinepool"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machinetemplate"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/scalableresource"
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/operator"
 )
@@ -44,6 +46,10 @@ func init() {
 		clusterapi.InstanceFamilyLabelKey,
 		clusterapi.InstanceCPULabelKey,
 		clusterapi.InstanceMemoryLabelKey,
+		clusterapi.InstanceGPUCountLabelKey,
+		clusterapi.InstanceGPUManufacturerLabelKey,
+		clusterapi.InstanceGPUModelLabelKey,
+		clusterapi.InstanceGPUMIGCountLabelKey,
 	)
 	lo.Must0(apis.AddToScheme(scheme.Scheme))
 }
@@ -51,27 +57,42 @@ func init() {
 type Operator struct {
 	*operator.Operator
 
+	// ManagementCluster is the cluster with the Cluster API resources, it is the operator itself when a separate
+	// management cluster is not configured.
+	ManagementCluster cluster.Cluster
+
 	MachineProvider           machine.Provider
 	MachineDeploymentProvider machinedeployment.Provider
+	MachinePoolProvider       machinepool.Provider
+	MachineTemplateProvider   machinetemplate.Provider
+	ScalableResourceProvider  scalableresource.Provider
 }
 
 func NewOperator(ctx context.Context, operator *operator.Operator) (context.Context, *Operator) {
-	mgmtCluster, err := buildManagementClusterKubeClient(ctx, operator)
+	mgmtCluster, err := buildManagementCluster(ctx, operator)
 	if err != nil {
 		log.Fatalf("unable to build management cluster client: %v", err)
 	}
+	mgmtClient := mgmtCluster.GetClient()
 
-	machineProvider := machine.NewDefaultProvider(ctx, mgmtCluster)
-	machineDeploymentProvider := machinedeployment.NewDefaultProvider(ctx, mgmtCluster)
+	machineProvider := machine.NewIndexedProvider(ctx, mgmtClient)
+	machineDeploymentProvider := machinedeployment.NewDefaultProvider(ctx, mgmtClient)
+	machinePoolProvider := machinepool.NewDefaultProvider(ctx, mgmtClient)
+	machineTemplateProvider := machinetemplate.NewDefaultProvider(ctx, mgmtClient)
+	scalableResourceProvider := scalableresource.NewDefaultProvider(ctx, mgmtClient, machineProvider, machineDeploymentProvider, machinePoolProvider)
 
 	return ctx, &Operator{
 		Operator:                  operator,
+		ManagementCluster:         mgmtCluster,
 		MachineProvider:           machineProvider,
 		MachineDeploymentProvider: machineDeploymentProvider,
+		MachinePoolProvider:       machinePoolProvider,
+		MachineTemplateProvider:   machineTemplateProvider,
+		ScalableResourceProvider:  scalableResourceProvider,
 	}
 }
 
-func buildManagementClusterKubeClient(ctx context.Context, operator *operator.Operator) (client.Client, error) {
+func buildManagementCluster(ctx context.Context, operator *operator.Operator) (cluster.Cluster, error) {
 	clusterAPIKubeConfig, err := buildClusterCAPIKubeConfig(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("unable to build cluster API kube config: %w", err)
@@ -84,12 +105,18 @@ func buildManagementClusterKubeClient(ctx context.Context, operator *operator.Op
 		if err != nil {
 			return nil, fmt.Errorf("unable to create new kube config for management cluster: %w", err)
 		}
+		if err = machine.IndexFields(ctx, mgmtCluster.GetFieldIndexer()); err != nil {
+			return nil, fmt.Errorf("unable to index Machines in management cluster: %w", err)
+		}
 		if err = operator.Add(mgmtCluster); err != nil {
 			return nil, fmt.Errorf("unable to add management cluster to operator: %w", err)
 		}
-		return mgmtCluster.GetClient(), nil
+		return mgmtCluster, nil
+	}
+	if err := machine.IndexFields(ctx, operator.GetFieldIndexer()); err != nil {
+		return nil, fmt.Errorf("unable to index Machines: %w", err)
 	}
-	return operator.GetClient(), nil
+	return operator.Manager, nil
 }
 
 func buildClusterCAPIKubeConfig(ctx context.Context) (*rest.Config, error) {
@@ -22,11 +22,23 @@ import (
 	"flag"
 	"fmt"
 	"os"
-
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	karpoptions "sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/utils/env"
+
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/apis/v1alpha1"
 )
 
+// DefaultInstanceTypeNamePattern matches instance type names in the form "family.size", for example "m5.xlarge".
+const DefaultInstanceTypeNamePattern = `^(?P<family>[^.]+)\.(?P<size>[^.]+)$`
+
 func init() {
 	karpoptions.Injectables = append(karpoptions.Injectables, &Options{})
 }
@@ -39,6 +51,16 @@ type Options struct {
 	ClusterAPIToken                    string
 	ClusterAPICertificateAuthorityData string
 	ClusterAPISkipTlsVerify            bool
+	RepairPolicies                     string
+	DisableDefaultRepairPolicies       bool
+	InstanceTypeSelectionStrategy      string
+	ScalableResourceTypes              string
+	DisableMinSizeCheck                bool
+	AsyncLaunch                        bool
+	InstanceTypeNamePattern            string
+	ClusterName                        string
+	OrphanedMachineGracePeriod         time.Duration
+	OrphanedMachineDryRun              bool
 }
 
 func (o *Options) AddFlags(fs *karpoptions.FlagSet) {
@@ -47,6 +69,16 @@ func (o *Options) AddFlags(fs *karpoptions.FlagSet) {
 	fs.StringVar(&o.ClusterAPIToken, "cluster-api-token", env.WithDefaultString("CLUSTER_API_TOKEN", ""), "The Bearer token for authentication of the cluster api manager cluster")
 	fs.StringVar(&o.ClusterAPICertificateAuthorityData, "cluster-api-certificate-authority-data", env.WithDefaultString("CLUSTER_API_CERTIFICATE_AUTHORITY_DATA", ""), "The cert certificate authority of the cluster api manager cluster")
 	fs.BoolVarWithEnv(&o.ClusterAPISkipTlsVerify, "cluster-api-skip-tls-verify", "CLUSTER_API_SKIP_TLS_VERIFY", false, "Skip the check for certificate for validity of the cluster api manager cluster. This will make HTTPS connections insecure")
+	fs.StringVar(&o.RepairPolicies, "repair-policies", env.WithDefaultString("REPAIR_POLICIES", ""), "Optional comma separated list of node repair policies in the form 'ConditionType=ConditionStatus:TolerationDuration', for example 'Ready=False:15m'. These extend the default repair policies, or override the toleration duration of a default policy with the same condition type and status. Node repair also requires the NodeRepair feature gate.")
+	fs.BoolVarWithEnv(&o.DisableDefaultRepairPolicies, "disable-default-repair-policies", "DISABLE_DEFAULT_REPAIR_POLICIES", false, "Disable the default node repair policies, only the policies from --repair-policies will be used")
+	fs.StringVar(&o.InstanceTypeSelectionStrategy, "instance-type-selection-strategy", env.WithDefaultString("INSTANCE_TYPE_SELECTION_STRATEGY", string(v1alpha1.InstanceTypeSelectionStrategyCheapest)), "The strategy for choosing between compatible instance types when a ClusterAPINodeClass does not specify one. Can be one of 'Cheapest', 'LeastWaste', 'Weighted' or 'Random'")
+	fs.StringVar(&o.ScalableResourceTypes, "scalable-resource-types", env.WithDefaultString("SCALABLE_RESOURCE_TYPES", ""), "Optional comma separated list of additional scalable resource types in the form 'Kind.version.group', for example 'NodeGroup.v1alpha1.example.com'. Resources of these types must have the scale subresource with a label selector for their Machines, and the ClusterRole of the controller needs rules for the resources and their scale subresource, for example through the additionalClusterRoleRules value of the Helm chart.")
+	fs.BoolVarWithEnv(&o.DisableMinSizeCheck, "disable-min-size-check", "DISABLE_MIN_SIZE_CHECK", false, "Allow Karpenter to delete Machines from a scalable resource below the minimum size in its cluster-autoscaler min size annotation")
+	fs.BoolVarWithEnv(&o.AsyncLaunch, "async-launch", "ASYNC_LAUNCH", false, "Return from NodeClaim creation as soon as a Machine is bound to the NodeClaim, instead of waiting for the Machine to have a provider ID. The provider ID, capacity and labels of the NodeClaim are filled in by a controller when the Machine has a provider ID")
+	fs.StringVar(&o.InstanceTypeNamePattern, "instance-type-name-pattern", env.WithDefaultString("INSTANCE_TYPE_NAME_PATTERN", DefaultInstanceTypeNamePattern), "A regular expression which is matched against instance type names to set the instance family and size labels from its named groups 'family' and 'size'. Instance types whose name does not match do not get these labels, an empty pattern disables them")
+	fs.StringVar(&o.ClusterName, "cluster-name", env.WithDefaultString("CLUSTER_NAME", ""), "The name of the workload Cluster in the management cluster. When set, only the Machines with its cluster.x-k8s.io/cluster-name label are reported as NodeClaims, and it is the Cluster of the ClusterAPINodeClasses which do not have a clusterRef. ClusterAPINodeClasses without a clusterRef or machineTemplates are not ready when it is not set")
+	fs.DurationVar(&o.OrphanedMachineGracePeriod, "orphaned-machine-grace-period", env.WithDefaultDuration("ORPHANED_MACHINE_GRACE_PERIOD", 10*time.Minute), "How long a Machine claimed by Karpenter can be without a NodeClaim before it is deleted by the orphaned Machine garbage collection, which only runs when the cluster name is set")
+	fs.BoolVarWithEnv(&o.OrphanedMachineDryRun, "orphaned-machine-dry-run", "ORPHANED_MACHINE_DRY_RUN", false, "Only publish events and log the orphaned Machines that would be deleted, instead of deleting them")
 }
 
 func (o *Options) Parse(fs *karpoptions.FlagSet, args ...string) error {
@@ -65,6 +97,21 @@ func (o *Options) Parse(fs *karpoptions.FlagSet, args ...string) error {
 }
 
 func (o *Options) Validate() error {
+	if _, err := ParseRepairPolicies(o.RepairPolicies); err != nil {
+		return fmt.Errorf("invalid repair policies, %w", err)
+	}
+	if !lo.Contains(v1alpha1.InstanceTypeSelectionStrategies, v1alpha1.InstanceTypeSelectionStrategy(o.InstanceTypeSelectionStrategy)) {
+		return fmt.Errorf("unknown instance type selection strategy %q", o.InstanceTypeSelectionStrategy)
+	}
+	if _, err := ParseScalableResourceTypes(o.ScalableResourceTypes); err != nil {
+		return fmt.Errorf("invalid scalable resource types, %w", err)
+	}
+	if _, err := ParseInstanceTypeNamePattern(o.InstanceTypeNamePattern); err != nil {
+		return fmt.Errorf("invalid instance type name pattern, %w", err)
+	}
+	if o.OrphanedMachineGracePeriod < 0 {
+		return fmt.Errorf("orphaned machine grace period must not be negative, got %s", o.OrphanedMachineGracePeriod)
+	}
 	return nil
 }
 
@@ -79,3 +126,82 @@ func FromContext(ctx context.Context) *Options {
 	}
 	return retval.(*Options)
 }
+
+// ParseRepairPolicies parses a comma separated list of repair policies in the form
+// "ConditionType=ConditionStatus:TolerationDuration", for example "Ready=False:15m".
+func ParseRepairPolicies(policies string) ([]cloudprovider.RepairPolicy, error) {
+	parsed := []cloudprovider.RepairPolicy{}
+	for _, policy := range strings.Split(policies, ",") {
+		policy = strings.TrimSpace(policy)
+		if policy == "" {
+			continue
+		}
+
+		condition, duration, found := strings.Cut(policy, ":")
+		if !found {
+			return nil, fmt.Errorf("repair policy %q is missing a toleration duration", policy)
+		}
+		conditionType, conditionStatus, found := strings.Cut(condition, "=")
+		if !found || conditionType == "" {
+			return nil, fmt.Errorf("repair policy %q is missing a condition type or status", policy)
+		}
+		switch corev1.ConditionStatus(conditionStatus) {
+		case corev1.ConditionTrue, corev1.ConditionFalse, corev1.ConditionUnknown:
+		default:
+			return nil, fmt.Errorf("repair policy %q has an unknown condition status %q", policy, conditionStatus)
+		}
+		tolerationDuration, err := time.ParseDuration(duration)
+		if err != nil {
+			return nil, fmt.Errorf("repair policy %q has an invalid toleration duration: %w", policy, err)
+		}
+		if tolerationDuration < 0 {
+			return nil, fmt.Errorf("repair policy %q has a negative toleration duration", policy)
+		}
+
+		parsed = append(parsed, cloudprovider.RepairPolicy{
+			ConditionType:      corev1.NodeConditionType(conditionType),
+			ConditionStatus:    corev1.ConditionStatus(conditionStatus),
+			TolerationDuration: tolerationDuration,
+		})
+	}
+
+	return parsed, nil
+}
+
+// ParseScalableResourceTypes parses a comma separated list of resource types in the form "Kind.version.group",
+// for example "NodeGroup.v1alpha1.example.com".
+func ParseScalableResourceTypes(types string) ([]schema.GroupVersionKind, error) {
+	parsed := []schema.GroupVersionKind{}
+	for _, t := range strings.Split(types, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+
+		gvk, _ := schema.ParseKindArg(t)
+		if gvk == nil || gvk.Kind == "" || gvk.Version == "" || gvk.Group == "" {
+			return nil, fmt.Errorf("scalable resource type %q is not in the form 'Kind.version.group'", t)
+		}
+		parsed = append(parsed, *gvk)
+	}
+
+	return parsed, nil
+}
+
+// ParseInstanceTypeNamePattern compiles a regular expression with the named groups "family" and "size", at least
+// one of the groups must be present. It returns nil for an empty pattern.
+func ParseInstanceTypeNamePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("pattern %q is not a valid regular expression: %w", pattern, err)
+	}
+	if compiled.SubexpIndex("family") < 0 && compiled.SubexpIndex("size") < 0 {
+		return nil, fmt.Errorf("pattern %q has neither a 'family' nor a 'size' named group", pattern)
+	}
+
+	return compiled, nil
+}
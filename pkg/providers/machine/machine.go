@@ -21,16 +21,23 @@ import (
 	"fmt"
 	"time"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// ProviderIDIndex is the name of the field index on the provider ID of Machines.
+const ProviderIDIndex = "spec.providerID"
+
 type Provider interface {
+	Create(context.Context, *capiv1beta1.Machine) error
+	Delete(context.Context, *capiv1beta1.Machine) error
 	Get(context.Context, string, string) (*capiv1beta1.Machine, error)
 	GetByProviderID(context.Context, string) (*capiv1beta1.Machine, error)
 	List(context.Context, string, *metav1.LabelSelector) ([]*capiv1beta1.Machine, error)
 	IsDeleting(*capiv1beta1.Machine) bool
+	IsFailed(*capiv1beta1.Machine) bool
 	AddDeleteAnnotation(context.Context, *capiv1beta1.Machine) error
 	RemoveDeleteAnnotation(context.Context, *capiv1beta1.Machine) error
 	Update(context.Context, *capiv1beta1.Machine) error
@@ -38,6 +45,8 @@ type Provider interface {
 
 type DefaultProvider struct {
 	kubeClient client.Client
+	// indexed is true when the client can answer lookups with the fields indexes registered by IndexFields.
+	indexed bool
 }
 
 func NewDefaultProvider(_ context.Context, kubeClient client.Client) *DefaultProvider {
@@ -46,6 +55,61 @@ func NewDefaultProvider(_ context.Context, kubeClient client.Client) *DefaultPro
 	}
 }
 
+// NewIndexedProvider returns a DefaultProvider which answers the provider ID lookups from the field index,
+// IndexFields must have been called with the cache that backs the client.
+func NewIndexedProvider(_ context.Context, kubeClient client.Client) *DefaultProvider {
+	return &DefaultProvider{
+		kubeClient: kubeClient,
+		indexed:    true,
+	}
+}
+
+// IndexFields registers the ProviderIDIndex field index for Machines.
+func IndexFields(ctx context.Context, indexer client.FieldIndexer) error {
+	if err := indexer.IndexField(ctx, &capiv1beta1.Machine{}, ProviderIDIndex, providerIDIndexFunc); err != nil {
+		return fmt.Errorf("unable to index Machines by provider ID: %w", err)
+	}
+	return nil
+}
+
+func providerIDIndexFunc(o client.Object) []string {
+	machine, ok := o.(*capiv1beta1.Machine)
+	if !ok || machine.Spec.ProviderID == nil || *machine.Spec.ProviderID == "" {
+		return nil
+	}
+	return []string{*machine.Spec.ProviderID}
+}
+
+// Create creates a Machine which is not owned by a scalable resource, these are used by the ownerless
+// provisioning mode.
+func (p *DefaultProvider) Create(ctx context.Context, machine *capiv1beta1.Machine) error {
+	if machine == nil {
+		return fmt.Errorf("cannot create Machine, nil value")
+	}
+
+	err := p.kubeClient.Create(ctx, machine)
+	if err != nil {
+		return fmt.Errorf("unable to create Machine %q: %w", machine.Name, err)
+	}
+
+	return nil
+}
+
+// Delete deletes a Machine. It should only be used for Machines which are not owned by a scalable resource,
+// the Machines of a scalable resource are deleted by reducing its replicas.
+func (p *DefaultProvider) Delete(ctx context.Context, machine *capiv1beta1.Machine) error {
+	if machine == nil {
+		return fmt.Errorf("cannot delete Machine, nil value")
+	}
+
+	err := p.kubeClient.Delete(ctx, machine)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("unable to delete Machine %q: %w", machine.Name, err)
+	}
+
+	return nil
+}
+
 func (p *DefaultProvider) Get(ctx context.Context, name string, namespace string) (*capiv1beta1.Machine, error) {
 	machine := &capiv1beta1.Machine{}
 	err := p.kubeClient.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, machine)
@@ -57,17 +121,26 @@ func (p *DefaultProvider) Get(ctx context.Context, name string, namespace string
 
 // GetByProviderID returns the Machine indicated by the supplied Provider ID or nil if not found.
 // Because Get is used with a provider ID, it may return a Machine that does not have
-// a label for node pool membership.
+// a label for node pool membership. When the provider is not indexed every Machine is listed.
 func (p *DefaultProvider) GetByProviderID(ctx context.Context, providerID string) (*capiv1beta1.Machine, error) {
+	if providerID == "" {
+		return nil, nil
+	}
+
+	listOptions := []client.ListOption{}
+	if p.indexed {
+		listOptions = append(listOptions, client.MatchingFields{ProviderIDIndex: providerID})
+	}
+
 	machineList := &capiv1beta1.MachineList{}
-	err := p.kubeClient.List(ctx, machineList)
+	err := p.kubeClient.List(ctx, machineList, listOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("unable to list machines during Machine Provider Get request: %w", err)
 	}
 
-	for _, m := range machineList.Items {
-		if m.Spec.ProviderID != nil && *m.Spec.ProviderID == providerID {
-			return &m, nil
+	for i := range machineList.Items {
+		if machineList.Items[i].Spec.ProviderID != nil && *machineList.Items[i].Spec.ProviderID == providerID {
+			return &machineList.Items[i], nil
 		}
 	}
 
@@ -109,6 +182,16 @@ func (p *DefaultProvider) IsDeleting(machine *capiv1beta1.Machine) bool {
 	return machine != nil && !machine.GetDeletionTimestamp().IsZero()
 }
 
+// IsFailed returns true if the supplied Machine is in the Failed phase, or has a failure reason or message set.
+func (p *DefaultProvider) IsFailed(machine *capiv1beta1.Machine) bool {
+	if machine == nil {
+		return false
+	}
+	return machine.Status.GetTypedPhase() == capiv1beta1.MachinePhaseFailed ||
+		machine.Status.FailureReason != nil ||
+		machine.Status.FailureMessage != nil
+}
+
 // AddDeleteAnnotation adds the Cluster API deletion annotation to a Machine resource and updates
 // the API server. It returns an error if there is a failure.
 func (p *DefaultProvider) AddDeleteAnnotation(ctx context.Context, machine *capiv1beta1.Machine) error {
@@ -165,4 +248,3 @@ func (p *DefaultProvider) Update(ctx context.Context, machine *capiv1beta1.Machi
 
 	return nil
 }
-
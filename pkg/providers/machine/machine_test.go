@@ -25,8 +25,11 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
 	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers"
 )
 
@@ -60,6 +63,36 @@ var _ = Describe("Machine DefaultProvider.IsDeleting method", func() {
 	})
 })
 
+var _ = Describe("Machine DefaultProvider.IsFailed method", func() {
+	var provider Provider
+
+	BeforeEach(func() {
+		provider = NewDefaultProvider(context.Background(), cl)
+	})
+
+	It("returns false when Machine is nil", func() {
+		Expect(provider.IsFailed(nil)).To(BeFalse())
+	})
+
+	It("returns false when Machine is running", func() {
+		machine := newMachine("karpenter-1", testNamespace, "karpenter-cluster", true)
+		machine.Status.SetTypedPhase(capiv1beta1.MachinePhaseRunning)
+		Expect(provider.IsFailed(machine)).To(BeFalse())
+	})
+
+	It("returns true when Machine is in the Failed phase", func() {
+		machine := newMachine("karpenter-1", testNamespace, "karpenter-cluster", true)
+		machine.Status.SetTypedPhase(capiv1beta1.MachinePhaseFailed)
+		Expect(provider.IsFailed(machine)).To(BeTrue())
+	})
+
+	It("returns true when Machine has a failure message", func() {
+		machine := newMachine("karpenter-1", testNamespace, "karpenter-cluster", true)
+		machine.Status.FailureMessage = ptr.To("quota exceeded")
+		Expect(provider.IsFailed(machine)).To(BeTrue())
+	})
+})
+
 var _ = Describe("Machine DefaultProvider.Get method", func() {
 	var provider Provider
 
@@ -116,6 +149,37 @@ var _ = Describe("Machine DefaultProvider.Get method", func() {
 	})
 })
 
+var _ = Describe("Machine indexed DefaultProvider", func() {
+	var provider Provider
+	var machines []*capiv1beta1.Machine
+
+	BeforeEach(func() {
+		machines = []*capiv1beta1.Machine{
+			newMachine("karpenter-1", testNamespace, "karpenter-cluster", true),
+			newMachine("karpenter-2", testNamespace, "karpenter-cluster", false),
+			newMachine("karpenter-3", testNamespace, "karpenter-cluster", true),
+		}
+		machines[2].Spec.ProviderID = nil
+		provider = NewIndexedProvider(context.Background(), newIndexedFakeClient(machines...))
+	})
+
+	It("returns the Machine with the requested provider ID", func() {
+		machine, err := provider.GetByProviderID(context.Background(), *machines[1].Spec.ProviderID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(machine).Should(HaveField("Name", "karpenter-2"))
+	})
+
+	It("returns nil when there is no Machine with the requested provider ID", func() {
+		machine, err := provider.GetByProviderID(context.Background(), "clusterapi://the-wrong-provider-id")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(machine).To(BeNil())
+
+		machine, err = provider.GetByProviderID(context.Background(), "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(machine).To(BeNil())
+	})
+})
+
 var _ = Describe("Machine DefaultProvider.List method", func() {
 	var provider Provider
 
@@ -205,6 +269,48 @@ var _ = Describe("Machine DefaultProvider.List method", func() {
 	})
 })
 
+var _ = Describe("Machine DefaultProvider.Create and Delete methods", func() {
+	var provider Provider
+
+	BeforeEach(func() {
+		provider = NewDefaultProvider(context.Background(), cl)
+	})
+
+	AfterEach(func() {
+		Expect(cl.DeleteAllOf(context.Background(), &capiv1beta1.Machine{}, client.InNamespace(testNamespace))).To(Succeed())
+		Eventually(func() client.ObjectList {
+			machineList := &capiv1beta1.MachineList{}
+			Expect(cl.List(context.Background(), machineList, client.InNamespace(testNamespace))).To(Succeed())
+			return machineList
+		}).Should(HaveField("Items", HaveLen(0)))
+	})
+
+	It("returns an error when the Machine is nil", func() {
+		Expect(provider.Create(context.Background(), nil)).ToNot(Succeed())
+		Expect(provider.Delete(context.Background(), nil)).ToNot(Succeed())
+	})
+
+	It("creates and deletes a Machine", func() {
+		machine := newMachine("karpenter-1", testNamespace, "karpenter-cluster", true)
+		Expect(provider.Create(context.Background(), machine)).To(Succeed())
+
+		machine, err := provider.Get(context.Background(), "karpenter-1", testNamespace)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(machine).ToNot(BeNil())
+
+		Expect(provider.Delete(context.Background(), machine)).To(Succeed())
+		Eventually(func() error {
+			_, err := provider.Get(context.Background(), "karpenter-1", testNamespace)
+			return err
+		}).Should(HaveOccurred())
+	})
+
+	It("does not return an error when the deleted Machine does not exist", func() {
+		machine := newMachine("karpenter-1", testNamespace, "karpenter-cluster", true)
+		Expect(provider.Delete(context.Background(), machine)).To(Succeed())
+	})
+})
+
 var _ = Describe("Machine DefaultProvider.AddDeleteAnnotation method", func() {
 	var provider Provider
 
@@ -311,3 +417,14 @@ func newMachine(machineName string, machineNamespace string, clusterName string,
 	machine.Spec.ProviderID = &providerID
 	return machine
 }
+
+// newIndexedFakeClient returns a fake client with the Machine field index which contains the Machines.
+func newIndexedFakeClient(machines ...*capiv1beta1.Machine) client.Client {
+	builder := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithIndex(&capiv1beta1.Machine{}, ProviderIDIndex, providerIDIndexFunc)
+	for _, m := range machines {
+		builder = builder.WithObjects(m)
+	}
+	return builder.Build()
+}
@@ -55,21 +55,13 @@ func (p *DefaultProvider) Get(ctx context.Context, name string, namespace string
 func (p *DefaultProvider) List(ctx context.Context, selector *metav1.LabelSelector) ([]*capiv1beta1.MachineDeployment, error) {
 	machineDeployments := []*capiv1beta1.MachineDeployment{}
 
-	listOptions := []client.ListOption{
-		client.MatchingLabels{
-			providers.NodePoolMemberLabel: "",
-		},
-	}
-
-	if selector != nil {
-		sm, err := metav1.LabelSelectorAsSelector(selector)
-		if err != nil {
-			return machineDeployments, fmt.Errorf("unable to convert selector in MachineDeployment List: %w", err)
-		}
-		listOptions = append(listOptions, &client.ListOptions{LabelSelector: sm})
+	sm, err := providers.MemberSelector(selector)
+	if err != nil {
+		return machineDeployments, fmt.Errorf("unable to convert selector in MachineDeployment List: %w", err)
 	}
+	listOptions := []client.ListOption{&client.ListOptions{LabelSelector: sm}}
 	machineDeploymentList := &capiv1beta1.MachineDeploymentList{}
-	err := p.kubeClient.List(ctx, machineDeploymentList, listOptions...)
+	err = p.kubeClient.List(ctx, machineDeploymentList, listOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("unable to list MachineDeployments with selector: %w", err)
 	}
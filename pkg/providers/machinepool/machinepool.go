@@ -0,0 +1,116 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machinepool
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	expv1beta1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers"
+)
+
+type Provider interface {
+	Get(context.Context, string, string) (*expv1beta1.MachinePool, error)
+	GetForMachine(context.Context, *capiv1beta1.Machine) (*expv1beta1.MachinePool, error)
+	List(context.Context, *metav1.LabelSelector) ([]*expv1beta1.MachinePool, error)
+	Update(context.Context, *expv1beta1.MachinePool) error
+}
+
+type DefaultProvider struct {
+	kubeClient client.Client
+}
+
+func NewDefaultProvider(_ context.Context, kubeClient client.Client) *DefaultProvider {
+	return &DefaultProvider{
+		kubeClient: kubeClient,
+	}
+}
+
+func (p *DefaultProvider) Get(ctx context.Context, name string, namespace string) (*expv1beta1.MachinePool, error) {
+	machinePool := &expv1beta1.MachinePool{}
+	err := p.kubeClient.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, machinePool)
+	if err != nil {
+		machinePool = nil
+		return machinePool, fmt.Errorf("unable to get MachinePool %s in namespace %s: %w", name, namespace, err)
+	}
+	return machinePool, nil
+}
+
+// GetForMachine returns the MachinePool that a Machine belongs to, or nil if it does not belong to a MachinePool.
+// MachinePool Machines carry the pool name label, when it is missing the MachinePool is found by looking for the
+// provider ID of the Machine in the providerIDList of the MachinePools in its namespace.
+func (p *DefaultProvider) GetForMachine(ctx context.Context, machine *capiv1beta1.Machine) (*expv1beta1.MachinePool, error) {
+	if machine == nil {
+		return nil, nil
+	}
+
+	if name, found := machine.GetLabels()[capiv1beta1.MachinePoolNameLabel]; found {
+		return p.Get(ctx, name, machine.GetNamespace())
+	}
+
+	if machine.Spec.ProviderID == nil || *machine.Spec.ProviderID == "" {
+		return nil, nil
+	}
+
+	machinePoolList := &expv1beta1.MachinePoolList{}
+	if err := p.kubeClient.List(ctx, machinePoolList, client.InNamespace(machine.GetNamespace())); err != nil {
+		return nil, fmt.Errorf("unable to list MachinePools in namespace %s: %w", machine.GetNamespace(), err)
+	}
+
+	for _, mp := range machinePoolList.Items {
+		if slices.Contains(mp.Spec.ProviderIDList, *machine.Spec.ProviderID) {
+			return &mp, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (p *DefaultProvider) List(ctx context.Context, selector *metav1.LabelSelector) ([]*expv1beta1.MachinePool, error) {
+	machinePools := []*expv1beta1.MachinePool{}
+
+	sm, err := providers.MemberSelector(selector)
+	if err != nil {
+		return machinePools, fmt.Errorf("unable to convert selector in MachinePool List: %w", err)
+	}
+	listOptions := []client.ListOption{&client.ListOptions{LabelSelector: sm}}
+	machinePoolList := &expv1beta1.MachinePoolList{}
+	err = p.kubeClient.List(ctx, machinePoolList, listOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list MachinePools with selector: %w", err)
+	}
+
+	for _, m := range machinePoolList.Items {
+		machinePools = append(machinePools, &m)
+	}
+
+	return machinePools, nil
+}
+
+func (p *DefaultProvider) Update(ctx context.Context, machinePool *expv1beta1.MachinePool) error {
+	err := p.kubeClient.Update(ctx, machinePool)
+	if err != nil {
+		return fmt.Errorf("unable to update MachinePool %q: %w", machinePool.Name, err)
+	}
+
+	return nil
+}
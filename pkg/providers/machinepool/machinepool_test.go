@@ -0,0 +1,221 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machinepool
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	expv1beta1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers"
+)
+
+func eventuallyDeleteAllMachinePools() {
+	Expect(cl.DeleteAllOf(context.Background(), &expv1beta1.MachinePool{}, client.InNamespace(testNamespace))).To(Succeed())
+	Eventually(func() client.ObjectList {
+		machinePoolList := &expv1beta1.MachinePoolList{}
+		Expect(cl.List(context.Background(), machinePoolList, client.InNamespace(testNamespace))).To(Succeed())
+		return machinePoolList
+	}).Should(HaveField("Items", HaveLen(0)))
+}
+
+var _ = Describe("MachinePool DefaultProvider.Get method", func() {
+	var provider Provider
+
+	BeforeEach(func() {
+		provider = NewDefaultProvider(context.Background(), cl)
+	})
+
+	AfterEach(func() {
+		eventuallyDeleteAllMachinePools()
+	})
+
+	It("returns the named MachinePool when it exists", func() {
+		name := "test-machine-pool"
+		machinePool := newMachinePool(name, "workload-cluster", true)
+		Expect(cl.Create(context.Background(), machinePool)).To(Succeed())
+
+		machinePool, err := provider.Get(context.Background(), name, testNamespace)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(machinePool).ToNot(BeNil())
+	})
+
+	It("returns nil and an error when the MachinePool does not exist", func() {
+		machinePool, err := provider.Get(context.Background(), "test-machine-pool", testNamespace)
+		Expect(err).To(HaveOccurred())
+		Expect(machinePool).To(BeNil())
+	})
+})
+
+var _ = Describe("MachinePool DefaultProvider.GetForMachine method", func() {
+	var provider Provider
+
+	BeforeEach(func() {
+		provider = NewDefaultProvider(context.Background(), cl)
+	})
+
+	AfterEach(func() {
+		eventuallyDeleteAllMachinePools()
+	})
+
+	It("returns nil when the Machine is nil", func() {
+		machinePool, err := provider.GetForMachine(context.Background(), nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(machinePool).To(BeNil())
+	})
+
+	It("returns the MachinePool from the pool name label", func() {
+		Expect(cl.Create(context.Background(), newMachinePool("mp-1", "workload-cluster", true))).To(Succeed())
+		machine := newMachine("m-1", "aws:///us-east-1a/i-1")
+		machine.SetLabels(map[string]string{capiv1beta1.MachinePoolNameLabel: "mp-1"})
+
+		machinePool, err := provider.GetForMachine(context.Background(), machine)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(machinePool).ToNot(BeNil())
+		Expect(machinePool.Name).To(Equal("mp-1"))
+	})
+
+	It("returns the MachinePool with the provider ID of the Machine in its providerIDList", func() {
+		first := newMachinePool("mp-1", "workload-cluster", true)
+		first.Spec.ProviderIDList = []string{"aws:///us-east-1a/i-1"}
+		Expect(cl.Create(context.Background(), first)).To(Succeed())
+		second := newMachinePool("mp-2", "workload-cluster", true)
+		second.Spec.ProviderIDList = []string{"aws:///us-east-1a/i-2", "aws:///us-east-1a/i-3"}
+		Expect(cl.Create(context.Background(), second)).To(Succeed())
+
+		machinePool, err := provider.GetForMachine(context.Background(), newMachine("m-3", "aws:///us-east-1a/i-3"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(machinePool).ToNot(BeNil())
+		Expect(machinePool.Name).To(Equal("mp-2"))
+	})
+
+	It("returns nil when the Machine does not belong to a MachinePool", func() {
+		machinePool := newMachinePool("mp-1", "workload-cluster", true)
+		machinePool.Spec.ProviderIDList = []string{"aws:///us-east-1a/i-1"}
+		Expect(cl.Create(context.Background(), machinePool)).To(Succeed())
+
+		machinePool, err := provider.GetForMachine(context.Background(), newMachine("m-4", "aws:///us-east-1a/i-4"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(machinePool).To(BeNil())
+	})
+})
+
+var _ = Describe("MachinePool DefaultProvider.List method", func() {
+	var provider Provider
+
+	BeforeEach(func() {
+		provider = NewDefaultProvider(context.Background(), cl)
+	})
+
+	AfterEach(func() {
+		eventuallyDeleteAllMachinePools()
+	})
+
+	It("returns an empty list when no MachinePools are present in API", func() {
+		machinePools, err := provider.List(context.Background(), nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(machinePools).To(HaveLen(0))
+	})
+
+	It("returns only the karpenter member MachinePools", func() {
+		Expect(cl.Create(context.Background(), newMachinePool("mp-1", "workload-cluster", true))).To(Succeed())
+		Expect(cl.Create(context.Background(), newMachinePool("mp-2", "workload-cluster", false))).To(Succeed())
+
+		machinePools, err := provider.List(context.Background(), nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(machinePools).To(HaveLen(1))
+		Expect(machinePools[0].Name).To(Equal("mp-1"))
+	})
+
+	It("returns the member MachinePools that match the selector", func() {
+		selected := newMachinePool("mp-1", "workload-cluster", true)
+		selected.GetLabels()["pool"] = "gpu"
+		Expect(cl.Create(context.Background(), selected)).To(Succeed())
+		Expect(cl.Create(context.Background(), newMachinePool("mp-2", "workload-cluster", true))).To(Succeed())
+
+		selector := &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "gpu"}}
+		machinePools, err := provider.List(context.Background(), selector)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(machinePools).To(HaveLen(1))
+		Expect(machinePools[0].Name).To(Equal("mp-1"))
+	})
+})
+
+var _ = Describe("MachinePool DefaultProvider.Update method", func() {
+	var provider Provider
+
+	BeforeEach(func() {
+		provider = NewDefaultProvider(context.Background(), cl)
+	})
+
+	AfterEach(func() {
+		eventuallyDeleteAllMachinePools()
+	})
+
+	It("returns an error when the MachinePool does not exist", func() {
+		machinePool := newMachinePool("non-existant", "fake-cluster", true)
+		err := provider.Update(context.Background(), machinePool)
+		Expect(err).Should(MatchError(ContainSubstring(fmt.Sprintf("unable to update MachinePool %q", machinePool.Name))))
+	})
+
+	It("updates the MachinePool as expected", func() {
+		machinePool := newMachinePool("mp-1", "karpenter-cluster", true)
+		machinePool.Spec.Replicas = ptr.To(int32(0))
+		Expect(cl.Create(context.Background(), machinePool)).To(Succeed())
+
+		machinePool, err := provider.Get(context.Background(), machinePool.Name, machinePool.Namespace)
+		Expect(err).ToNot(HaveOccurred())
+		expectedReplicas := *machinePool.Spec.Replicas + 1
+		machinePool.Spec.Replicas = ptr.To(expectedReplicas)
+
+		Expect(provider.Update(context.Background(), machinePool)).To(Succeed())
+
+		Eventually(func() *expv1beta1.MachinePool {
+			mp, err := provider.Get(context.Background(), machinePool.Name, machinePool.Namespace)
+			Expect(err).ToNot(HaveOccurred())
+			return mp
+		}).Should(HaveField("Spec", HaveField("Replicas", ptr.To(expectedReplicas))))
+	})
+})
+
+func newMachinePool(name string, clusterName string, karpenterMember bool) *expv1beta1.MachinePool {
+	machinePool := &expv1beta1.MachinePool{}
+	machinePool.SetName(name)
+	machinePool.SetNamespace(testNamespace)
+	labels := map[string]string{}
+	if karpenterMember {
+		labels[providers.NodePoolMemberLabel] = ""
+	}
+	machinePool.SetLabels(labels)
+	machinePool.Spec.ClusterName = clusterName
+	machinePool.Spec.Template.Spec.ClusterName = clusterName
+	return machinePool
+}
+
+func newMachine(name string, providerID string) *capiv1beta1.Machine {
+	machine := &capiv1beta1.Machine{}
+	machine.SetName(name)
+	machine.SetNamespace(testNamespace)
+	machine.Spec.ProviderID = ptr.To(providerID)
+	return machine
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machinetemplate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Status is the portion of an InfrastructureMachineTemplate status that is described by the
+// Cluster API opt-in autoscaling from zero contract.
+// see https://github.com/kubernetes-sigs/cluster-api/blob/main/docs/proposals/20210310-opt-in-autoscaling-from-zero.md#infrastructure-machine-template-status-fields
+type Status struct {
+	// Capacity is the resource capacity of a Machine created from the template.
+	Capacity corev1.ResourceList
+	// NodeInfo describes the Node of a Machine created from the template, it is nil when the template does not
+	// publish it.
+	NodeInfo *NodeInfo
+}
+
+// NodeInfo is the nodeInfo field of the InfrastructureMachineTemplate status.
+type NodeInfo struct {
+	// Architecture is the CPU architecture of the Node, for example "amd64" or "arm64".
+	Architecture string
+	// OperatingSystem is the operating system of the Node, for example "linux" or "windows".
+	OperatingSystem string
+}
+
+type Provider interface {
+	Get(context.Context, *corev1.ObjectReference, string) (*unstructured.Unstructured, error)
+	GetStatus(context.Context, *corev1.ObjectReference, string) (*Status, error)
+	List(context.Context, string, string, string, *metav1.LabelSelector) ([]*unstructured.Unstructured, error)
+	Clone(context.Context, *unstructured.Unstructured, string, map[string]string) (*corev1.ObjectReference, error)
+	Delete(context.Context, *corev1.ObjectReference, string) error
+}
+
+type DefaultProvider struct {
+	kubeClient client.Client
+}
+
+func NewDefaultProvider(_ context.Context, kubeClient client.Client) *DefaultProvider {
+	return &DefaultProvider{
+		kubeClient: kubeClient,
+	}
+}
+
+// Get returns the object referenced by the supplied object reference as an unstructured object.
+// Infrastructure templates are provider specific types, so we cannot know their concrete type. The
+// same applies to the infrastructure machines and bootstrap configs that are cloned from templates.
+// If the reference does not specify a namespace, the supplied namespace is used.
+func (p *DefaultProvider) Get(ctx context.Context, ref *corev1.ObjectReference, namespace string) (*unstructured.Unstructured, error) {
+	if ref == nil || ref.Name == "" {
+		return nil, fmt.Errorf("cannot get template, reference is empty")
+	}
+
+	if ref.Namespace != "" {
+		namespace = ref.Namespace
+	}
+
+	template := &unstructured.Unstructured{}
+	template.SetGroupVersionKind(ref.GroupVersionKind())
+	err := p.kubeClient.Get(ctx, client.ObjectKey{Name: ref.Name, Namespace: namespace}, template)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get %s %s in namespace %s: %w", ref.Kind, ref.Name, namespace, err)
+	}
+
+	return template, nil
+}
+
+// List returns the templates of the kind in the namespace which match the selector, a nil selector matches
+// all the templates.
+func (p *DefaultProvider) List(ctx context.Context, apiVersion string, kind string, namespace string, selector *metav1.LabelSelector) ([]*unstructured.Unstructured, error) {
+	templates := []*unstructured.Unstructured{}
+
+	listOptions := []client.ListOption{
+		client.InNamespace(namespace),
+	}
+	if selector != nil {
+		sm, err := metav1.LabelSelectorAsSelector(selector)
+		if err != nil {
+			return templates, fmt.Errorf("unable to convert selector in %s List: %w", kind, err)
+		}
+		listOptions = append(listOptions, &client.ListOptions{LabelSelector: sm})
+	}
+
+	templateList := &unstructured.UnstructuredList{}
+	templateList.SetAPIVersion(apiVersion)
+	templateList.SetKind(kind + "List")
+	if err := p.kubeClient.List(ctx, templateList, listOptions...); err != nil {
+		return templates, fmt.Errorf("unable to list %s in namespace %s: %w", kind, namespace, err)
+	}
+
+	for i := range templateList.Items {
+		templates = append(templates, &templateList.Items[i])
+	}
+
+	return templates, nil
+}
+
+// Clone creates an object from the spec.template of the template, in the same way that Cluster API clones
+// the templates of a MachineSet. The object has the kind of the template without the "Template" suffix, the
+// supplied name and labels, and the cloned-from annotations which are used to detect drift. An object which
+// already exists with the same name is reused, so that a failed clone can be retried.
+// It returns a reference to the object.
+func (p *DefaultProvider) Clone(ctx context.Context, template *unstructured.Unstructured, name string, labels map[string]string) (*corev1.ObjectReference, error) {
+	if template == nil {
+		return nil, fmt.Errorf("cannot clone template, nil value")
+	}
+
+	object := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	spec, found, err := unstructured.NestedMap(template.Object, "spec", "template", "spec")
+	if err != nil {
+		return nil, fmt.Errorf("unable to read spec.template.spec of %s %s: %w", template.GetKind(), template.GetName(), err)
+	}
+	if found {
+		object.Object["spec"] = spec
+	}
+
+	object.SetAPIVersion(template.GetAPIVersion())
+	object.SetKind(strings.TrimSuffix(template.GetKind(), "Template"))
+	object.SetName(name)
+	object.SetNamespace(template.GetNamespace())
+
+	objectLabels, _, _ := unstructured.NestedStringMap(template.Object, "spec", "template", "metadata", "labels")
+	if objectLabels == nil {
+		objectLabels = map[string]string{}
+	}
+	for k, v := range labels {
+		objectLabels[k] = v
+	}
+	object.SetLabels(objectLabels)
+
+	annotations, _, _ := unstructured.NestedStringMap(template.Object, "spec", "template", "metadata", "annotations")
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[capiv1beta1.TemplateClonedFromNameAnnotation] = template.GetName()
+	annotations[capiv1beta1.TemplateClonedFromGroupKindAnnotation] = template.GroupVersionKind().GroupKind().String()
+	object.SetAnnotations(annotations)
+
+	if err := p.kubeClient.Create(ctx, object); err != nil && !apierrors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("unable to create %s %s from %s %s: %w", object.GetKind(), name, template.GetKind(), template.GetName(), err)
+	}
+
+	return &corev1.ObjectReference{
+		APIVersion: object.GetAPIVersion(),
+		Kind:       object.GetKind(),
+		Name:       object.GetName(),
+		Namespace:  object.GetNamespace(),
+	}, nil
+}
+
+// Delete deletes the object referenced by the supplied object reference, it is used to remove the objects
+// cloned from templates when a Machine cannot be created. An object which does not exist is ignored.
+func (p *DefaultProvider) Delete(ctx context.Context, ref *corev1.ObjectReference, namespace string) error {
+	if ref == nil || ref.Name == "" {
+		return fmt.Errorf("cannot delete object, reference is empty")
+	}
+
+	if ref.Namespace != "" {
+		namespace = ref.Namespace
+	}
+
+	object := &unstructured.Unstructured{}
+	object.SetGroupVersionKind(ref.GroupVersionKind())
+	object.SetName(ref.Name)
+	object.SetNamespace(namespace)
+	if err := p.kubeClient.Delete(ctx, object); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("unable to delete %s %s in namespace %s: %w", ref.Kind, ref.Name, namespace, err)
+	}
+
+	return nil
+}
+
+// GetStatus returns the scale from zero status of the template referenced by the supplied object reference.
+func (p *DefaultProvider) GetStatus(ctx context.Context, ref *corev1.ObjectReference, namespace string) (*Status, error) {
+	template, err := p.Get(ctx, ref, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return StatusFromTemplate(template), nil
+}
+
+// StatusFromTemplate reads the scale from zero status fields from an unstructured template. Fields that
+// are missing or cannot be parsed are left empty.
+func StatusFromTemplate(template *unstructured.Unstructured) *Status {
+	status := &Status{
+		Capacity: corev1.ResourceList{},
+	}
+
+	if template == nil {
+		return status
+	}
+
+	capacity, found, err := unstructured.NestedStringMap(template.Object, "status", "capacity")
+	if err == nil && found {
+		for name, value := range capacity {
+			quantity, err := resource.ParseQuantity(value)
+			if err != nil {
+				continue
+			}
+			status.Capacity[corev1.ResourceName(name)] = quantity
+		}
+	}
+
+	nodeInfo, found, err := unstructured.NestedStringMap(template.Object, "status", "nodeInfo")
+	if err == nil && found {
+		status.NodeInfo = &NodeInfo{
+			Architecture:    nodeInfo["architecture"],
+			OperatingSystem: nodeInfo["operatingSystem"],
+		}
+	}
+
+	return status
+}
@@ -0,0 +1,236 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machinetemplate
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("MachineTemplate DefaultProvider.Get method", func() {
+	var provider Provider
+
+	BeforeEach(func() {
+		provider = NewDefaultProvider(context.Background(), cl)
+	})
+
+	AfterEach(func() {
+		Expect(client.IgnoreNotFound(cl.Delete(context.Background(), newTemplate("template-1")))).To(Succeed())
+	})
+
+	It("returns an error when the reference is empty", func() {
+		template, err := provider.Get(context.Background(), &corev1.ObjectReference{}, testNamespace)
+		Expect(err).To(HaveOccurred())
+		Expect(template).To(BeNil())
+	})
+
+	It("returns an error when the template does not exist", func() {
+		template, err := provider.Get(context.Background(), newReference("does-not-exist"), testNamespace)
+		Expect(err).To(HaveOccurred())
+		Expect(template).To(BeNil())
+	})
+
+	It("returns the referenced template when it exists", func() {
+		Expect(cl.Create(context.Background(), newTemplate("template-1"))).To(Succeed())
+
+		template, err := provider.Get(context.Background(), newReference("template-1"), testNamespace)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(template.GetName()).To(Equal("template-1"))
+	})
+})
+
+var _ = Describe("MachineTemplate DefaultProvider.GetStatus method", func() {
+	var provider Provider
+
+	BeforeEach(func() {
+		provider = NewDefaultProvider(context.Background(), cl)
+	})
+
+	AfterEach(func() {
+		Expect(client.IgnoreNotFound(cl.Delete(context.Background(), newTemplate("template-1")))).To(Succeed())
+	})
+
+	It("returns the capacity from the template status", func() {
+		template := newTemplate("template-1")
+		Expect(cl.Create(context.Background(), template)).To(Succeed())
+		Expect(unstructured.SetNestedStringMap(template.Object, map[string]string{"cpu": "4", "memory": "16Gi"}, "status", "capacity")).To(Succeed())
+		Expect(cl.Update(context.Background(), template)).To(Succeed())
+
+		status, err := provider.GetStatus(context.Background(), newReference("template-1"), testNamespace)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Capacity).To(HaveKeyWithValue(corev1.ResourceCPU, resource.MustParse("4")))
+		Expect(status.Capacity).To(HaveKeyWithValue(corev1.ResourceMemory, resource.MustParse("16Gi")))
+	})
+})
+
+var _ = Describe("MachineTemplate DefaultProvider.List method", func() {
+	var provider Provider
+
+	BeforeEach(func() {
+		provider = NewDefaultProvider(context.Background(), cl)
+	})
+
+	AfterEach(func() {
+		Expect(client.IgnoreNotFound(cl.Delete(context.Background(), newTemplate("template-1")))).To(Succeed())
+		Expect(client.IgnoreNotFound(cl.Delete(context.Background(), newTemplate("template-2")))).To(Succeed())
+	})
+
+	It("returns the templates which match the selector", func() {
+		template := newTemplate("template-1")
+		template.SetLabels(map[string]string{"size": "large"})
+		Expect(cl.Create(context.Background(), template)).To(Succeed())
+		Expect(cl.Create(context.Background(), newTemplate("template-2"))).To(Succeed())
+
+		templates, err := provider.List(context.Background(), testTemplateGroup+"/"+testTemplateVersion, testTemplateKind, testNamespace, &metav1.LabelSelector{MatchLabels: map[string]string{"size": "large"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(templates).To(HaveLen(1))
+		Expect(templates[0].GetName()).To(Equal("template-1"))
+	})
+
+	It("returns all the templates when the selector is nil", func() {
+		Expect(cl.Create(context.Background(), newTemplate("template-1"))).To(Succeed())
+		Expect(cl.Create(context.Background(), newTemplate("template-2"))).To(Succeed())
+
+		templates, err := provider.List(context.Background(), testTemplateGroup+"/"+testTemplateVersion, testTemplateKind, testNamespace, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(templates).To(HaveLen(2))
+	})
+})
+
+var _ = Describe("MachineTemplate DefaultProvider.Clone method", func() {
+	var provider Provider
+
+	BeforeEach(func() {
+		provider = NewDefaultProvider(context.Background(), cl)
+	})
+
+	AfterEach(func() {
+		Expect(client.IgnoreNotFound(cl.Delete(context.Background(), newTemplate("template-1")))).To(Succeed())
+		machine := &unstructured.Unstructured{}
+		machine.SetAPIVersion(testTemplateGroup + "/" + testTemplateVersion)
+		machine.SetKind(testMachineKind)
+		machine.SetName("machine-1")
+		machine.SetNamespace(testNamespace)
+		Expect(client.IgnoreNotFound(cl.Delete(context.Background(), machine))).To(Succeed())
+	})
+
+	It("creates an object from the template spec with the cloned-from annotations", func() {
+		template := newTemplate("template-1")
+		Expect(unstructured.SetNestedField(template.Object, "large", "spec", "template", "spec", "size")).To(Succeed())
+		Expect(unstructured.SetNestedStringMap(template.Object, map[string]string{"team": "a"}, "spec", "template", "metadata", "labels")).To(Succeed())
+		Expect(cl.Create(context.Background(), template)).To(Succeed())
+
+		ref, err := provider.Clone(context.Background(), template, "machine-1", map[string]string{capiv1beta1.ClusterNameLabel: "test-cluster"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ref.Kind).To(Equal(testMachineKind))
+		Expect(ref.Name).To(Equal("machine-1"))
+
+		machine, err := provider.Get(context.Background(), ref, testNamespace)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(machine.GetLabels()).To(HaveKeyWithValue("team", "a"))
+		Expect(machine.GetLabels()).To(HaveKeyWithValue(capiv1beta1.ClusterNameLabel, "test-cluster"))
+		Expect(machine.GetAnnotations()).To(HaveKeyWithValue(capiv1beta1.TemplateClonedFromNameAnnotation, "template-1"))
+		Expect(machine.GetAnnotations()).To(HaveKeyWithValue(capiv1beta1.TemplateClonedFromGroupKindAnnotation, testTemplateKind+"."+testTemplateGroup))
+		size, _, _ := unstructured.NestedString(machine.Object, "spec", "size")
+		Expect(size).To(Equal("large"))
+	})
+
+	It("reuses an object which already exists", func() {
+		template := newTemplate("template-1")
+		Expect(cl.Create(context.Background(), template)).To(Succeed())
+
+		_, err := provider.Clone(context.Background(), template, "machine-1", nil)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = provider.Clone(context.Background(), template, "machine-1", nil)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("deletes the cloned object", func() {
+		template := newTemplate("template-1")
+		Expect(cl.Create(context.Background(), template)).To(Succeed())
+
+		ref, err := provider.Clone(context.Background(), template, "machine-1", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(provider.Delete(context.Background(), ref, testNamespace)).To(Succeed())
+
+		_, err = provider.Get(context.Background(), ref, testNamespace)
+		Expect(err).To(HaveOccurred())
+		// deleting an object which does not exist is not an error.
+		Expect(provider.Delete(context.Background(), ref, testNamespace)).To(Succeed())
+	})
+})
+
+var _ = Describe("StatusFromTemplate function", func() {
+	It("returns an empty status when the template is nil", func() {
+		status := StatusFromTemplate(nil)
+		Expect(status.Capacity).To(BeEmpty())
+	})
+
+	It("returns an empty status when the template has no status", func() {
+		status := StatusFromTemplate(newTemplate("template-1"))
+		Expect(status.Capacity).To(BeEmpty())
+	})
+
+	It("skips capacity values that cannot be parsed", func() {
+		template := newTemplate("template-1")
+		Expect(unstructured.SetNestedStringMap(template.Object, map[string]string{"cpu": "4", "memory": "lots"}, "status", "capacity")).To(Succeed())
+
+		status := StatusFromTemplate(template)
+		Expect(status.Capacity).To(HaveLen(1))
+		Expect(status.Capacity).To(HaveKeyWithValue(corev1.ResourceCPU, resource.MustParse("4")))
+	})
+
+	It("returns the node info from the template status", func() {
+		template := newTemplate("template-1")
+		Expect(unstructured.SetNestedStringMap(template.Object, map[string]string{"architecture": "arm64", "operatingSystem": "linux"}, "status", "nodeInfo")).To(Succeed())
+
+		status := StatusFromTemplate(template)
+		Expect(status.NodeInfo).To(Equal(&NodeInfo{Architecture: "arm64", OperatingSystem: "linux"}))
+	})
+
+	It("returns no node info when the template status does not have it", func() {
+		template := newTemplate("template-1")
+		Expect(unstructured.SetNestedStringMap(template.Object, map[string]string{"cpu": "4"}, "status", "capacity")).To(Succeed())
+
+		Expect(StatusFromTemplate(template).NodeInfo).To(BeNil())
+	})
+})
+
+func newReference(name string) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		APIVersion: testTemplateGroup + "/" + testTemplateVersion,
+		Kind:       testTemplateKind,
+		Name:       name,
+	}
+}
+
+func newTemplate(name string) *unstructured.Unstructured {
+	template := &unstructured.Unstructured{}
+	template.SetAPIVersion(testTemplateGroup + "/" + testTemplateVersion)
+	template.SetKind(testTemplateKind)
+	template.SetName(name)
+	template.SetNamespace(testNamespace)
+	return template
+}
@@ -19,6 +19,10 @@ package providers
 import (
 	"fmt"
 	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
 )
 
 const (
@@ -28,8 +32,49 @@ const (
 	// MachineAnnotation is the annotation on a NodeClaim that references
 	// the CAPI Machine bound to it, in "namespace/name" format.
 	MachineAnnotation = "cluster.x-k8s.io/machine"
+
+	// MachineTemplateLabel is a label on the Machines that Karpenter creates from an InfrastructureMachineTemplate
+	// in the ownerless provisioning mode, the value is the name of the template.
+	MachineTemplateLabel = "node.cluster.x-k8s.io/karpenter-machine-template"
+
+	// NodeClaimAnnotation is the annotation on a Machine that references the NodeClaim bound to it.
+	NodeClaimAnnotation = "karpenter.cluster.x-k8s.io/nodeclaim"
+
+	// NodePoolAnnotation is the annotation on a Machine with the NodePool of the NodeClaim bound to it.
+	NodePoolAnnotation = "karpenter.cluster.x-k8s.io/nodepool"
+
+	// NodeClassAnnotation is the annotation on a Machine with the ClusterAPINodeClass of the NodeClaim bound to it.
+	NodeClassAnnotation = "karpenter.cluster.x-k8s.io/nodeclass"
+)
+
+const (
+	// MachineDeploymentKind is the kind of a MachineDeployment scalable resource.
+	MachineDeploymentKind = "MachineDeployment"
+
+	// MachinePoolKind is the kind of a MachinePool scalable resource.
+	MachinePoolKind = "MachinePool"
+
+	// MachineSetKind is the kind of a MachineSet scalable resource.
+	MachineSetKind = "MachineSet"
 )
 
+// MemberSelector returns a selector for the resources with the NodePoolMemberLabel which also match the selector, a
+// nil selector matches all the participating resources.
+func MemberSelector(selector *metav1.LabelSelector) (labels.Selector, error) {
+	sm := labels.NewSelector()
+	if selector != nil {
+		var err error
+		if sm, err = metav1.LabelSelectorAsSelector(selector); err != nil {
+			return nil, err
+		}
+	}
+	member, err := labels.NewRequirement(NodePoolMemberLabel, selection.Equals, []string{""})
+	if err != nil {
+		return nil, err
+	}
+	return sm.Add(*member), nil
+}
+
 // ParseMachineAnnotation splits a "namespace/name" annotation value into its components.
 func ParseMachineAnnotation(annotationValue string) (string, string, error) {
 	parts := strings.Split(annotationValue, "/")
@@ -46,3 +91,20 @@ func ParseMachineAnnotation(annotationValue string) (string, string, error) {
 
 	return ns, name, nil
 }
+
+// BindingAnnotations returns the annotations which record the NodeClaim bound to a Machine, with its NodePool and
+// ClusterAPINodeClass. Empty names are left out.
+func BindingAnnotations(nodeClaimName, nodePoolName, nodeClassName string) map[string]string {
+	annotations := map[string]string{}
+	for key, value := range map[string]string{
+		NodeClaimAnnotation: nodeClaimName,
+		NodePoolAnnotation:  nodePoolName,
+		NodeClassAnnotation: nodeClassName,
+	} {
+		if value != "" {
+			annotations[key] = value
+		}
+	}
+
+	return annotations
+}
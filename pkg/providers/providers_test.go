@@ -18,6 +18,9 @@ package providers
 
 import (
 	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 func TestParseMachineAnnotation(t *testing.T) {
@@ -104,3 +107,73 @@ func TestParseMachineAnnotation(t *testing.T) {
 		})
 	}
 }
+
+func TestBindingAnnotations(t *testing.T) {
+	annotations := BindingAnnotations("nc-1", "default", "")
+	if len(annotations) != 2 {
+		t.Fatalf("expected 2 annotations, got %v", annotations)
+	}
+	if annotations[NodeClaimAnnotation] != "nc-1" {
+		t.Errorf("NodeClaim annotation: got %q, want %q", annotations[NodeClaimAnnotation], "nc-1")
+	}
+	if annotations[NodePoolAnnotation] != "default" {
+		t.Errorf("NodePool annotation: got %q, want %q", annotations[NodePoolAnnotation], "default")
+	}
+	if _, found := annotations[NodeClassAnnotation]; found {
+		t.Errorf("expected no NodeClass annotation for an empty name, got %v", annotations)
+	}
+}
+
+func TestMemberSelector(t *testing.T) {
+	tests := []struct {
+		name      string
+		selector  *metav1.LabelSelector
+		labels    map[string]string
+		wantMatch bool
+	}{
+		{
+			name:      "nil selector matches a member",
+			labels:    map[string]string{NodePoolMemberLabel: ""},
+			wantMatch: true,
+		},
+		{
+			name:   "nil selector does not match a non-member",
+			labels: map[string]string{},
+		},
+		{
+			name:      "selector matches a member with its labels",
+			selector:  &metav1.LabelSelector{MatchLabels: map[string]string{"cluster": "test-cluster"}},
+			labels:    map[string]string{NodePoolMemberLabel: "", "cluster": "test-cluster"},
+			wantMatch: true,
+		},
+		{
+			name:     "selector does not match a non-member with its labels",
+			selector: &metav1.LabelSelector{MatchLabels: map[string]string{"cluster": "test-cluster"}},
+			labels:   map[string]string{"cluster": "test-cluster"},
+		},
+		{
+			name:     "selector does not match a member without its labels",
+			selector: &metav1.LabelSelector{MatchLabels: map[string]string{"cluster": "test-cluster"}},
+			labels:   map[string]string{NodePoolMemberLabel: ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector, err := MemberSelector(tt.selector)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if match := selector.Matches(labels.Set(tt.labels)); match != tt.wantMatch {
+				t.Errorf("match: got %t, want %t", match, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestMemberSelectorInvalid(t *testing.T) {
+	selector := &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "a", Operator: "Invalid"}}}
+	if _, err := MemberSelector(selector); err == nil {
+		t.Errorf("expected error for an invalid selector")
+	}
+}
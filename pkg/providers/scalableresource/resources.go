@@ -0,0 +1,195 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scalableresource
+
+import (
+	"maps"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	expv1beta1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
+
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers"
+)
+
+// NewMachineDeploymentResource returns the scalable resource for a MachineDeployment.
+func NewMachineDeploymentResource(machineDeployment *capiv1beta1.MachineDeployment) ScalableResource {
+	return &machineDeploymentResource{machineDeployment: machineDeployment}
+}
+
+// NewMachinePoolResource returns the scalable resource for a MachinePool.
+func NewMachinePoolResource(machinePool *expv1beta1.MachinePool) ScalableResource {
+	return &machinePoolResource{machinePool: machinePool}
+}
+
+// NewMachineSetResource returns the scalable resource for a MachineSet.
+func NewMachineSetResource(machineSet *capiv1beta1.MachineSet) ScalableResource {
+	return &machineSetResource{machineSet: machineSet}
+}
+
+type machineDeploymentResource struct {
+	machineDeployment *capiv1beta1.MachineDeployment
+}
+
+func (r *machineDeploymentResource) Reference() Reference {
+	return NewReference("", providers.MachineDeploymentKind, r.machineDeployment.Name, r.machineDeployment.Namespace)
+}
+
+func (r *machineDeploymentResource) Replicas() *int32 {
+	return r.machineDeployment.Spec.Replicas
+}
+
+func (r *machineDeploymentResource) SetReplicas(replicas int32) {
+	r.machineDeployment.Spec.Replicas = ptr.To(replicas)
+}
+
+func (r *machineDeploymentResource) MachineSelector() *metav1.LabelSelector {
+	return &metav1.LabelSelector{MatchLabels: map[string]string{capiv1beta1.MachineDeploymentNameLabel: r.machineDeployment.Name}}
+}
+
+func (r *machineDeploymentResource) MachineDeployment() *capiv1beta1.MachineDeployment {
+	return r.machineDeployment
+}
+
+type machineSetResource struct {
+	machineSet *capiv1beta1.MachineSet
+}
+
+func (r *machineSetResource) Reference() Reference {
+	return NewReference("", providers.MachineSetKind, r.machineSet.Name, r.machineSet.Namespace)
+}
+
+func (r *machineSetResource) Replicas() *int32 {
+	return r.machineSet.Spec.Replicas
+}
+
+func (r *machineSetResource) SetReplicas(replicas int32) {
+	r.machineSet.Spec.Replicas = ptr.To(replicas)
+}
+
+func (r *machineSetResource) MachineSelector() *metav1.LabelSelector {
+	return &metav1.LabelSelector{MatchLabels: map[string]string{capiv1beta1.MachineSetNameLabel: r.machineSet.Name}}
+}
+
+func (r *machineSetResource) MachineDeployment() *capiv1beta1.MachineDeployment {
+	return &capiv1beta1.MachineDeployment{
+		ObjectMeta: *r.machineSet.ObjectMeta.DeepCopy(),
+		Spec: capiv1beta1.MachineDeploymentSpec{
+			ClusterName: r.machineSet.Spec.ClusterName,
+			Replicas:    r.machineSet.Spec.Replicas,
+			Template:    *r.machineSet.Spec.Template.DeepCopy(),
+		},
+	}
+}
+
+type machinePoolResource struct {
+	machinePool *expv1beta1.MachinePool
+}
+
+func (r *machinePoolResource) Reference() Reference {
+	return NewReference("", providers.MachinePoolKind, r.machinePool.Name, r.machinePool.Namespace)
+}
+
+func (r *machinePoolResource) Replicas() *int32 {
+	return r.machinePool.Spec.Replicas
+}
+
+func (r *machinePoolResource) SetReplicas(replicas int32) {
+	r.machinePool.Spec.Replicas = ptr.To(replicas)
+}
+
+func (r *machinePoolResource) MachineSelector() *metav1.LabelSelector {
+	return &metav1.LabelSelector{MatchLabels: map[string]string{capiv1beta1.MachinePoolNameLabel: r.machinePool.Name}}
+}
+
+// MachineDeployment returns the MachinePool as a MachineDeployment. When the MachinePool has a single failure
+// domain and the template does not set one, it is used as the failure domain of the template.
+func (r *machinePoolResource) MachineDeployment() *capiv1beta1.MachineDeployment {
+	machineDeployment := &capiv1beta1.MachineDeployment{
+		ObjectMeta: *r.machinePool.ObjectMeta.DeepCopy(),
+		Spec: capiv1beta1.MachineDeploymentSpec{
+			ClusterName: r.machinePool.Spec.ClusterName,
+			Replicas:    r.machinePool.Spec.Replicas,
+			Template:    *r.machinePool.Spec.Template.DeepCopy(),
+		},
+	}
+
+	if machineDeployment.Spec.Template.Spec.FailureDomain == nil && len(r.machinePool.Spec.FailureDomains) == 1 {
+		machineDeployment.Spec.Template.Spec.FailureDomain = ptr.To(r.machinePool.Spec.FailureDomains[0])
+	}
+
+	return machineDeployment
+}
+
+// scaleResource is a resource of a kind outside of Cluster API which is scaled through its scale subresource.
+type scaleResource struct {
+	object *unstructured.Unstructured
+	scale  *autoscalingv1.Scale
+}
+
+func (r *scaleResource) Reference() Reference {
+	return NewReference(r.object.GetAPIVersion(), r.object.GetKind(), r.object.GetName(), r.object.GetNamespace())
+}
+
+func (r *scaleResource) Replicas() *int32 {
+	return ptr.To(r.scale.Spec.Replicas)
+}
+
+func (r *scaleResource) SetReplicas(replicas int32) {
+	r.scale.Spec.Replicas = replicas
+}
+
+// MachineSelector returns the selector from the status of the scale subresource, the selector is empty when
+// the resource does not publish one.
+func (r *scaleResource) MachineSelector() *metav1.LabelSelector {
+	selector, err := metav1.ParseToLabelSelector(r.scale.Status.Selector)
+	if err != nil {
+		return &metav1.LabelSelector{}
+	}
+	return selector
+}
+
+// MachineDeployment returns a MachineDeployment with the metadata of the resource and the replicas from the scale
+// subresource. When the resource has a Cluster API Machine template in spec.template it is also used.
+func (r *scaleResource) MachineDeployment() *capiv1beta1.MachineDeployment {
+	machineDeployment := &capiv1beta1.MachineDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        r.object.GetName(),
+			Namespace:   r.object.GetNamespace(),
+			Labels:      maps.Clone(r.object.GetLabels()),
+			Annotations: maps.Clone(r.object.GetAnnotations()),
+		},
+		Spec: capiv1beta1.MachineDeploymentSpec{
+			Replicas: r.Replicas(),
+		},
+	}
+
+	if template, found, err := unstructured.NestedMap(r.object.Object, "spec", "template"); err == nil && found {
+		// a template which is not a Cluster API Machine template is ignored.
+		machineTemplate := capiv1beta1.MachineTemplateSpec{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(template, &machineTemplate); err == nil {
+			machineDeployment.Spec.Template = machineTemplate
+		}
+	}
+	machineDeployment.Spec.ClusterName, _, _ = unstructured.NestedString(r.object.Object, "spec", "clusterName")
+
+	return machineDeployment
+}
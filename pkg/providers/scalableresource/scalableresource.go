@@ -0,0 +1,397 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scalableresource
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/operator/options"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machine"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machinedeployment"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machinepool"
+)
+
+// ScalableResource is a resource that manages a group of Machines created from the same template, and whose
+// replicas Karpenter changes to create and delete NodeClaims.
+type ScalableResource interface {
+	// Reference returns the reference that identifies the resource.
+	Reference() Reference
+	// Replicas returns the desired number of Machines, or nil when it is not set.
+	Replicas() *int32
+	// SetReplicas sets the desired number of Machines, the change is saved with Provider.Update.
+	SetReplicas(int32)
+	// MachineSelector returns the label selector for the Machines of the resource.
+	MachineSelector() *metav1.LabelSelector
+	// MachineDeployment returns a MachineDeployment with the metadata, replicas and Machine template of the
+	// resource. It is used to read the capacity, labels, taints and offerings of the Machines in the same way
+	// for all kinds of scalable resources.
+	MachineDeployment() *capiv1beta1.MachineDeployment
+}
+
+// Reference identifies a scalable resource. The APIVersion is only needed for kinds which are not part of
+// Cluster API, these are accessed through their scale subresource.
+type Reference struct {
+	APIVersion string
+	Kind       string
+	Name       string
+	Namespace  string
+}
+
+// NewReference returns a reference, an empty kind is a MachineDeployment.
+func NewReference(apiVersion, kind, name, namespace string) Reference {
+	if kind == "" {
+		kind = providers.MachineDeploymentKind
+	}
+	return Reference{APIVersion: apiVersion, Kind: kind, Name: name, Namespace: namespace}
+}
+
+// Key returns a unique key for the resource, MachineDeployments keep the "namespace/name" key.
+func (r Reference) Key() string {
+	switch {
+	case r.Kind == providers.MachineDeploymentKind:
+		return r.Namespace + "/" + r.Name
+	case r.isClusterAPIKind():
+		return r.Kind + "/" + r.Namespace + "/" + r.Name
+	default:
+		return r.Kind + "." + r.APIVersion + "/" + r.Namespace + "/" + r.Name
+	}
+}
+
+func (r Reference) isClusterAPIKind() bool {
+	switch r.Kind {
+	case providers.MachineDeploymentKind, providers.MachineSetKind, providers.MachinePoolKind:
+		return r.APIVersion == "" || schema.FromAPIVersionAndKind(r.APIVersion, r.Kind).Group == capiv1beta1.GroupVersion.Group
+	}
+	return false
+}
+
+// MinSize returns the minimum number of replicas of the resource from the cluster-autoscaler min size annotation.
+// It returns false when the annotation is not set or is invalid, or when the minimum size is disabled with the
+// --disable-min-size-check option.
+func MinSize(ctx context.Context, resource ScalableResource) (int32, bool) {
+	if opts := options.FromContext(ctx); opts != nil && opts.DisableMinSizeCheck {
+		return 0, false
+	}
+
+	minSize, err := strconv.ParseInt(resource.MachineDeployment().GetAnnotations()[capiv1beta1.AutoscalerMinSizeAnnotation], 10, 32)
+	if err != nil || minSize < 0 {
+		return 0, false
+	}
+
+	return int32(minSize), true
+}
+
+type Provider interface {
+	Get(context.Context, Reference) (ScalableResource, error)
+	GetForMachine(context.Context, *capiv1beta1.Machine) (ScalableResource, error)
+	List(context.Context, *metav1.LabelSelector) ([]ScalableResource, error)
+	Update(context.Context, ScalableResource) error
+	ListMachines(context.Context, ScalableResource, *metav1.LabelSelector) ([]*capiv1beta1.Machine, error)
+}
+
+// DefaultProvider supports MachineDeployments, MachinePools, MachineSets which are not owned by a
+// MachineDeployment, and the additional kinds from the --scalable-resource-types option through the
+// scale subresource.
+type DefaultProvider struct {
+	kubeClient                client.Client
+	machineProvider           machine.Provider
+	machineDeploymentProvider machinedeployment.Provider
+	machinePoolProvider       machinepool.Provider
+	scaleKinds                []schema.GroupVersionKind
+}
+
+func NewDefaultProvider(ctx context.Context, kubeClient client.Client, machineProvider machine.Provider, machineDeploymentProvider machinedeployment.Provider, machinePoolProvider machinepool.Provider) *DefaultProvider {
+	return &DefaultProvider{
+		kubeClient:                kubeClient,
+		machineProvider:           machineProvider,
+		machineDeploymentProvider: machineDeploymentProvider,
+		machinePoolProvider:       machinePoolProvider,
+		scaleKinds:                ScaleKindsFromOptions(ctx),
+	}
+}
+
+// ScaleKindsFromOptions returns the kinds from the --scalable-resource-types option, which are scaled through
+// their scale subresource.
+func ScaleKindsFromOptions(ctx context.Context) []schema.GroupVersionKind {
+	opts := options.FromContext(ctx)
+	if opts == nil {
+		return nil
+	}
+	// the option is validated when it is parsed.
+	kinds, _ := options.ParseScalableResourceTypes(opts.ScalableResourceTypes)
+	return kinds
+}
+
+func (p *DefaultProvider) Get(ctx context.Context, ref Reference) (ScalableResource, error) {
+	if !ref.isClusterAPIKind() {
+		return p.getScale(ctx, schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind), ref.Name, ref.Namespace)
+	}
+
+	switch ref.Kind {
+	case providers.MachinePoolKind:
+		if p.machinePoolProvider == nil {
+			return nil, fmt.Errorf("unable to get MachinePool %s in namespace %s: MachinePools are not supported", ref.Name, ref.Namespace)
+		}
+		machinePool, err := p.machinePoolProvider.Get(ctx, ref.Name, ref.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &machinePoolResource{machinePool: machinePool}, nil
+	case providers.MachineSetKind:
+		machineSet := &capiv1beta1.MachineSet{}
+		if err := p.kubeClient.Get(ctx, client.ObjectKey{Name: ref.Name, Namespace: ref.Namespace}, machineSet); err != nil {
+			return nil, fmt.Errorf("unable to get MachineSet %s in namespace %s: %w", ref.Name, ref.Namespace, err)
+		}
+		return &machineSetResource{machineSet: machineSet}, nil
+	default:
+		machineDeployment, err := p.machineDeploymentProvider.Get(ctx, ref.Name, ref.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &machineDeploymentResource{machineDeployment: machineDeployment}, nil
+	}
+}
+
+// GetForMachine returns the scalable resource that manages the Machine, or nil if there is none. The Cluster API
+// labels on the Machine are checked first, followed by the MachinePools and the resources of the additional kinds.
+func (p *DefaultProvider) GetForMachine(ctx context.Context, m *capiv1beta1.Machine) (ScalableResource, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	if name, found := m.GetLabels()[capiv1beta1.MachineDeploymentNameLabel]; found {
+		return p.Get(ctx, NewReference("", providers.MachineDeploymentKind, name, m.GetNamespace()))
+	}
+
+	if name, found := m.GetLabels()[capiv1beta1.MachineSetNameLabel]; found {
+		return p.Get(ctx, NewReference("", providers.MachineSetKind, name, m.GetNamespace()))
+	}
+
+	if p.machinePoolProvider != nil {
+		machinePool, err := p.machinePoolProvider.GetForMachine(ctx, m)
+		if err != nil && !meta.IsNoMatchError(err) {
+			return nil, err
+		}
+		if machinePool != nil {
+			return &machinePoolResource{machinePool: machinePool}, nil
+		}
+	}
+
+	for _, gvk := range p.scaleKinds {
+		resources, err := p.listScale(ctx, gvk, nil, client.InNamespace(m.GetNamespace()))
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range resources {
+			selector, err := metav1.LabelSelectorAsSelector(r.MachineSelector())
+			if err != nil || selector.Empty() {
+				continue
+			}
+			if selector.Matches(labels.Set(m.GetLabels())) {
+				return r, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// List returns the scalable resources with the NodePoolMemberLabel which match the selector. MachinePools and the
+// resources of the additional kinds are skipped when their CustomResourceDefinition is not installed.
+func (p *DefaultProvider) List(ctx context.Context, selector *metav1.LabelSelector) ([]ScalableResource, error) {
+	resources := []ScalableResource{}
+
+	machineDeployments, err := p.machineDeploymentProvider.List(ctx, selector)
+	if err != nil {
+		return resources, err
+	}
+	for _, md := range machineDeployments {
+		resources = append(resources, &machineDeploymentResource{machineDeployment: md})
+	}
+
+	machineSets, err := p.listMachineSets(ctx, selector)
+	if err != nil {
+		return resources, err
+	}
+	resources = append(resources, machineSets...)
+
+	if p.machinePoolProvider != nil {
+		machinePools, err := p.machinePoolProvider.List(ctx, selector)
+		if err != nil && !meta.IsNoMatchError(err) {
+			return resources, err
+		}
+		for _, mp := range machinePools {
+			resources = append(resources, &machinePoolResource{machinePool: mp})
+		}
+	}
+
+	for _, gvk := range p.scaleKinds {
+		scaleResources, err := p.listScale(ctx, gvk, selector)
+		if err != nil {
+			return resources, err
+		}
+		resources = append(resources, scaleResources...)
+	}
+
+	return resources, nil
+}
+
+func (p *DefaultProvider) Update(ctx context.Context, resource ScalableResource) error {
+	switch r := resource.(type) {
+	case *machineDeploymentResource:
+		return p.machineDeploymentProvider.Update(ctx, r.machineDeployment)
+	case *machinePoolResource:
+		if p.machinePoolProvider == nil {
+			return fmt.Errorf("unable to update MachinePool %q: MachinePools are not supported", r.machinePool.Name)
+		}
+		return p.machinePoolProvider.Update(ctx, r.machinePool)
+	case *machineSetResource:
+		if err := p.kubeClient.Update(ctx, r.machineSet); err != nil {
+			return fmt.Errorf("unable to update MachineSet %q: %w", r.machineSet.Name, err)
+		}
+		return nil
+	case *scaleResource:
+		if err := p.kubeClient.SubResource("scale").Update(ctx, r.object, client.WithSubResourceBody(r.scale)); err != nil {
+			return fmt.Errorf("unable to update the scale of %s %q: %w", r.object.GetKind(), r.object.GetName(), err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unable to update scalable resource %q, unknown type %T", resource.Reference().Key(), resource)
+	}
+}
+
+// ListMachines returns the Machines of the scalable resource which also match the selector.
+func (p *DefaultProvider) ListMachines(ctx context.Context, resource ScalableResource, selector *metav1.LabelSelector) ([]*capiv1beta1.Machine, error) {
+	machineSelector := resource.MachineSelector()
+	if machineSelector == nil || (len(machineSelector.MatchLabels) == 0 && len(machineSelector.MatchExpressions) == 0) {
+		return nil, fmt.Errorf("unable to list Machines of %s %q, it does not have a Machine selector", resource.Reference().Kind, resource.Reference().Name)
+	}
+
+	merged := machineSelector.DeepCopy()
+	if selector != nil {
+		for k, v := range selector.MatchLabels {
+			if merged.MatchLabels == nil {
+				merged.MatchLabels = map[string]string{}
+			}
+			merged.MatchLabels[k] = v
+		}
+		merged.MatchExpressions = append(merged.MatchExpressions, selector.MatchExpressions...)
+	}
+
+	return p.machineProvider.List(ctx, resource.Reference().Namespace, merged)
+}
+
+// listMachineSets returns the MachineSets with the NodePoolMemberLabel which match the selector, and which are not
+// owned by a MachineDeployment. MachineSets created by a MachineDeployment copy the labels of its Machine template.
+func (p *DefaultProvider) listMachineSets(ctx context.Context, selector *metav1.LabelSelector) ([]ScalableResource, error) {
+	resources := []ScalableResource{}
+
+	sm, err := providers.MemberSelector(selector)
+	if err != nil {
+		return resources, fmt.Errorf("unable to convert selector in MachineSet List: %w", err)
+	}
+	listOptions := []client.ListOption{&client.ListOptions{LabelSelector: sm}}
+
+	machineSetList := &capiv1beta1.MachineSetList{}
+	if err := p.kubeClient.List(ctx, machineSetList, listOptions...); err != nil {
+		return resources, fmt.Errorf("unable to list MachineSets with selector: %w", err)
+	}
+
+	for i := range machineSetList.Items {
+		ms := &machineSetList.Items[i]
+		if isOwnedByMachineDeployment(ms) {
+			continue
+		}
+		resources = append(resources, &machineSetResource{machineSet: ms})
+	}
+
+	return resources, nil
+}
+
+func isOwnedByMachineDeployment(machineSet *capiv1beta1.MachineSet) bool {
+	if _, found := machineSet.GetLabels()[capiv1beta1.MachineDeploymentNameLabel]; found {
+		return true
+	}
+	for _, ref := range machineSet.GetOwnerReferences() {
+		if ref.Kind == providers.MachineDeploymentKind {
+			return true
+		}
+	}
+	return false
+}
+
+// getScale returns a resource of a kind outside of Cluster API through its scale subresource.
+func (p *DefaultProvider) getScale(ctx context.Context, gvk schema.GroupVersionKind, name, namespace string) (ScalableResource, error) {
+	object := &unstructured.Unstructured{}
+	object.SetGroupVersionKind(gvk)
+	if err := p.kubeClient.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, object); err != nil {
+		return nil, fmt.Errorf("unable to get %s %s in namespace %s: %w", gvk.Kind, name, namespace, err)
+	}
+	return p.scaleForObject(ctx, object)
+}
+
+// listScale returns the resources of a kind outside of Cluster API with the NodePoolMemberLabel which match the selector.
+func (p *DefaultProvider) listScale(ctx context.Context, gvk schema.GroupVersionKind, selector *metav1.LabelSelector, opts ...client.ListOption) ([]ScalableResource, error) {
+	resources := []ScalableResource{}
+
+	sm, err := providers.MemberSelector(selector)
+	if err != nil {
+		return resources, fmt.Errorf("unable to convert selector in %s List: %w", gvk.Kind, err)
+	}
+	listOptions := append([]client.ListOption{&client.ListOptions{LabelSelector: sm}}, opts...)
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := p.kubeClient.List(ctx, list, listOptions...); err != nil {
+		if meta.IsNoMatchError(err) {
+			log.FromContext(ctx).V(1).Info("scalable resource type is not installed, skipping", "kind", gvk.String())
+			return resources, nil
+		}
+		return resources, fmt.Errorf("unable to list %s with selector: %w", gvk.Kind, err)
+	}
+
+	for i := range list.Items {
+		r, err := p.scaleForObject(ctx, &list.Items[i])
+		if err != nil {
+			return resources, err
+		}
+		resources = append(resources, r)
+	}
+
+	return resources, nil
+}
+
+func (p *DefaultProvider) scaleForObject(ctx context.Context, object *unstructured.Unstructured) (ScalableResource, error) {
+	scale := &autoscalingv1.Scale{}
+	if err := p.kubeClient.SubResource("scale").Get(ctx, object, scale); err != nil {
+		return nil, fmt.Errorf("unable to get the scale of %s %s in namespace %s: %w", object.GetKind(), object.GetName(), object.GetNamespace(), err)
+	}
+	return &scaleResource{object: object, scale: scale}, nil
+}
@@ -0,0 +1,467 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scalableresource
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	capiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	expv1beta1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/operator/options"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machine"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machinedeployment"
+	"sigs.k8s.io/karpenter-provider-cluster-api/pkg/providers/machinepool"
+)
+
+func eventuallyDeleteAllOf(obj client.Object, ls client.ObjectList) {
+	Expect(cl.DeleteAllOf(context.Background(), obj, client.InNamespace(testNamespace))).To(Succeed())
+	Eventually(func() client.ObjectList {
+		Expect(cl.List(context.Background(), ls, client.InNamespace(testNamespace))).To(Succeed())
+		return ls
+	}).Should(HaveField("Items", HaveLen(0)))
+}
+
+func newDefaultProvider(ctx context.Context, kubeClient client.Client) *DefaultProvider {
+	return NewDefaultProvider(ctx, kubeClient,
+		machine.NewDefaultProvider(ctx, kubeClient),
+		machinedeployment.NewDefaultProvider(ctx, kubeClient),
+		machinepool.NewDefaultProvider(ctx, kubeClient))
+}
+
+var _ = Describe("Reference.Key method", func() {
+	It("keeps the namespace and name key for MachineDeployments", func() {
+		Expect(NewReference("", "", "md-1", "ns").Key()).To(Equal("ns/md-1"))
+		Expect(NewReference("", providers.MachineDeploymentKind, "md-1", "ns").Key()).To(Equal("ns/md-1"))
+	})
+
+	It("prefixes the kind for the other Cluster API kinds", func() {
+		Expect(NewReference("", providers.MachineSetKind, "ms-1", "ns").Key()).To(Equal("MachineSet/ns/ms-1"))
+		Expect(NewReference(expv1beta1.GroupVersion.String(), providers.MachinePoolKind, "mp-1", "ns").Key()).To(Equal("MachinePool/ns/mp-1"))
+	})
+
+	It("prefixes the kind and API version for other kinds", func() {
+		Expect(NewReference("example.com/v1", "NodeGroup", "ng-1", "ns").Key()).To(Equal("NodeGroup.example.com/v1/ns/ng-1"))
+		// a kind with the same name in another group is not a Cluster API kind.
+		Expect(NewReference("example.com/v1", providers.MachineSetKind, "ms-1", "ns").Key()).To(Equal("MachineSet.example.com/v1/ns/ms-1"))
+	})
+})
+
+var _ = Describe("ScalableResource.MachineDeployment method", func() {
+	It("copies the metadata, replicas and template of a MachinePool", func() {
+		machinePool := newMachinePool("mp-1", true)
+		machinePool.SetAnnotations(map[string]string{"capacity.cluster-autoscaler.kubernetes.io/cpu": "4"})
+		machinePool.Spec.Replicas = ptr.To(int32(3))
+		machinePool.Spec.Template.Labels = map[string]string{"node.kubernetes.io/instance-type": "m5.xlarge"}
+
+		machineDeployment := NewMachinePoolResource(machinePool).MachineDeployment()
+		Expect(machineDeployment.Name).To(Equal(machinePool.Name))
+		Expect(machineDeployment.Namespace).To(Equal(machinePool.Namespace))
+		Expect(machineDeployment.GetAnnotations()).To(HaveKeyWithValue("capacity.cluster-autoscaler.kubernetes.io/cpu", "4"))
+		Expect(machineDeployment.Spec.ClusterName).To(Equal("test-cluster"))
+		Expect(machineDeployment.Spec.Replicas).To(Equal(ptr.To(int32(3))))
+		Expect(machineDeployment.Spec.Template.Labels).To(HaveKeyWithValue("node.kubernetes.io/instance-type", "m5.xlarge"))
+	})
+
+	It("uses a single failure domain of the MachinePool as the zone", func() {
+		machinePool := newMachinePool("mp-1", true)
+		machinePool.Spec.FailureDomains = []string{"zone-a"}
+
+		machineDeployment := NewMachinePoolResource(machinePool).MachineDeployment()
+		Expect(machineDeployment.Spec.Template.Spec.FailureDomain).To(Equal(ptr.To("zone-a")))
+	})
+
+	It("does not set a zone when the MachinePool has multiple failure domains", func() {
+		machinePool := newMachinePool("mp-1", true)
+		machinePool.Spec.FailureDomains = []string{"zone-a", "zone-b"}
+
+		machineDeployment := NewMachinePoolResource(machinePool).MachineDeployment()
+		Expect(machineDeployment.Spec.Template.Spec.FailureDomain).To(BeNil())
+	})
+
+	It("copies the metadata, replicas and template of a MachineSet", func() {
+		machineSet := newMachineSet("ms-1", true)
+		machineSet.Spec.Replicas = ptr.To(int32(2))
+		machineSet.Spec.Template.Spec.FailureDomain = ptr.To("zone-a")
+
+		machineDeployment := NewMachineSetResource(machineSet).MachineDeployment()
+		Expect(machineDeployment.Name).To(Equal(machineSet.Name))
+		Expect(machineDeployment.GetLabels()).To(HaveKey(providers.NodePoolMemberLabel))
+		Expect(machineDeployment.Spec.Replicas).To(Equal(ptr.To(int32(2))))
+		Expect(machineDeployment.Spec.Template.Spec.FailureDomain).To(Equal(ptr.To("zone-a")))
+	})
+
+	It("reads the replicas from the scale subresource and the template from the spec", func() {
+		resource := &scaleResource{object: newNodeGroup("ng-1", true), scale: &autoscalingv1.Scale{Spec: autoscalingv1.ScaleSpec{Replicas: 4}}}
+
+		machineDeployment := resource.MachineDeployment()
+		Expect(machineDeployment.Name).To(Equal("ng-1"))
+		Expect(machineDeployment.Spec.ClusterName).To(Equal("test-cluster"))
+		Expect(machineDeployment.Spec.Replicas).To(Equal(ptr.To(int32(4))))
+		Expect(machineDeployment.Spec.Template.Spec.FailureDomain).To(Equal(ptr.To("zone-a")))
+	})
+
+	It("does not share the labels and annotations with the resource", func() {
+		object := newNodeGroup("ng-1", true)
+		object.SetAnnotations(map[string]string{"example.com/annotation": "value"})
+		resource := &scaleResource{object: object, scale: &autoscalingv1.Scale{}}
+
+		machineDeployment := resource.MachineDeployment()
+		machineDeployment.Labels["example.com/label"] = "changed"
+		machineDeployment.Annotations["example.com/annotation"] = "changed"
+		Expect(object.GetLabels()).ToNot(HaveKey("example.com/label"))
+		Expect(object.GetAnnotations()).To(HaveKeyWithValue("example.com/annotation", "value"))
+	})
+})
+
+var _ = Describe("MinSize function", func() {
+	newResource := func(minSize string) ScalableResource {
+		machineDeployment := &capiv1beta1.MachineDeployment{}
+		machineDeployment.SetAnnotations(map[string]string{capiv1beta1.AutoscalerMinSizeAnnotation: minSize})
+		return NewMachineDeploymentResource(machineDeployment)
+	}
+
+	It("returns the minimum size from the annotation", func() {
+		minSize, found := MinSize(context.Background(), newResource("2"))
+		Expect(found).To(BeTrue())
+		Expect(minSize).To(Equal(int32(2)))
+	})
+
+	It("returns false when the annotation is missing or invalid", func() {
+		_, found := MinSize(context.Background(), NewMachineDeploymentResource(&capiv1beta1.MachineDeployment{}))
+		Expect(found).To(BeFalse())
+		_, found = MinSize(context.Background(), newResource("two"))
+		Expect(found).To(BeFalse())
+		_, found = MinSize(context.Background(), newResource("-1"))
+		Expect(found).To(BeFalse())
+	})
+
+	It("returns false when the check is disabled", func() {
+		opts := &options.Options{DisableMinSizeCheck: true}
+		_, found := MinSize(opts.ToContext(context.Background()), newResource("2"))
+		Expect(found).To(BeFalse())
+	})
+})
+
+var _ = Describe("ScalableResource DefaultProvider.List method", func() {
+	var provider *DefaultProvider
+
+	BeforeEach(func() {
+		provider = newDefaultProvider(context.Background(), cl)
+	})
+
+	AfterEach(func() {
+		eventuallyDeleteAllOf(&capiv1beta1.MachineDeployment{}, &capiv1beta1.MachineDeploymentList{})
+		eventuallyDeleteAllOf(&capiv1beta1.MachineSet{}, &capiv1beta1.MachineSetList{})
+		eventuallyDeleteAllOf(&expv1beta1.MachinePool{}, &expv1beta1.MachinePoolList{})
+	})
+
+	It("returns the MachineDeployments, standalone MachineSets and MachinePools with the member label", func() {
+		Expect(cl.Create(context.Background(), newMachineDeployment("md-1", true))).To(Succeed())
+		Expect(cl.Create(context.Background(), newMachineDeployment("md-2", false))).To(Succeed())
+		Expect(cl.Create(context.Background(), newMachineSet("ms-1", true))).To(Succeed())
+		Expect(cl.Create(context.Background(), newMachineSet("ms-2", false))).To(Succeed())
+		Expect(cl.Create(context.Background(), newMachinePool("mp-1", true))).To(Succeed())
+
+		resources, err := provider.List(context.Background(), nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		keys := []string{}
+		for _, r := range resources {
+			keys = append(keys, r.Reference().Key())
+		}
+		Expect(keys).To(ConsistOf(testNamespace+"/md-1", "MachineSet/"+testNamespace+"/ms-1", "MachinePool/"+testNamespace+"/mp-1"))
+	})
+
+	It("skips MachineSets which are owned by a MachineDeployment", func() {
+		labeled := newMachineSet("ms-1", true)
+		labeled.GetLabels()[capiv1beta1.MachineDeploymentNameLabel] = "md-1"
+		Expect(cl.Create(context.Background(), labeled)).To(Succeed())
+
+		owned := newMachineSet("ms-2", true)
+		owned.SetOwnerReferences([]metav1.OwnerReference{{
+			APIVersion: capiv1beta1.GroupVersion.String(),
+			Kind:       providers.MachineDeploymentKind,
+			Name:       "md-2",
+			UID:        "md-2-uid",
+		}})
+		Expect(cl.Create(context.Background(), owned)).To(Succeed())
+
+		resources, err := provider.List(context.Background(), nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resources).To(BeEmpty())
+	})
+
+	It("filters the resources with the selector", func() {
+		ms := newMachineSet("ms-1", true)
+		ms.GetLabels()["env"] = "test"
+		Expect(cl.Create(context.Background(), ms)).To(Succeed())
+		Expect(cl.Create(context.Background(), newMachineSet("ms-2", true))).To(Succeed())
+
+		resources, err := provider.List(context.Background(), &metav1.LabelSelector{MatchLabels: map[string]string{"env": "test"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resources).To(HaveLen(1))
+		Expect(resources[0].Reference().Name).To(Equal("ms-1"))
+	})
+})
+
+var _ = Describe("ScalableResource DefaultProvider.GetForMachine method", func() {
+	var provider *DefaultProvider
+
+	BeforeEach(func() {
+		provider = newDefaultProvider(context.Background(), cl)
+	})
+
+	AfterEach(func() {
+		eventuallyDeleteAllOf(&capiv1beta1.MachineDeployment{}, &capiv1beta1.MachineDeploymentList{})
+		eventuallyDeleteAllOf(&capiv1beta1.MachineSet{}, &capiv1beta1.MachineSetList{})
+		eventuallyDeleteAllOf(&expv1beta1.MachinePool{}, &expv1beta1.MachinePoolList{})
+	})
+
+	It("returns the MachineDeployment before the MachineSet of the Machine", func() {
+		Expect(cl.Create(context.Background(), newMachineDeployment("md-1", true))).To(Succeed())
+		Expect(cl.Create(context.Background(), newMachineSet("ms-1", true))).To(Succeed())
+
+		m := newMachine("m-1", map[string]string{
+			capiv1beta1.MachineDeploymentNameLabel: "md-1",
+			capiv1beta1.MachineSetNameLabel:        "ms-1",
+		})
+		resource, err := provider.GetForMachine(context.Background(), m)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resource.Reference().Kind).To(Equal(providers.MachineDeploymentKind))
+	})
+
+	It("returns the MachineSet of a Machine without a MachineDeployment", func() {
+		Expect(cl.Create(context.Background(), newMachineSet("ms-1", true))).To(Succeed())
+
+		m := newMachine("m-1", map[string]string{capiv1beta1.MachineSetNameLabel: "ms-1"})
+		resource, err := provider.GetForMachine(context.Background(), m)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resource.Reference()).To(Equal(NewReference("", providers.MachineSetKind, "ms-1", testNamespace)))
+	})
+
+	It("returns the MachinePool of the Machine", func() {
+		Expect(cl.Create(context.Background(), newMachinePool("mp-1", true))).To(Succeed())
+
+		m := newMachine("m-1", map[string]string{capiv1beta1.MachinePoolNameLabel: "mp-1"})
+		resource, err := provider.GetForMachine(context.Background(), m)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resource.Reference().Kind).To(Equal(providers.MachinePoolKind))
+	})
+
+	It("returns nil when no resource manages the Machine", func() {
+		resource, err := provider.GetForMachine(context.Background(), newMachine("m-1", nil))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resource).To(BeNil())
+	})
+})
+
+var _ = Describe("ScalableResource DefaultProvider.Update method", func() {
+	var provider *DefaultProvider
+
+	BeforeEach(func() {
+		provider = newDefaultProvider(context.Background(), cl)
+	})
+
+	AfterEach(func() {
+		eventuallyDeleteAllOf(&capiv1beta1.MachineSet{}, &capiv1beta1.MachineSetList{})
+	})
+
+	It("updates the replicas of a MachineSet", func() {
+		Expect(cl.Create(context.Background(), newMachineSet("ms-1", true))).To(Succeed())
+
+		resource, err := provider.Get(context.Background(), NewReference("", providers.MachineSetKind, "ms-1", testNamespace))
+		Expect(err).ToNot(HaveOccurred())
+		resource.SetReplicas(3)
+		Expect(provider.Update(context.Background(), resource)).To(Succeed())
+
+		ms := &capiv1beta1.MachineSet{}
+		Expect(cl.Get(context.Background(), client.ObjectKey{Name: "ms-1", Namespace: testNamespace}, ms)).To(Succeed())
+		Expect(ms.Spec.Replicas).To(Equal(ptr.To(int32(3))))
+	})
+})
+
+var _ = Describe("ScalableResource DefaultProvider.ListMachines method", func() {
+	var provider *DefaultProvider
+
+	BeforeEach(func() {
+		provider = newDefaultProvider(context.Background(), cl)
+	})
+
+	AfterEach(func() {
+		eventuallyDeleteAllOf(&capiv1beta1.Machine{}, &capiv1beta1.MachineList{})
+	})
+
+	It("returns the Machines of the resource which match the selector", func() {
+		Expect(cl.Create(context.Background(), newMachine("m-1", map[string]string{capiv1beta1.MachineSetNameLabel: "ms-1"}))).To(Succeed())
+		Expect(cl.Create(context.Background(), newMachine("m-2", map[string]string{capiv1beta1.MachineSetNameLabel: "ms-1", "claimed": "true"}))).To(Succeed())
+		Expect(cl.Create(context.Background(), newMachine("m-3", map[string]string{capiv1beta1.MachineSetNameLabel: "ms-2"}))).To(Succeed())
+
+		resource := NewMachineSetResource(newMachineSet("ms-1", true))
+		machines, err := provider.ListMachines(context.Background(), resource, &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "claimed", Operator: metav1.LabelSelectorOpDoesNotExist}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(machines).To(HaveLen(1))
+		Expect(machines[0].Name).To(Equal("m-1"))
+	})
+
+	It("returns an error when the resource does not have a Machine selector", func() {
+		resource := &scaleResource{object: newNodeGroup("ng-1", true), scale: &autoscalingv1.Scale{}}
+		_, err := provider.ListMachines(context.Background(), resource, nil)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("ScalableResource DefaultProvider with scale subresource kinds", func() {
+	var provider *DefaultProvider
+	var kubeClient client.Client
+	var scales map[string]*autoscalingv1.Scale
+
+	BeforeEach(func() {
+		scales = map[string]*autoscalingv1.Scale{}
+		kubeClient = fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithObjects(newNodeGroup("ng-1", true), newNodeGroup("ng-2", false)).
+			WithInterceptorFuncs(interceptor.Funcs{
+				SubResourceGet: func(_ context.Context, _ client.Client, _ string, obj client.Object, subResource client.Object, _ ...client.SubResourceGetOption) error {
+					scale, found := scales[obj.GetName()]
+					if !found {
+						scale = &autoscalingv1.Scale{Spec: autoscalingv1.ScaleSpec{Replicas: 1}, Status: autoscalingv1.ScaleStatus{Selector: "example.com/node-group=" + obj.GetName()}}
+					}
+					scale.DeepCopyInto(subResource.(*autoscalingv1.Scale))
+					return nil
+				},
+				SubResourceUpdate: func(_ context.Context, _ client.Client, _ string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+					updateOptions := &client.SubResourceUpdateOptions{}
+					updateOptions.ApplyOptions(opts)
+					scales[obj.GetName()] = updateOptions.SubResourceBody.(*autoscalingv1.Scale)
+					return nil
+				},
+			}).Build()
+
+		opts := &options.Options{ScalableResourceTypes: "NodeGroup.v1.example.com"}
+		ctx := opts.ToContext(context.Background())
+		provider = NewDefaultProvider(ctx, kubeClient, machine.NewDefaultProvider(ctx, kubeClient), machinedeployment.NewDefaultProvider(ctx, kubeClient), nil)
+	})
+
+	It("lists the resources of the configured kinds with the member label", func() {
+		resources, err := provider.List(context.Background(), nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resources).To(HaveLen(1))
+		Expect(resources[0].Reference()).To(Equal(NewReference("example.com/v1", "NodeGroup", "ng-1", testNamespace)))
+		Expect(resources[0].Replicas()).To(Equal(ptr.To(int32(1))))
+	})
+
+	It("returns the resource whose selector matches the Machine", func() {
+		resource, err := provider.GetForMachine(context.Background(), newMachine("m-1", map[string]string{"example.com/node-group": "ng-1"}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resource).ToNot(BeNil())
+		Expect(resource.Reference().Name).To(Equal("ng-1"))
+	})
+
+	It("updates the replicas through the scale subresource", func() {
+		resource, err := provider.Get(context.Background(), NewReference("example.com/v1", "NodeGroup", "ng-1", testNamespace))
+		Expect(err).ToNot(HaveOccurred())
+		resource.SetReplicas(5)
+		Expect(provider.Update(context.Background(), resource)).To(Succeed())
+		Expect(scales).To(HaveKey("ng-1"))
+		Expect(scales["ng-1"].Spec.Replicas).To(Equal(int32(5)))
+	})
+})
+
+func newMachineDeployment(name string, karpenterMember bool) *capiv1beta1.MachineDeployment {
+	machineDeployment := &capiv1beta1.MachineDeployment{}
+	machineDeployment.SetName(name)
+	machineDeployment.SetNamespace(testNamespace)
+	if karpenterMember {
+		machineDeployment.SetLabels(map[string]string{providers.NodePoolMemberLabel: ""})
+	}
+	machineDeployment.Spec.ClusterName = "test-cluster"
+	machineDeployment.Spec.Template.Spec.ClusterName = "test-cluster"
+	return machineDeployment
+}
+
+func newMachineSet(name string, karpenterMember bool) *capiv1beta1.MachineSet {
+	machineSet := &capiv1beta1.MachineSet{}
+	machineSet.SetName(name)
+	machineSet.SetNamespace(testNamespace)
+	machineSet.SetLabels(map[string]string{})
+	if karpenterMember {
+		machineSet.GetLabels()[providers.NodePoolMemberLabel] = ""
+	}
+	machineSet.Spec.ClusterName = "test-cluster"
+	machineSet.Spec.Selector.MatchLabels = map[string]string{capiv1beta1.MachineSetNameLabel: name}
+	machineSet.Spec.Template.Labels = map[string]string{capiv1beta1.MachineSetNameLabel: name}
+	machineSet.Spec.Template.Spec.ClusterName = "test-cluster"
+	return machineSet
+}
+
+func newMachinePool(name string, karpenterMember bool) *expv1beta1.MachinePool {
+	machinePool := &expv1beta1.MachinePool{}
+	machinePool.SetName(name)
+	machinePool.SetNamespace(testNamespace)
+	if karpenterMember {
+		machinePool.SetLabels(map[string]string{providers.NodePoolMemberLabel: ""})
+	}
+	machinePool.Spec.ClusterName = "test-cluster"
+	machinePool.Spec.Template.Spec.ClusterName = "test-cluster"
+	return machinePool
+}
+
+func newMachine(name string, labels map[string]string) *capiv1beta1.Machine {
+	m := &capiv1beta1.Machine{}
+	m.SetName(name)
+	m.SetNamespace(testNamespace)
+	m.SetLabels(labels)
+	m.Spec.ClusterName = "test-cluster"
+	return m
+}
+
+// newNodeGroup returns a resource of a kind outside of Cluster API with a Machine template.
+func newNodeGroup(name string, karpenterMember bool) *unstructured.Unstructured {
+	nodeGroup := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"clusterName": "test-cluster",
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"clusterName":   "test-cluster",
+					"failureDomain": "zone-a",
+				},
+			},
+		},
+	}}
+	nodeGroup.SetAPIVersion("example.com/v1")
+	nodeGroup.SetKind("NodeGroup")
+	nodeGroup.SetName(name)
+	nodeGroup.SetNamespace(testNamespace)
+	if karpenterMember {
+		nodeGroup.SetLabels(map[string]string{providers.NodePoolMemberLabel: ""})
+	}
+	return nodeGroup
+}